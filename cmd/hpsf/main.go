@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/honeycombio/hpsf/pkg/config/tmpl"
@@ -20,11 +22,15 @@ import (
 )
 
 type Options struct {
-	Verbose bool     `short:"v" long:"verbose" description:"enable verbose mode"`
-	Input   string   `short:"i" long:"input" description:"input file" default:"-"`
-	Output  string   `short:"o" long:"output" description:"output file" default:"-"`
-	Subs    []string `short:"s" long:"sub" description:"substitutions in the form 'context.varname=value'; can be repeated"`
-	Data    []string `short:"d" long:"data" description:"data in the form 'key=value'; can be repeated"`
+	Verbose        bool     `short:"v" long:"verbose" description:"enable verbose mode"`
+	Input          string   `short:"i" long:"input" description:"input file" default:"-"`
+	Output         string   `short:"o" long:"output" description:"output file" default:"-"`
+	Subs           []string `short:"s" long:"sub" description:"substitutions in the form 'context.varname=value'; can be repeated"`
+	Data           []string `short:"d" long:"data" description:"data in the form 'key=value'; can be repeated"`
+	Format         string   `short:"f" long:"format" description:"output format for validate: text, json, or sarif" default:"text"`
+	Target         string   `short:"t" long:"target" description:"format version for migrate; defaults to the latest"`
+	DryRun         bool     `short:"n" long:"dry-run" description:"for migrate, describe the changes instead of writing the migrated document"`
+	LiteralSecrets bool     `long:"literal-secrets" description:"for cConfig, keep secrets written into the document instead of replacing them with environment variable references"`
 }
 
 func main() {
@@ -85,6 +91,18 @@ func main() {
 	subst.SetPriority("cluster", 1)
 	input := subst.DoSubstitutions(string(inputData))
 
+	// Some commands (diff, merge) take the names of other files to read.
+	// These are read before the output file is created so that the output
+	// can replace one of them, which is what git merge drivers expect.
+	otherInputs := make([]string, 0, len(cmds)-1)
+	for _, name := range cmds[1:] {
+		data, err := readInput(name)
+		if err != nil {
+			log.Fatalf("error reading %s: %v", name, err)
+		}
+		otherInputs = append(otherInputs, subst.DoSubstitutions(string(data)))
+	}
+
 	// Create the output file
 	var outf io.Writer
 	var f *os.File
@@ -112,6 +130,7 @@ func main() {
 	}
 	// install the components
 	tr.InstallComponents(components)
+	tr.SetLiteralSecrets(cmdopts.LiteralSecrets)
 
 	switch cmds[0] {
 	case "format":
@@ -165,30 +184,200 @@ func main() {
 	case "validate":
 		err = hpsf.EnsureHPSFYAML(string(inputData))
 		if err != nil {
-			log.Fatalf("input file is not hpsf: %v", err)
+			// validation failures are reported in detail below
+			if _, ok := err.(validator.Result); !ok {
+				log.Fatalf("input file is not hpsf: %v", err)
+			}
 		}
 
-		var h hpsf.HPSF
-		err = y.Unmarshal(inputData, &h)
+		// keep the file name so that errors can be reported as file:line:column
+		filename := cmdopts.Input
+		if filename == "-" {
+			filename = ""
+		}
+		h, err := hpsf.FromNamedYAML(filename, string(inputData))
 		if err != nil {
 			log.Fatalf("error unmarshaling to HPSF: %v", err)
 		}
 
-		// validate the HPSF
-		if verrors := h.Validate(); verrors != nil {
-			if hErr, ok := verrors.(validator.Result); ok {
+		// validate the HPSF, and if that passes, check it against the components
+		verrors := h.Validate()
+		if verrors == nil {
+			var warnings validator.Result
+			warnings, verrors = tr.ValidateConfigWithWarnings(&h)
+			// the warnings are reported along with any errors
+			if result, ok := verrors.(validator.Result); ok {
+				result.Details = append(result.Details, warnings.Details...)
+				verrors = result
+			} else if verrors == nil {
+				verrors = warnings.ErrOrNil()
+			}
+		}
+
+		switch cmdopts.Format {
+		case "json", "sarif":
+			report := hpsf.NewReport(verrors)
+			var data []byte
+			if cmdopts.Format == "json" {
+				data, err = report.JSON()
+			} else {
+				data, err = report.SARIF()
+			}
+			if err != nil {
+				log.Fatalf("error marshaling report: %v", err)
+			}
+			_, err = outf.Write(data)
+			if err != nil {
+				log.Fatalf("error writing output file: %v", err)
+			}
+			if !report.Valid {
+				os.Exit(1)
+			}
+			return
+		case "text":
+		default:
+			log.Fatalf("unknown format: %s", cmdopts.Format)
+		}
+
+		// warnings are reported but don't fail validation
+		if verrors != nil {
+			hErr, ok := verrors.(validator.Result)
+			if !ok {
+				log.Printf("unexpected validation error: %v", verrors)
+				os.Exit(1)
+			}
+			failed := !hpsf.OnlyWarnings(hErr)
+			if failed {
 				log.Printf("error: %v", hErr.Msg)
-				for _, e := range hErr.Details {
+			}
+			for _, e := range hErr.Details {
+				if hpsf.OnlyWarnings(e) {
+					log.Printf("  warning: %v", e)
+				} else {
 					log.Printf("  error: %v", e)
 				}
+			}
+			if failed {
 				os.Exit(1)
 			}
+		}
 
-			log.Printf("unexpected validation error: %v", verrors)
+		log.Printf("HPSF is valid")
+
+	case "fix":
+		// upgrades old components and repairs the problems that have only one
+		// sensible fix, and logs what it changed
+		h, err := hpsf.FromYAML(input)
+		if err != nil {
+			log.Fatalf("error unmarshaling input file: %v", err)
+		}
+		upgraded, fixes := tr.Upgrade(&h)
+		fixed, normalized := tr.Normalize(upgraded)
+		fixes = append(fixes, normalized...)
+		data, err := y.Marshal(fixed)
+		if err != nil {
+			log.Fatalf("error marshaling output file: %v", err)
+		}
+		_, err = outf.Write(data)
+		if err != nil {
+			log.Fatalf("error writing output file: %v", err)
+		}
+		for _, fix := range fixes {
+			log.Printf("fixed: %v", fix)
+		}
+		if len(fixes) == 0 {
+			log.Printf("nothing to fix")
+		}
+
+	case "migrate":
+		// upgrades the input to a newer version of the format
+		h, err := hpsf.FromYAML(input)
+		if err != nil {
+			log.Fatalf("error unmarshaling input file: %v", err)
+		}
+		migrated, steps, err := hpsf.Migrate(&h, cmdopts.Target)
+		if err != nil {
+			log.Fatalf("error migrating: %v", err)
+		}
+		for _, m := range steps {
+			log.Printf("migration %v", m)
+		}
+		if len(steps) == 0 {
+			version := migrated.FormatVersion
+			if version == "" {
+				version = hpsf.FormatVersion
+			}
+			log.Printf("already at version %s", version)
+		}
+		if cmdopts.DryRun {
+			_, err = io.WriteString(outf, diff(tr, &h, migrated).String())
+		} else {
+			var data []byte
+			data, err = y.Marshal(migrated)
+			if err != nil {
+				log.Fatalf("error marshaling output file: %v", err)
+			}
+			_, err = outf.Write(data)
+		}
+		if err != nil {
+			log.Fatalf("error writing output file: %v", err)
+		}
+
+	case "diff":
+		// compares the input file (the old version) with the file named after the command
+		if len(otherInputs) != 1 {
+			log.Fatalf("usage: hpsf -i old.yaml diff new.yaml")
+		}
+		a, err := hpsf.FromYAML(input)
+		if err != nil {
+			log.Fatalf("error unmarshaling input file: %v", err)
+		}
+		b, err := hpsf.FromYAML(otherInputs[0])
+		if err != nil {
+			log.Fatalf("error unmarshaling %s: %v", cmds[1], err)
+		}
+		d := diff(tr, &a, &b)
+		_, err = io.WriteString(outf, d.String())
+		if err != nil {
+			log.Fatalf("error writing output file: %v", err)
+		}
+		// like diff(1), exit with 1 when the files differ
+		if !d.IsEmpty() {
 			os.Exit(1)
 		}
 
-		log.Printf("HPSF is valid")
+	case "merge":
+		// merges two edited versions of the input file (the common ancestor); as a git
+		// merge driver, use: hpsf -i %O -o %A merge %A %B
+		if len(otherInputs) != 2 {
+			log.Fatalf("usage: hpsf -i base.yaml merge ours.yaml theirs.yaml")
+		}
+		docs := make([]hpsf.HPSF, 3)
+		for i, in := range []string{input, otherInputs[0], otherInputs[1]} {
+			docs[i], err = hpsf.FromYAML(in)
+			if err != nil {
+				log.Fatalf("error unmarshaling input file: %v", err)
+			}
+		}
+		merged, conflicts := hpsf.Merge3(&docs[0], &docs[1], &docs[2])
+		data, err := y.Marshal(merged)
+		if err != nil {
+			log.Fatalf("error marshaling output file: %v", err)
+		}
+		_, err = outf.Write(data)
+		if err != nil {
+			log.Fatalf("error writing output file: %v", err)
+		}
+		if len(conflicts) > 0 {
+			// the output has our side of each conflict
+			for _, c := range conflicts {
+				if typ := tr.PropertyType(&docs[1], c.Component, c.Property); typ != "" {
+					c.Type = typ
+				}
+				log.Printf("conflict: %v", c)
+			}
+			os.Exit(1)
+		}
 
 	case "dotify":
 		// create a dotted config from the input file and write it to the output
@@ -231,11 +420,33 @@ func main() {
 		if err != nil {
 			log.Fatalf("error writing output file: %v", err)
 		}
+		if ct == hpsftypes.CollectorConfig && !cmdopts.LiteralSecrets {
+			// literal secrets were replaced by references, so say what the collector will need
+			env, err := tr.SecretEnv(&hpsf)
+			if err != nil {
+				log.Fatalf("error finding secrets: %v", err)
+			}
+			for _, name := range slices.Sorted(maps.Keys(env)) {
+				log.Printf("the collector needs the secret in environment variable %s", name)
+			}
+		}
 	default:
 		log.Fatalf("unknown command: %s", cmds[0])
 	}
 }
 
+// diff compares two documents like hpsf.Diff, using the types the translator's
+// components give their properties so that secrets are redacted.
+func diff(tr *translator.Translator, a, b *hpsf.HPSF) *hpsf.DiffResult {
+	d := hpsf.Diff(a, b)
+	for i, p := range d.PropertiesChanged {
+		if typ := tr.PropertyType(b, p.Component, p.Property); typ != "" {
+			d.PropertiesChanged[i].Type = typ
+		}
+	}
+	return d
+}
+
 func readInput(filename string) ([]byte, error) {
 	// Open the fIn
 	var fIn io.Reader
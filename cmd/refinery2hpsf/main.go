@@ -50,6 +50,9 @@ func main() {
 	if err != nil {
 		log.Fatalf("error generating HPSF workflow: %v", err)
 	}
+	for _, w := range gen.Warnings() {
+		log.Printf("warning: %s", w)
+	}
 
 	// Validate the generated workflow
 	if verrors := workflow.Validate(); verrors != nil {
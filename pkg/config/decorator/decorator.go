@@ -21,20 +21,36 @@ const (
 
 // Prefixes we support:
 const (
-	IntPrefix   = "int" + GroupSeparator
-	BoolPrefix  = "bool" + GroupSeparator
-	FloatPrefix = "float" + GroupSeparator
-	ArrPrefix   = "arr" + GroupSeparator
-	MapPrefix   = "map" + GroupSeparator
+	IntPrefix      = "int" + GroupSeparator
+	BoolPrefix     = "bool" + GroupSeparator
+	FloatPrefix    = "float" + GroupSeparator
+	ArrPrefix      = "arr" + GroupSeparator
+	IntArrPrefix   = "intarr" + GroupSeparator
+	FloatArrPrefix = "floatarr" + GroupSeparator
+	MapPrefix      = "map" + GroupSeparator
+	StrMapPrefix   = "strmap" + GroupSeparator
 )
 
 // EncodeAsArray takes a slice and returns a string intended to be expanded
 // later into an array when it's rendered to YAML.
 // The result looks like "arr\x1dA:1\x1fB:2"
+// Slices of ints and floats keep their types, so they are rendered as numbers.
 func EncodeAsArray(arr any) string {
 	switch a := arr.(type) {
 	case []string:
 		return ArrPrefix + strings.Join(a, FieldSeparator)
+	case []int:
+		items := make([]string, len(a))
+		for i, v := range a {
+			items[i] = strconv.Itoa(v)
+		}
+		return IntArrPrefix + strings.Join(items, FieldSeparator)
+	case []float64:
+		items := make([]string, len(a))
+		for i, v := range a {
+			items[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return FloatArrPrefix + strings.Join(items, FieldSeparator)
 	case []any:
 		return ArrPrefix + strings.Join(getStringsFrom(arr), FieldSeparator)
 	default:
@@ -125,7 +141,16 @@ func EncodeAsInt(a any) string {
 // EncodeAsMap takes a map (which may contain nested maps) and returns a string
 // intended to be expanded later into the same map when it's rendered to YAML.
 // We encode to JSON because it's fast and easy.
-func EncodeAsMap(a map[string]any) string {
+// Maps of strings keep their type.
+func EncodeAsMap(a any) string {
+	prefix := MapPrefix
+	switch a.(type) {
+	case map[string]any:
+	case map[string]string:
+		prefix = StrMapPrefix
+	default:
+		return ""
+	}
 	buf := bytes.Buffer{}
 	j := json.NewEncoder(&buf)
 	// There's no model for returning an error, but also...
@@ -133,7 +158,7 @@ func EncodeAsMap(a map[string]any) string {
 	// to a buffer, so there doesn't seem to be an error we
 	// could encounter that would be meaningful.
 	_ = j.Encode(a)
-	return MapPrefix + buf.String()
+	return prefix + buf.String()
 }
 
 // Undecorate removes type decorations from strings and returns the desired type.
@@ -163,6 +188,34 @@ func Undecorate(s string) any {
 		if err == nil {
 			return f
 		}
+	case strings.HasPrefix(s, IntArrPrefix):
+		s = strings.TrimPrefix(s, IntArrPrefix)
+		arr := []int{}
+		for _, item := range splitItems(s) {
+			i, err := strconv.Atoi(item)
+			if err != nil {
+				return s
+			}
+			arr = append(arr, i)
+		}
+		return arr
+	case strings.HasPrefix(s, FloatArrPrefix):
+		s = strings.TrimPrefix(s, FloatArrPrefix)
+		arr := []float64{}
+		for _, item := range splitItems(s) {
+			f, err := strconv.ParseFloat(item, 64)
+			if err != nil {
+				return s
+			}
+			arr = append(arr, f)
+		}
+		return arr
+	case strings.HasPrefix(s, StrMapPrefix):
+		s = strings.TrimPrefix(s, StrMapPrefix)
+		// like a map, but we know the values are strings
+		var m map[string]string
+		json.Unmarshal([]byte(s), &m)
+		return m
 	case strings.HasPrefix(s, ArrPrefix):
 		s = strings.TrimPrefix(s, ArrPrefix)
 		items := strings.Split(s, FieldSeparator)
@@ -189,6 +242,17 @@ func Undecorate(s string) any {
 	return s
 }
 
+// splitItems splits an encoded array into its non-blank items.
+func splitItems(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, FieldSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // getStringsFrom converts various types to a slice of strings
 func getStringsFrom(value any) []string {
 	result := make([]string, 0)
@@ -1,6 +1,7 @@
 package decorator
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -158,6 +159,10 @@ func Test_EncodeAsArray(t *testing.T) {
 		{"any slice with no strings", []any{1, 2, 3}, ArrPrefix},
 		{"any slice with bool and nums", []any{true, false, 42}, ArrPrefix},
 
+		// Typed numeric slices keep their types
+		{"int slice", []int{80, 443}, IntArrPrefix + "80" + FieldSeparator + "443"},
+		{"float slice", []float64{0.5, 0.99, 1}, FloatArrPrefix + "0.5" + FieldSeparator + "0.99" + FieldSeparator + "1"},
+
 		// Other types (should return empty string)
 		{"single string", "not an array", ""},
 		{"single int", 42, ""},
@@ -228,3 +233,27 @@ func Test_EncodeAsMap_MultipleKeys(t *testing.T) {
 		t.Errorf("EncodeAsMap() = %v, expected to end with newline", got)
 	}
 }
+
+func Test_UndecorateTypedCollections(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		want    any
+	}{
+		{"int slice", EncodeAsArray([]int{80, 443}), []int{80, 443}},
+		{"empty int slice", EncodeAsArray([]int{}), []int{}},
+		{"float slice", EncodeAsArray([]float64{0.5, 0.99}), []float64{0.5, 0.99}},
+		{"string slice", EncodeAsArray([]string{"a", "b"}), []string{"a", "b"}},
+		{"string map", EncodeAsMap(map[string]string{"X-Team": "abc"}), map[string]string{"X-Team": "abc"}},
+		{"map", EncodeAsMap(map[string]any{"count": 2}), map[string]any{"count": 2.0}},
+		{"not a map", EncodeAsMap([]string{"a"}), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Undecorate(tt.encoded); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Undecorate() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -53,7 +53,7 @@ func buildDottedConfigTemplate(data []any) (dottedConfigTemplate, error) {
 	return d, nil
 }
 
-func (t *TemplateComponent) generateDottedConfig(dct dottedConfigTemplate, userdata map[string]any) (tmpl.DottedConfig, error) {
+func (t *TemplateComponent) generateDottedConfig(dct dottedConfigTemplate, templateName string, userdata map[string]any) (tmpl.DottedConfig, error) {
 	// we have to fill in the template with the default values
 	// and the values from the properties
 	config := make(tmpl.DottedConfig)
@@ -79,6 +79,7 @@ func (t *TemplateComponent) generateDottedConfig(dct dottedConfigTemplate, userd
 			return nil, err
 		}
 		config[key] = value
+		t.addOrigin(OriginKey, key, templateName, kv.key, kv.value)
 	}
 	return config, nil
 }
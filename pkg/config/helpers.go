@@ -204,6 +204,10 @@ func _isZeroValue(value any) bool {
 		return !v
 	case []string:
 		return len(v) == 0
+	case []int:
+		return len(v) == 0
+	case []float64:
+		return len(v) == 0
 	case []any:
 		return len(v) == 0
 	case map[string]string:
@@ -256,7 +260,7 @@ type ChecklistItem struct {
 	ID          string `yaml:"id"`
 	DisplayName string `yaml:"displayName"`
 	Value       string `yaml:"value"`
-	TooltipText string `yaml:"tooltipText"`
+	TooltipText string `yaml:"tooltipText,omitempty"`
 }
 
 // getChecked takes a TemplateProperty (checklist type) and a list of checked IDs,
@@ -267,32 +271,10 @@ func getChecked(prop TemplateProperty, checkedIDs any) []any {
 		return []any{}
 	}
 
-	// Handle subtype as []any containing checklist items
-	subtypeSlice, ok := prop.Subtype.([]any)
-	if !ok {
+	if prop.Subtype == nil || prop.Subtype.Kind != SubtypeChecklist {
 		return []any{}
 	}
-
-	// Convert []any to []ChecklistItem
-	var items []ChecklistItem
-	for _, item := range subtypeSlice {
-		if itemMap, ok := item.(map[string]any); ok {
-			checklistItem := ChecklistItem{}
-			if id, ok := itemMap["id"].(string); ok {
-				checklistItem.ID = id
-			}
-			if displayName, ok := itemMap["displayName"].(string); ok {
-				checklistItem.DisplayName = displayName
-			}
-			if value, ok := itemMap["value"].(string); ok {
-				checklistItem.Value = value
-			}
-			if tooltipText, ok := itemMap["tooltipText"].(string); ok {
-				checklistItem.TooltipText = tooltipText
-			}
-			items = append(items, checklistItem)
-		}
-	}
+	items := prop.Subtype.Items
 
 	// Convert checkedIDs to a map for quick lookup
 	checkedMap := make(map[string]bool)
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"regexp"
+	"slices"
+)
+
+// An Origin says where part of a generated configuration came from: the HPSF
+// component and its kind, the name of the template that generated it, and the
+// properties of the component that the template used to generate it.
+type Origin struct {
+	Component  string   `yaml:"component" json:"component"`
+	Kind       string   `yaml:"kind" json:"kind"`
+	Template   string   `yaml:"template" json:"template"`
+	Properties []string `yaml:"properties,omitempty" json:"properties,omitempty"`
+}
+
+// OriginTarget is the part of a generated configuration that an OriginRecord
+// is about.
+type OriginTarget string
+
+const (
+	OriginKey       OriginTarget = "key"       // a dotted key, like "exporters.otlp/Honeycomb.endpoint"
+	OriginComponent OriginTarget = "component" // a collector component, like "otlp/Honeycomb"
+	OriginRule      OriginTarget = "rule"      // a Refinery sampling rule, in the environment called Name if it's set
+)
+
+// An OriginRecord says that part of a generated configuration came from Origin.
+type OriginRecord struct {
+	Target OriginTarget
+	Name   string
+	Origin Origin
+}
+
+// matches the ways templates refer to a property: .Values.Name, .HProps.Name,
+// .Props.Name, or index .Values "Name"
+var propertyRefPattern = regexp.MustCompile(`\.(?:Values|HProps|Props)(?:\.(\w+)|\s+"(\w+)")`)
+
+// RecordOrigins makes the component record where the configuration it
+// generates comes from, so that TakeOrigins can return it. Recording is off by
+// default, because it costs time that most generation doesn't need to spend.
+func (t *TemplateComponent) RecordOrigins() {
+	t.recordOrigins = true
+}
+
+// TakeOrigins returns what the component has recorded since the last call,
+// in the order it was generated, and forgets it.
+func (t *TemplateComponent) TakeOrigins() []OriginRecord {
+	origins := t.origins
+	t.origins = nil
+	return origins
+}
+
+// addOrigin records that the component's template generated the named part of
+// a configuration from the properties that the given template texts refer to.
+// Records that were already made since the last TakeOrigins are skipped.
+func (t *TemplateComponent) addOrigin(target OriginTarget, name, template string, texts ...any) {
+	if !t.recordOrigins {
+		return
+	}
+	rec := OriginRecord{
+		Target: target,
+		Name:   name,
+		Origin: Origin{Kind: t.Kind, Template: template, Properties: t.referencedProperties(texts...)},
+	}
+	if t.hpsf != nil {
+		rec.Origin.Component = t.hpsf.Name
+	}
+	for _, o := range t.origins {
+		if o.Target == rec.Target && o.Name == rec.Name && o.Origin.Template == rec.Origin.Template &&
+			slices.Equal(o.Origin.Properties, rec.Origin.Properties) {
+			return
+		}
+	}
+	t.origins = append(t.origins, rec)
+}
+
+// referencedProperties returns the names of the component's properties that
+// the template texts refer to, in the order they're first referred to.
+func (t *TemplateComponent) referencedProperties(texts ...any) []string {
+	var props []string
+	add := func(s string) {
+		for _, m := range propertyRefPattern.FindAllStringSubmatch(s, -1) {
+			name := m[1] + m[2]
+			if t.propertyExists(name) && !slices.Contains(props, name) {
+				props = append(props, name)
+			}
+		}
+	}
+	for _, text := range texts {
+		switch v := text.(type) {
+		case string:
+			add(v)
+		case []string:
+			for _, s := range v {
+				add(s)
+			}
+		case []any:
+			for _, s := range v {
+				if s, ok := s.(string); ok {
+					add(s)
+				}
+			}
+		}
+	}
+	return props
+}
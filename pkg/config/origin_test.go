@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferencedProperties(t *testing.T) {
+	tc := &TemplateComponent{
+		Properties: []TemplateProperty{
+			{Name: "Host", Type: hpsf.PTYPE_STRING},
+			{Name: "Port", Type: hpsf.PTYPE_INT},
+			{Name: "Headers", Type: hpsf.PTYPE_MAPSTR},
+		},
+	}
+	assert.Equal(t, []string{"Host", "Port", "Headers"}, tc.referencedProperties(
+		"{{ .ComponentName }}.endpoint",
+		"{{ .Values.Host }}:{{ .Values.Port }}",
+		[]string{`{{ index .HProps "Headers" }}`, "{{ .Props.Port.Default }}"},
+		42,
+	))
+	// things that aren't properties of the component aren't included
+	assert.Empty(t, tc.referencedProperties("{{ .Values.Other }} {{ .User.Host }} {{ .Values.Hostname }}"))
+}
+
+func TestTemplateComponent_Origins(t *testing.T) {
+	tc := &TemplateComponent{
+		Kind:       "Thing",
+		Properties: []TemplateProperty{{Name: "Host", Type: hpsf.PTYPE_STRING}},
+		hpsf:       &hpsf.Component{Name: "My Thing", Kind: "Thing"},
+	}
+
+	// nothing is recorded unless it's asked for
+	tc.addOrigin(OriginKey, "a.b", "thing_template", "{{ .Values.Host }}")
+	assert.Empty(t, tc.TakeOrigins())
+
+	tc.RecordOrigins()
+	tc.addOrigin(OriginKey, "a.b", "thing_template", "{{ .Values.Host }}")
+	tc.addOrigin(OriginKey, "a.b", "thing_template", "{{ .Values.Host }}")
+	tc.addOrigin(OriginComponent, "thing/My_Thing", "thing_template")
+	assert.Equal(t, []OriginRecord{
+		{Target: OriginKey, Name: "a.b", Origin: Origin{Component: "My Thing", Kind: "Thing", Template: "thing_template", Properties: []string{"Host"}}},
+		{Target: OriginComponent, Name: "thing/My_Thing", Origin: Origin{Component: "My Thing", Kind: "Thing", Template: "thing_template"}},
+	}, tc.TakeOrigins())
+	assert.Empty(t, tc.TakeOrigins())
+}
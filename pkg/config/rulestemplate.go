@@ -53,7 +53,7 @@ func buildRulesTemplate(t TemplateData) (*rulesTemplate, error) {
 
 // we expand template variables here, but we don't actually apply the kvs yet;
 // that's deferred until merge time.
-func (t *TemplateComponent) generateRulesConfig(rt *rulesTemplate, compType tmpl.RulesMergeType, pipelineIndex int, userdata map[string]any) (*tmpl.RulesConfig, error) {
+func (t *TemplateComponent) generateRulesConfig(rt *rulesTemplate, templateName string, compType tmpl.RulesMergeType, pipelineIndex int, userdata map[string]any) (*tmpl.RulesConfig, error) {
 	kvs := make(map[string]any)
 	meta := make(map[string]string)
 	meta[tmpl.MetaPipelineIndex] = strconv.Itoa(pipelineIndex)
@@ -83,6 +83,7 @@ func (t *TemplateComponent) generateRulesConfig(rt *rulesTemplate, compType tmpl
 		meta["scope"] = expandedScope
 	}
 
+	texts := []any{rt.sampler, rt.scope} // the templates the rule was generated from
 	for _, kv := range rt.kvs {
 		// do the key
 		key, err := t.expandTemplateVariable(kv.key, userdata)
@@ -106,8 +107,10 @@ func (t *TemplateComponent) generateRulesConfig(rt *rulesTemplate, compType tmpl
 		}
 		if kv.value != "" {
 			kvs[key] = value
+			texts = append(texts, kv.key, kv.value)
 		}
 	}
+	t.addOrigin(OriginRule, env, templateName, texts...)
 
 	rc := tmpl.NewRulesConfig(compType, meta, kvs)
 	return rc, nil
@@ -2,8 +2,11 @@ package config
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -32,12 +35,45 @@ import (
 // should be treated as an indexed port (e.g. for use in a pipeline). The
 // index values for ports should be sequential within a given component, and
 // should start at 1. If the index is not specified, it is assumed to be 0.
+//
+// MinConnections and MaxConnections limit the number of connections that can
+// be made to the port; a MaxConnections of 0 means there is no upper limit.
 type TemplatePort struct {
-	Name      string              `yaml:"name"`
-	Direction string              `yaml:"direction"`
-	Type      hpsf.ConnectionType `yaml:"type"`
-	Index     int                 `yaml:"index,omitempty"`
-	Note      string              `yaml:"note,omitempty"`
+	Name           string              `yaml:"name"`
+	Direction      string              `yaml:"direction"`
+	Type           hpsf.ConnectionType `yaml:"type"`
+	Index          int                 `yaml:"index,omitempty"`
+	MinConnections int                 `yaml:"minConnections,omitempty"`
+	MaxConnections int                 `yaml:"maxConnections,omitempty"`
+	Note           string              `yaml:"note,omitempty"`
+}
+
+// ConnectionLimit describes the number of connections the port allows, like
+// "exactly one" or "at least 2", or returns "" if the port has no limits.
+func (p *TemplatePort) ConnectionLimit() string {
+	count := func(n int) string {
+		if n == 1 {
+			return "one"
+		}
+		return strconv.Itoa(n)
+	}
+	switch {
+	case p.MaxConnections == 0 && p.MinConnections == 0:
+		return ""
+	case p.MaxConnections == 0:
+		return "at least " + count(p.MinConnections)
+	case p.MinConnections == p.MaxConnections:
+		return "exactly " + count(p.MaxConnections)
+	case p.MinConnections == 0:
+		return "at most " + count(p.MaxConnections)
+	default:
+		return fmt.Sprintf("between %d and %d", p.MinConnections, p.MaxConnections)
+	}
+}
+
+// AllowsConnections reports whether n connections to the port are within its limits.
+func (p *TemplatePort) AllowsConnections(n int) bool {
+	return n >= p.MinConnections && (p.MaxConnections == 0 || n <= p.MaxConnections)
 }
 
 // A TemplateData describes a template for generating configuration data. It's a
@@ -139,11 +175,13 @@ func (c ComponentStatus) MarshalYAML() (any, error) {
 //     It will likely become some sort of enum, but for now we don't know what the values will be.
 //   - Type is the generalized type of component for broad classification - Base, Meta, or Template.
 //   - Status is the development status of the component.
+//   - Upgrades describes how to upgrade components from older major versions; see ComponentUpgrade.
 //   - User is only used for templating, but it needs to be exported, so its yaml tag is set to "-"
 //   - collName is the name of the OTel collector component that this component is associated with; it may
 //     be empty if the component is not associated with a collector. We need to store it in this data type
 //     because it's used in the template rendering, but it's not part of the component itself (it's specified
 //     in the template metadata).
+//   - recordOrigins and origins support RecordOrigins and TakeOrigins.
 type TemplateComponent struct {
 	Kind        string                    `yaml:"kind"`
 	Version     string                    `yaml:"version"`
@@ -163,10 +201,53 @@ type TemplateComponent struct {
 	Properties  []TemplateProperty        `yaml:"properties,omitempty"`
 	Validations []string                  `yaml:"validations,omitempty"`
 	Templates   []TemplateData            `yaml:"templates,omitempty"`
+	Upgrades    []ComponentUpgrade        `yaml:"upgrades,omitempty"`
 	User        map[string]any            `yaml:"-"`
 	hpsf        *hpsf.Component           // the component from the hpsf document
 	connections []*hpsf.Connection
 	collName    string
+
+	literalSecrets bool
+	recordOrigins  bool
+	origins        []OriginRecord
+}
+
+// LiteralSecrets makes the component render secrets that are written into the
+// document as written in the collector configs it generates. By default, they
+// are rendered as references to environment variables (see hpsf.SecretEnvVar),
+// so the secrets themselves don't end up in the config.
+func (t *TemplateComponent) LiteralSecrets() {
+	t.literalSecrets = true
+}
+
+// withSecretRefs returns a copy of the component in which each secret that's
+// written into the document is replaced by a reference to the environment
+// variable that's expected to hold it. If there are no such secrets, it
+// returns the component itself.
+func (t *TemplateComponent) withSecretRefs() *TemplateComponent {
+	if t.hpsf == nil {
+		return t
+	}
+	var comp *hpsf.Component
+	for _, prop := range t.Properties {
+		p := t.hpsf.GetProperty(prop.Name)
+		if p == nil || !prop.Type.IsLiteralSecret(p.Value) {
+			continue
+		}
+		if comp == nil {
+			c := *t.hpsf
+			c.Properties = slices.Clone(t.hpsf.Properties)
+			comp = &c
+		}
+		p.Value = "${" + hpsf.SecretEnvVar(t.hpsf.Name, prop.Name) + "}"
+		comp.SetProperty(*p)
+	}
+	if comp == nil {
+		return t
+	}
+	c := *t
+	c.hpsf = comp
+	return &c
 }
 
 // SetHPSF stores the original component's details and may modify their contents. To
@@ -239,6 +320,16 @@ func (t *TemplateComponent) Values() map[string]any {
 		}
 	}
 
+	// Durations are written in one canonical form, however they were given,
+	// and typed arrays and maps are rendered with their real types
+	for _, prop := range t.Properties {
+		if slices.Contains(coercedTypes, prop.Type) {
+			if d, err := prop.Coerce(result[prop.Name]); err == nil {
+				result[prop.Name] = d
+			}
+		}
+	}
+
 	return result
 }
 
@@ -310,7 +401,7 @@ func (t *TemplateComponent) GenerateConfig(cfgType hpsftypes.Type, pipeline hpsf
 					return nil, fmt.Errorf("error %w building dotted config template for %s",
 						err, t.Kind)
 				}
-				tmpl, err := t.generateDottedConfig(dct, userdata)
+				tmpl, err := t.generateDottedConfig(dct, template.Name, userdata)
 				if err != nil {
 					return nil, err
 				}
@@ -321,7 +412,7 @@ func (t *TemplateComponent) GenerateConfig(cfgType hpsftypes.Type, pipeline hpsf
 					return nil, fmt.Errorf("error %w building collector template for %s",
 						err, t.Kind)
 				}
-				tmpl, err := t.generateCollectorConfig(ct, pipeline, userdata)
+				tmpl, err := t.generateCollectorConfig(ct, template.Name, pipeline, userdata)
 				if err != nil {
 					return nil, err
 				}
@@ -361,7 +452,7 @@ func (t *TemplateComponent) GenerateConfig(cfgType hpsftypes.Type, pipeline hpsf
 					return nil, fmt.Errorf("error %w getting RulesComponentType from style %s for %s",
 						err, t.Style, t.Kind)
 				}
-				tmpl, err := t.generateRulesConfig(rt, rmt, index, userdata)
+				tmpl, err := t.generateRulesConfig(rt, template.Name, rmt, index, userdata)
 				if err != nil {
 					return nil, err
 				}
@@ -450,12 +541,19 @@ func (t *TemplateComponent) applyTemplate(tmplVal any, userdata map[string]any)
 
 // this is where we do the actual work of generating the config; this thing knows about
 // the structure of the collector config and how to fill it in
-func (t *TemplateComponent) generateCollectorConfig(ct collectorTemplate, pipeline hpsf.PathWithConnections, userdata map[string]any) (*tmpl.CollectorConfig, error) {
+func (t *TemplateComponent) generateCollectorConfig(ct collectorTemplate, templateName string, pipeline hpsf.PathWithConnections, userdata map[string]any) (*tmpl.CollectorConfig, error) {
 	// we have to fill in the template with the default values
 	// and the values from the properties
 	t.collName = ct.collectorComponentName
+	// the templates see secrets that are written into the document as
+	// references to environment variables, unless asked otherwise
+	src := t
+	if !t.literalSecrets {
+		src = t.withSecretRefs()
+	}
 	config := tmpl.NewCollectorConfig()
 	sectionOrder := []string{"receivers", "processors", "exporters", "extensions"}
+	var texts []any // the templates of the keys that were generated
 	for _, section := range sectionOrder {
 		for _, signalType := range hpsf.CollectorSignalTypes {
 			if pipeline.ConnType != signalType {
@@ -469,7 +567,7 @@ func (t *TemplateComponent) generateCollectorConfig(ct collectorTemplate, pipeli
 			for _, kv := range ct.kvs[section] {
 				if kv.suppressIf != "" {
 					// if the suppress_if condition is met, we skip this key
-					condition, err := t.applyTemplate(kv.suppressIf, userdata)
+					condition, err := src.applyTemplate(kv.suppressIf, userdata)
 					if err != nil {
 						return nil, err
 					}
@@ -478,11 +576,14 @@ func (t *TemplateComponent) generateCollectorConfig(ct collectorTemplate, pipeli
 					}
 				}
 				config.Set("service", svcKey, []string{t.ComponentName()})
-				key, err := t.expandTemplateVariable(kv.key, userdata)
+				key, err := src.expandTemplateVariable(kv.key, userdata)
 				if err != nil {
 					return nil, err
 				}
-				value, err := t.applyTemplate(kv.value, userdata)
+				t.addOrigin(OriginKey, "service."+svcKey, templateName)
+				t.addOrigin(OriginKey, section+"."+key, templateName, kv.key, kv.value)
+				texts = append(texts, kv.key, kv.value)
+				value, err := src.applyTemplate(kv.value, userdata)
 				if err != nil {
 					return nil, err
 				}
@@ -490,6 +591,9 @@ func (t *TemplateComponent) generateCollectorConfig(ct collectorTemplate, pipeli
 			}
 		}
 	}
+	if len(texts) > 0 {
+		t.addOrigin(OriginComponent, t.ComponentName(), templateName, texts...)
+	}
 	return config, nil
 }
 
@@ -525,6 +629,10 @@ func (t *TemplateComponent) Validate(component *hpsf.Component) error {
 	// Execute each validation rule
 	for _, validation := range t.Validations {
 		if err := t.executeComponentValidation(validation, propertyValues, component.Name); err != nil {
+			var herr *hpsf.HPSFError
+			if errors.As(err, &herr) {
+				herr.WithLocation(component.Location())
+			}
 			return err
 		}
 	}
@@ -599,13 +707,14 @@ func parseComponentValidation(validationStr string) (validationType string, prop
 func (t *TemplateComponent) executeComponentValidation(validationStr string, propertyValues map[string]any, componentName string) error {
 	validationType, properties, conditionProperty, conditionValue, err := parseComponentValidation(validationStr)
 	if err != nil {
-		return hpsf.NewError("failed to parse component validation: " + err.Error()).WithComponent(componentName)
+		return hpsf.NewError("failed to parse component validation: " + err.Error()).WithCode(hpsf.CODE_COMPONENT_VALIDATION_FAILED).WithComponent(componentName)
 	}
 
 	// Validate that all referenced properties exist
 	for _, propName := range properties {
 		if !t.propertyExists(propName) {
 			return hpsf.NewError("component validation references unknown property: " + propName).
+				WithCode(hpsf.CODE_COMPONENT_VALIDATION_FAILED).
 				WithComponent(componentName)
 		}
 	}
@@ -613,6 +722,7 @@ func (t *TemplateComponent) executeComponentValidation(validationStr string, pro
 	// Validate condition property if specified
 	if conditionProperty != "" && !t.propertyExists(conditionProperty) {
 		return hpsf.NewError("component validation references unknown condition property: " + conditionProperty).
+			WithCode(hpsf.CODE_COMPONENT_VALIDATION_FAILED).
 			WithComponent(componentName)
 	}
 
@@ -629,6 +739,7 @@ func (t *TemplateComponent) executeComponentValidation(validationStr string, pro
 		return t.validateConditionalRequireTogether(properties, conditionProperty, conditionValue, propertyValues, componentName)
 	default:
 		return hpsf.NewError("unknown component validation type: " + validationType).
+			WithCode(hpsf.CODE_COMPONENT_VALIDATION_FAILED).
 			WithComponent(componentName)
 	}
 }
@@ -697,7 +808,7 @@ func (t *TemplateComponent) validateAtLeastOneOf(properties []string, propertyVa
 			return nil // At least one property has a non-empty value
 		}
 	}
-	return hpsf.NewError(generateValidationErrorMessage("at_least_one_of", properties, "", nil)).WithComponent(componentName)
+	return hpsf.NewError(generateValidationErrorMessage("at_least_one_of", properties, "", nil)).WithCode(hpsf.CODE_COMPONENT_VALIDATION_FAILED).WithComponent(componentName)
 }
 
 // validateExactlyOneOf ensures exactly one of the specified properties is non-empty
@@ -709,7 +820,7 @@ func (t *TemplateComponent) validateExactlyOneOf(properties []string, propertyVa
 		}
 	}
 	if nonEmptyCount != 1 {
-		return hpsf.NewError(generateValidationErrorMessage("exactly_one_of", properties, "", nil)).WithComponent(componentName)
+		return hpsf.NewError(generateValidationErrorMessage("exactly_one_of", properties, "", nil)).WithCode(hpsf.CODE_COMPONENT_VALIDATION_FAILED).WithComponent(componentName)
 	}
 	return nil
 }
@@ -721,7 +832,7 @@ func (t *TemplateComponent) validateMutuallyExclusive(properties []string, prope
 		if value, exists := propertyValues[propName]; exists && !isPropertyEmpty(value) {
 			nonEmptyCount++
 			if nonEmptyCount > 1 {
-				return hpsf.NewError(generateValidationErrorMessage("mutually_exclusive", properties, "", nil)).WithComponent(componentName)
+				return hpsf.NewError(generateValidationErrorMessage("mutually_exclusive", properties, "", nil)).WithCode(hpsf.CODE_COMPONENT_VALIDATION_FAILED).WithComponent(componentName)
 			}
 		}
 	}
@@ -746,7 +857,7 @@ func (t *TemplateComponent) validateRequireTogether(properties []string, propert
 
 	// If we have both empty and non-empty properties, that's an error
 	if hasEmpty && hasNonEmpty {
-		return hpsf.NewError(generateValidationErrorMessage("require_together", properties, "", nil)).WithComponent(componentName)
+		return hpsf.NewError(generateValidationErrorMessage("require_together", properties, "", nil)).WithCode(hpsf.CODE_COMPONENT_VALIDATION_FAILED).WithComponent(componentName)
 	}
 
 	return nil
@@ -792,7 +903,7 @@ func (t *TemplateComponent) validateConditionalRequireTogether(properties []stri
 	for _, propName := range properties {
 		value, exists := propertyValues[propName]
 		if !exists || isPropertyEmpty(value) {
-			return hpsf.NewError(generateValidationErrorMessage("conditional_require_together", properties, conditionProperty, conditionValue)).WithComponent(componentName)
+			return hpsf.NewError(generateValidationErrorMessage("conditional_require_together", properties, conditionProperty, conditionValue)).WithCode(hpsf.CODE_COMPONENT_VALIDATION_FAILED).WithComponent(componentName)
 		}
 	}
 
@@ -564,3 +564,33 @@ templates:
 	assert.Equal(t, "otlphttp", ct.Meta["collectorComponentName"])
 	require.Len(t, ct.Data, 1)
 }
+
+func TestTemplatePort_ConnectionLimits(t *testing.T) {
+	tests := []struct {
+		min, max int
+		limit    string
+		allowed  []int
+		refused  []int
+	}{
+		{0, 0, "", []int{0, 1, 5}, nil},
+		{1, 1, "exactly one", []int{1}, []int{0, 2}},
+		{1, 0, "at least one", []int{1, 5}, []int{0}},
+		{0, 1, "at most one", []int{0, 1}, []int{2}},
+		{2, 2, "exactly 2", []int{2}, []int{1, 3}},
+		{1, 3, "between 1 and 3", []int{1, 3}, []int{0, 4}},
+	}
+	for _, tt := range tests {
+		port := TemplatePort{Name: "Traces", MinConnections: tt.min, MaxConnections: tt.max}
+		assert.Equal(t, tt.limit, port.ConnectionLimit())
+		for _, n := range tt.allowed {
+			assert.True(t, port.AllowsConnections(n), "%s should allow %d", tt.limit, n)
+		}
+		for _, n := range tt.refused {
+			assert.False(t, port.AllowsConnections(n), "%s should refuse %d", tt.limit, n)
+		}
+	}
+
+	var port TemplatePort
+	require.NoError(t, y.Unmarshal([]byte("name: Sample\ndirection: input\ntype: SampleData\nminConnections: 1\nmaxConnections: 1"), &port))
+	assert.Equal(t, "exactly one", port.ConnectionLimit())
+}
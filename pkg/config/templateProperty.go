@@ -5,11 +5,14 @@ import (
 	"net"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/honeycombio/hpsf/pkg/config/tmpl"
 	"github.com/honeycombio/hpsf/pkg/hpsf"
+	y "gopkg.in/yaml.v3"
 )
 
 // A TemplateProperty describes a property of a component. A property is a
@@ -29,12 +32,60 @@ type TemplateProperty struct {
 	Summary     string        `yaml:"summary,omitempty"`
 	Description string        `yaml:"description,omitempty"`
 	Type        hpsf.PropType `yaml:"type"`
-	Subtype     any           `yaml:"subtype,omitempty"`
+	Subtype     *Subtype      `yaml:"subtype,omitempty"`
 	Advanced    bool          `yaml:"advanced,omitempty"`
 	Validations []string      `yaml:"validations,omitempty"`
 	Default     any           `yaml:"default,omitempty"`
 }
 
+// UnmarshalYAML checks that the property's subtype can be used with its type,
+// so that malformed components are rejected when they're loaded.
+func (tp *TemplateProperty) UnmarshalYAML(value *y.Node) error {
+	type plain TemplateProperty
+	if err := value.Decode((*plain)(tp)); err != nil {
+		return err
+	}
+	if tp.Subtype != nil {
+		if err := tp.Subtype.CheckPropType(tp.Type); err != nil {
+			return fmt.Errorf("property %s: %w", tp.Name, err)
+		}
+	}
+	return nil
+}
+
+// DurationUnit is the unit in which a duration property counts values that
+// are given as plain numbers: the unit of its subtype, like unit(ms), or seconds.
+func (tp *TemplateProperty) DurationUnit() time.Duration {
+	if tp.Subtype != nil && tp.Subtype.Kind == SubtypeUnit {
+		if d, err := time.ParseDuration("1" + tp.Subtype.Unit); err == nil {
+			return d
+		}
+	}
+	return time.Second
+}
+
+// coercedTypes are the property types whose values are converted to the
+// template property's type before they're validated or used in templates,
+// because their validations and templates depend on the real type.
+var coercedTypes = []hpsf.PropType{hpsf.PTYPE_DUR, hpsf.PTYPE_ARRINT, hpsf.PTYPE_ARRFLOAT, hpsf.PTYPE_STRMAP}
+
+// Coerce converts a value to the property's type, like PropType.ValueCoerce,
+// except that durations given as numbers are counted in the property's
+// DurationUnit. Durations are returned in their canonical form (see
+// hpsf.FormatDuration).
+func (tp *TemplateProperty) Coerce(value any) (any, error) {
+	if tp.Type == hpsf.PTYPE_DUR {
+		d, err := hpsf.ParseDuration(value, tp.DurationUnit())
+		if err != nil {
+			return nil, err
+		}
+		return hpsf.FormatDuration(d), nil
+	}
+	var v any
+	err := tp.Type.ValueCoerce(value, &v)
+	return v, err
+}
+
 // returns a validation function based on the validation string provided.
 // the validation string can include a set of comma-separated arguments in parens,
 // which will be bound to the validation function when appropriate.
@@ -63,7 +114,7 @@ func getValidationRule(validation string) func(val any) bool {
 	case "positive":
 		// positive will check if a numeric value is greater than 0
 		// nonumeric types will return false
-		return positive
+		return eachElement(positive)
 	case "noblanks":
 		// noblanks will check that no strings in the value are blank;
 		// this is useful for strings and slices
@@ -75,7 +126,7 @@ func getValidationRule(validation string) func(val any) bool {
 	case "oneof":
 		// oneof will check if the value is exactly one of the provided options
 		// (using only string comparison)
-		return oneof(args...)
+		return eachElement(oneof(args...))
 	case "url":
 		// url will check if the value can be parsed as a URL
 		return isURL
@@ -88,13 +139,13 @@ func getValidationRule(validation string) func(val any) bool {
 		return isDuration
 	case "atleast":
 		// atLeast will check if the value is greater than or equal to the provided argument
-		return atLeast(args...)
+		return eachElement(atLeast(args...))
 	case "atmost":
 		// atMost will check if the value is less than or equal to the provided argument
-		return atMost(args...)
+		return eachElement(atMost(args...))
 	case "inrange":
 		// inRange will check if the value is within a specified range
-		return inRange(args...)
+		return eachElement(inRange(args...))
 	case "regex":
 		// regex will check if the value is a valid regular expression
 		return isValidRegex
@@ -117,10 +168,34 @@ func (tp *TemplateProperty) Validate(prop hpsf.Property) error {
 	if err := prop.Type.ValueCoerce(prop.Value, &value); err != nil {
 		// if the type of the property does not match the type of the template property, return an error
 		return hpsf.NewError("value cannot be converted to expected type " + tp.Type.String()).
+			WithCode(hpsf.CODE_INVALID_PROPERTY).
 			WithProperty(tp.Name).
 			WithCause(err)
 	}
 
+	// some types are validated as the template's type, so that bounds like
+	// atleast(1s) compare durations and per-element validations see numbers
+	if slices.Contains(coercedTypes, tp.Type) && value != nil && value != "" {
+		d, err := tp.Coerce(value)
+		if err != nil {
+			return hpsf.NewError("value cannot be converted to expected type " + tp.Type.String()).
+				WithCode(hpsf.CODE_INVALID_PROPERTY).
+				WithProperty(tp.Name).
+				WithCause(err)
+		}
+		value = d
+	}
+
+	// a rule has to be something that Refinery would accept as a rule
+	if tp.Type == hpsf.PTYPE_RULE && value != nil {
+		if _, err := tmpl.ParseRule(value); err != nil {
+			return hpsf.NewError("value is not a valid sampling rule").
+				WithCode(hpsf.CODE_INVALID_PROPERTY).
+				WithProperty(tp.Name).
+				WithCause(err)
+		}
+	}
+
 	// If there are additional validations, run them against "value" which
 	// has been coerced to the expected type. This allows for additional
 	// validations to be applied beyond just type checking.
@@ -131,12 +206,38 @@ func (tp *TemplateProperty) Validate(prop hpsf.Property) error {
 		if !validationFunc(value) {
 			// if the validation fails, return an error
 			return hpsf.NewError("validation failed for property: " + validation).
+				WithCode(hpsf.CODE_INVALID_PROPERTY).
 				WithProperty(tp.Name)
 		}
 	}
 	return nil
 }
 
+// eachElement applies a check to every element of a typed array, so that
+// validations like positive or atleast(1) work with intarray and floatarray
+// properties. Other values are checked as they are.
+func eachElement(check func(val any) bool) func(val any) bool {
+	return func(val any) bool {
+		switch v := val.(type) {
+		case []int:
+			return allElements(v, check)
+		case []float64:
+			return allElements(v, check)
+		default:
+			return check(val)
+		}
+	}
+}
+
+func allElements[T any](elems []T, check func(val any) bool) bool {
+	for _, elem := range elems {
+		if !check(elem) {
+			return false
+		}
+	}
+	return true
+}
+
 // always returns false
 func alwaysFail(_ any) bool {
 	return false
@@ -177,6 +278,12 @@ func noBlankStrings(val any) bool {
 				}
 			}
 		}
+	case map[string]string:
+		for k, s := range v {
+			if len(k) == 0 || len(s) == 0 {
+				return false
+			}
+		}
 	}
 	return true
 }
@@ -197,6 +304,8 @@ func nonempty(val any) bool {
 		return len(v) > 0
 	case map[string]any:
 		return len(v) > 0
+	case map[string]string:
+		return len(v) > 0
 	default:
 		return true // for other types, we consider them non-empty
 	}
@@ -284,6 +393,12 @@ func atLeast(options ...string) func(val any) bool {
 	if len(options) == 0 {
 		return alwaysFail
 	}
+	if mindur, ok := durationBound(options[0]); ok {
+		return func(val any) bool {
+			d, ok := durationValue(val)
+			return ok && d >= mindur
+		}
+	}
 	minval, err := strconv.ParseFloat(options[0], 64)
 	if err != nil {
 		return alwaysFail
@@ -308,6 +423,12 @@ func atMost(options ...string) func(val any) bool {
 	if len(options) == 0 {
 		return alwaysFail
 	}
+	if maxdur, ok := durationBound(options[0]); ok {
+		return func(val any) bool {
+			d, ok := durationValue(val)
+			return ok && d <= maxdur
+		}
+	}
 	maxval, err := strconv.ParseFloat(options[0], 64)
 	if err != nil {
 		return alwaysFail
@@ -332,6 +453,17 @@ func inRange(options ...string) func(val any) bool {
 	if len(options) < 2 {
 		return alwaysFail // need at least two options for a range
 	}
+	mindur, minIsDur := durationBound(options[0])
+	maxdur, maxIsDur := durationBound(options[1])
+	if minIsDur && maxIsDur {
+		if mindur > maxdur {
+			maxdur, mindur = mindur, maxdur // ensure min is less than max
+		}
+		return func(val any) bool {
+			d, ok := durationValue(val)
+			return ok && d >= mindur && d <= maxdur
+		}
+	}
 	// parse the minimum and maximum values
 	minval, err := strconv.ParseFloat(options[0], 64)
 	if err != nil {
@@ -358,6 +490,27 @@ func inRange(options ...string) func(val any) bool {
 	}
 }
 
+// durationBound parses the argument of a bounds check if it's a duration,
+// like 1s; plain numbers are left to the numeric checks.
+func durationBound(arg string) (time.Duration, bool) {
+	if _, err := strconv.ParseFloat(arg, 64); err == nil {
+		return 0, false
+	}
+	d, err := time.ParseDuration(arg)
+	return d, err == nil
+}
+
+// durationValue gets the duration from a value that is being checked against
+// duration bounds; by then, duration properties have been coerced to strings.
+func durationValue(val any) (time.Duration, bool) {
+	s, ok := val.(string)
+	if !ok {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
+
 // isValidRegex checks if the value can be interpreted as a valid regular expression,
 // or a slice of valid regular expressions.
 func isValidRegex(val any) bool {
@@ -3,6 +3,12 @@ package config
 import (
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	y "gopkg.in/yaml.v3"
 )
 
 func Test_getValidationRule(t *testing.T) {
@@ -65,6 +71,24 @@ func Test_getValidationRule(t *testing.T) {
 		{"float range high end", "inrange(1.5,5.5)", 5.5, true},
 		{"float range fail low", "inrange(1.5,5.5)", 1.4, false},
 		{"float range fail high", "inrange(1.5,5.5)", 5.6, false},
+		{"duration at least", "atleast(1s)", "1m", true},
+		{"duration at least equal", "atleast(1s)", "1000ms", true},
+		{"duration at least fail", "atleast(1s)", "500ms", false},
+		{"duration at least not a duration", "atleast(1s)", 5, false},
+		{"duration at most", "atmost(1m)", "59s", true},
+		{"duration at most fail", "atmost(1m)", "1m30s", false},
+		{"duration range", "inrange(100ms, 10s)", "2s", true},
+		{"duration range fail", "inrange(100ms, 10s)", "10ms", false},
+		{"positive int array", "positive", []int{1, 2}, true},
+		{"int array with zero", "positive", []int{1, 0}, false},
+		{"float array at least", "atleast(1)", []float64{1, 2.5}, true},
+		{"float array at least fail", "atleast(1)", []float64{2.5, 0.5}, false},
+		{"int array in range", "inrange(1,5)", []int{1, 5}, true},
+		{"int array in range fail", "inrange(1,5)", []int{3, 6}, false},
+		{"int array one of", "oneof(1, 2)", []int{2, 1}, true},
+		{"non-blank string map", "noblanks", map[string]string{"key": "value"}, true},
+		{"blank in a string map", "noblanks", map[string]string{"key": ""}, false},
+		{"empty string map", "nonempty", map[string]string{}, false},
 		{"valid regex", "regex", "[a-z]+", true},
 		{"invalid regex", "regex", "[a-z", false},
 		{"valid regex with quantifier", "regex", "\\d{3,5}", true},
@@ -105,3 +129,60 @@ func Test_getValidationRule(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateProperty_CoerceDuration(t *testing.T) {
+	tp := TemplateProperty{Name: "Timeout", Type: hpsf.PTYPE_DUR}
+	assert.Equal(t, time.Second, tp.DurationUnit())
+	tests := []struct {
+		value any
+		want  any
+	}{
+		{"30s", "30s"},
+		{"60s", "1m"},
+		{"1m0s", "1m"},
+		{"90m", "1h30m"},
+		{30, "30s"},
+		{1.5, "1.5s"},
+		{"15", "15s"},
+	}
+	for _, tt := range tests {
+		got, err := tp.Coerce(tt.value)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "coercing %v", tt.value)
+	}
+	_, err := tp.Coerce("soon")
+	assert.Error(t, err)
+
+	// the unit subtype says how to count plain numbers
+	require.NoError(t, y.Unmarshal([]byte("name: Timeout\ntype: duration\nsubtype: unit(ms)"), &tp))
+	assert.Equal(t, time.Millisecond, tp.DurationUnit())
+	got, err := tp.Coerce(1500)
+	require.NoError(t, err)
+	assert.Equal(t, "1.5s", got)
+	got, err = tp.Coerce("250ms")
+	require.NoError(t, err)
+	assert.Equal(t, "250ms", got)
+
+	err = y.Unmarshal([]byte("name: Timeout\ntype: duration\nsubtype: unit(fortnights)"), &tp)
+	assert.EqualError(t, err, "property Timeout: fortnights is not a unit of time")
+}
+
+func TestTemplateProperty_ValidateDuration(t *testing.T) {
+	tp := TemplateProperty{Name: "Timeout", Type: hpsf.PTYPE_DUR, Validations: []string{"duration", "atleast(1s)"}}
+	assert.NoError(t, tp.Validate(hpsf.Property{Name: "Timeout", Value: "1m"}))
+	assert.NoError(t, tp.Validate(hpsf.Property{Name: "Timeout", Value: 5}))
+	assert.Error(t, tp.Validate(hpsf.Property{Name: "Timeout", Value: "500ms"}))
+	assert.Error(t, tp.Validate(hpsf.Property{Name: "Timeout", Value: "soon"}))
+}
+
+func TestTemplateProperty_ValidateTypedCollections(t *testing.T) {
+	tp := TemplateProperty{Name: "Buckets", Type: hpsf.PTYPE_ARRFLOAT, Validations: []string{"nonempty", "positive"}}
+	assert.NoError(t, tp.Validate(hpsf.Property{Name: "Buckets", Value: []any{0.5, 1, "2.5"}}))
+	assert.Error(t, tp.Validate(hpsf.Property{Name: "Buckets", Value: []any{0.5, -1}}))
+	assert.Error(t, tp.Validate(hpsf.Property{Name: "Buckets", Value: []any{"lots"}}))
+
+	tp = TemplateProperty{Name: "Headers", Type: hpsf.PTYPE_STRMAP, Validations: []string{"noblanks"}}
+	assert.NoError(t, tp.Validate(hpsf.Property{Name: "Headers", Value: map[string]any{"x-team": "a", "x-count": 3}}))
+	assert.Error(t, tp.Validate(hpsf.Property{Name: "Headers", Value: map[string]any{"x-team": ""}}))
+	assert.Error(t, tp.Validate(hpsf.Property{Name: "Headers", Value: map[string]any{"x-team": map[string]any{}}}))
+}
@@ -0,0 +1,303 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	y "gopkg.in/yaml.v3"
+)
+
+// SubtypeKind identifies the kind of a property's subtype, which tells the
+// property editor how to present the value.
+type SubtypeKind string
+
+const (
+	// SubtypeOneOf offers a fixed set of options, usually as a dropdown.
+	// Written as oneof(a, b, c); options can have labels: oneof(a "First", b).
+	SubtypeOneOf SubtypeKind = "oneof"
+	// SubtypeLabel is the label for a bool's checkbox. Written as label("text").
+	SubtypeLabel SubtypeKind = "label"
+	// SubtypeUnit is the unit of a number. Written as unit(ms).
+	SubtypeUnit SubtypeKind = "unit"
+	// SubtypeLanguage is the language of a code property. Written as its name, like ottl.
+	SubtypeLanguage SubtypeKind = "language"
+	// SubtypeHeader means a map's keys are HTTP header names. Written as header.
+	SubtypeHeader SubtypeKind = "header"
+	// SubtypeChecklist is the list of items for a checklist property. Written
+	// as a list of items, each with an id, displayName, value, and an optional tooltipText.
+	SubtypeChecklist SubtypeKind = "checklist"
+)
+
+// the languages that code properties can be written in
+var codeLanguages = []string{"ottl"}
+
+// the property types that each kind of subtype can be used with
+var subtypePropTypes = map[SubtypeKind][]hpsf.PropType{
+	SubtypeOneOf:     {hpsf.PTYPE_STRING, hpsf.PTYPE_INT, hpsf.PTYPE_FLOAT, hpsf.PTYPE_BOOL, hpsf.PTYPE_DUR},
+	SubtypeLabel:     {hpsf.PTYPE_BOOL},
+	SubtypeUnit:      {hpsf.PTYPE_INT, hpsf.PTYPE_FLOAT, hpsf.PTYPE_DUR, hpsf.PTYPE_ARRINT, hpsf.PTYPE_ARRFLOAT},
+	SubtypeLanguage:  {hpsf.PTYPE_CODE},
+	SubtypeHeader:    {hpsf.PTYPE_MAPSTR, hpsf.PTYPE_STRMAP},
+	SubtypeChecklist: {hpsf.PTYPE_CHECK},
+}
+
+// A SubtypeOption is one of the options of a oneof subtype. Label is what the
+// UI shows for the option; it's empty if the value should be shown as is.
+type SubtypeOption struct {
+	Value string
+	Label string
+}
+
+// A Subtype further constrains a property's value, mainly so that the UI can
+// choose a suitable editor for it. Only the fields for its Kind are set.
+type Subtype struct {
+	Kind     SubtypeKind
+	Options  []SubtypeOption // SubtypeOneOf
+	Label    string          // SubtypeLabel
+	Unit     string          // SubtypeUnit
+	Language string          // SubtypeLanguage
+	Items    []ChecklistItem // SubtypeChecklist
+}
+
+// OptionValues returns the values of a oneof subtype's options.
+func (s *Subtype) OptionValues() []string {
+	values := make([]string, len(s.Options))
+	for i, o := range s.Options {
+		values[i] = o.Value
+	}
+	return values
+}
+
+// CheckPropType returns an error if the subtype can't be used with a
+// property of the given type.
+func (s *Subtype) CheckPropType(p hpsf.PropType) error {
+	if !slices.Contains(subtypePropTypes[s.Kind], p) {
+		return fmt.Errorf("a %s subtype can't be used with a %s property", s.Kind, p)
+	}
+	// durations given as numbers are counted in the unit, so it has to be one
+	if s.Kind == SubtypeUnit && p == hpsf.PTYPE_DUR {
+		if _, err := time.ParseDuration("1" + s.Unit); err != nil {
+			return fmt.Errorf("%s is not a unit of time", s.Unit)
+		}
+	}
+	return nil
+}
+
+var subtypeCall = regexp.MustCompile(`^(\w+)\((.*)\)$`)
+var subtypeWord = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// ParseSubtype parses the string form of a subtype, like oneof(a, b).
+func ParseSubtype(s string) (*Subtype, error) {
+	s = strings.TrimSpace(s)
+	m := subtypeCall.FindStringSubmatch(s)
+	if m == nil {
+		switch {
+		case s == string(SubtypeHeader):
+			return &Subtype{Kind: SubtypeHeader}, nil
+		case slices.Contains(codeLanguages, s):
+			return &Subtype{Kind: SubtypeLanguage, Language: s}, nil
+		case subtypeWord.MatchString(s):
+			return nil, fmt.Errorf("unknown subtype %q", s)
+		default:
+			return nil, fmt.Errorf("malformed subtype %q", s)
+		}
+	}
+
+	args, err := splitSubtypeArgs(m[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed subtype %q: %w", s, err)
+	}
+	switch SubtypeKind(m[1]) {
+	case SubtypeOneOf:
+		st := &Subtype{Kind: SubtypeOneOf}
+		for _, arg := range args {
+			o, err := parseSubtypeOption(arg)
+			if err != nil {
+				return nil, fmt.Errorf("malformed subtype %q: %w", s, err)
+			}
+			if o.Value == "" {
+				return nil, fmt.Errorf("malformed subtype %q: options can't be empty", s)
+			}
+			if slices.Contains(st.OptionValues(), o.Value) {
+				return nil, fmt.Errorf("malformed subtype %q: option %s is repeated", s, o.Value)
+			}
+			st.Options = append(st.Options, o)
+		}
+		if len(st.Options) == 0 {
+			return nil, fmt.Errorf("malformed subtype %q: oneof needs at least one option", s)
+		}
+		return st, nil
+	case SubtypeLabel, SubtypeUnit:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("malformed subtype %q: %s takes exactly one argument", s, m[1])
+		}
+		arg, err := unquoteSubtypeArg(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("malformed subtype %q: %w", s, err)
+		}
+		if arg == "" {
+			return nil, fmt.Errorf("malformed subtype %q: %s can't be empty", s, m[1])
+		}
+		if m[1] == string(SubtypeLabel) {
+			return &Subtype{Kind: SubtypeLabel, Label: arg}, nil
+		}
+		return &Subtype{Kind: SubtypeUnit, Unit: arg}, nil
+	default:
+		return nil, fmt.Errorf("unknown subtype %q", s)
+	}
+}
+
+// splitSubtypeArgs splits the arguments of a subtype on commas, except for
+// commas inside double-quoted strings.
+func splitSubtypeArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && quoted && i+1 < len(s):
+			current.WriteByte(c)
+			i++
+			current.WriteByte(s[i])
+			continue
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			args = append(args, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	if quoted {
+		return nil, errors.New("unterminated string")
+	}
+	if last := strings.TrimSpace(current.String()); last != "" || len(args) > 0 {
+		args = append(args, last)
+	}
+	return args, nil
+}
+
+// parseSubtypeOption parses one option of a oneof subtype: a value, which
+// can be quoted, optionally followed by a quoted label.
+func parseSubtypeOption(arg string) (SubtypeOption, error) {
+	var o SubtypeOption
+	rest := arg
+	switch i := strings.Index(rest, `"`); {
+	case i < 0:
+		return SubtypeOption{Value: rest}, nil
+	case i == 0:
+		q, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return o, err
+		}
+		o.Value, _ = strconv.Unquote(q)
+		rest = strings.TrimSpace(rest[len(q):])
+	default:
+		o.Value = strings.TrimSpace(rest[:i])
+		rest = rest[i:]
+	}
+	if rest == "" {
+		return o, nil
+	}
+	q, err := strconv.QuotedPrefix(rest)
+	if err != nil || len(q) != len(rest) {
+		return o, fmt.Errorf("option %s must be a value followed by an optional quoted label", arg)
+	}
+	o.Label, _ = strconv.Unquote(q)
+	return o, nil
+}
+
+func unquoteSubtypeArg(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+	return strconv.Unquote(s)
+}
+
+// quoteSubtypeArg quotes an argument if it wouldn't otherwise parse back to the same value.
+func quoteSubtypeArg(s string) string {
+	if s == "" || strings.ContainsAny(s, `,"()\`) || strings.TrimSpace(s) != s {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// String returns the subtype in the form it's written in component YAML.
+func (s *Subtype) String() string {
+	switch s.Kind {
+	case SubtypeOneOf:
+		options := make([]string, len(s.Options))
+		for i, o := range s.Options {
+			options[i] = quoteSubtypeArg(o.Value)
+			if o.Label != "" {
+				options[i] += " " + strconv.Quote(o.Label)
+			}
+		}
+		return "oneof(" + strings.Join(options, ", ") + ")"
+	case SubtypeLabel:
+		return "label(" + strconv.Quote(s.Label) + ")"
+	case SubtypeUnit:
+		return "unit(" + quoteSubtypeArg(s.Unit) + ")"
+	case SubtypeLanguage:
+		return s.Language
+	default:
+		return string(s.Kind)
+	}
+}
+
+var _ y.Marshaler = (*Subtype)(nil)
+var _ y.Unmarshaler = (*Subtype)(nil)
+
+func (s *Subtype) UnmarshalYAML(value *y.Node) error {
+	if value.Kind == y.SequenceNode {
+		var items []ChecklistItem
+		if err := value.Decode(&items); err != nil {
+			return err
+		}
+		ids := make(map[string]bool)
+		for _, item := range items {
+			if item.ID == "" || item.DisplayName == "" || item.Value == "" {
+				return fmt.Errorf("checklist item %q must have an id, displayName, and value", item.ID)
+			}
+			if ids[item.ID] {
+				return fmt.Errorf("checklist item %q is repeated", item.ID)
+			}
+			ids[item.ID] = true
+		}
+		*s = Subtype{Kind: SubtypeChecklist, Items: items}
+		return nil
+	}
+
+	var str string
+	if err := value.Decode(&str); err != nil {
+		return fmt.Errorf("subtype must be a string or a list of checklist items: %w", err)
+	}
+	st, err := ParseSubtype(str)
+	if err != nil {
+		return err
+	}
+	*s = *st
+	return nil
+}
+
+func (s Subtype) MarshalYAML() (any, error) {
+	if s.Kind == SubtypeChecklist {
+		return s.Items, nil
+	}
+	return s.String(), nil
+}
+
+// MarshalJSON writes the subtype in the same form as in YAML.
+func (s Subtype) MarshalJSON() ([]byte, error) {
+	v, _ := s.MarshalYAML()
+	return json.Marshal(v)
+}
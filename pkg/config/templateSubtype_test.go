@@ -0,0 +1,168 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	y "gopkg.in/yaml.v3"
+)
+
+func TestParseSubtype(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Subtype
+		out  string
+	}{
+		{
+			in:   "oneof(basic, normal, detailed)",
+			want: Subtype{Kind: SubtypeOneOf, Options: []SubtypeOption{{Value: "basic"}, {Value: "normal"}, {Value: "detailed"}}},
+		},
+		{
+			in:   "oneof(Trace,Debug)",
+			want: Subtype{Kind: SubtypeOneOf, Options: []SubtypeOption{{Value: "Trace"}, {Value: "Debug"}}},
+			out:  "oneof(Trace, Debug)",
+		},
+		{
+			in:   "oneof(=, !=, >=)",
+			want: Subtype{Kind: SubtypeOneOf, Options: []SubtypeOption{{Value: "="}, {Value: "!="}, {Value: ">="}}},
+		},
+		{
+			in:   "oneof(AWS S3, Google Cloud Storage)",
+			want: Subtype{Kind: SubtypeOneOf, Options: []SubtypeOption{{Value: "AWS S3"}, {Value: "Google Cloud Storage"}}},
+		},
+		{
+			in: `oneof(all "Everything", none "Nothing, at all", "a, b")`,
+			want: Subtype{Kind: SubtypeOneOf, Options: []SubtypeOption{
+				{Value: "all", Label: "Everything"}, {Value: "none", Label: "Nothing, at all"}, {Value: "a, b"},
+			}},
+		},
+		{
+			in:   `label("Value is 'true'")`,
+			want: Subtype{Kind: SubtypeLabel, Label: "Value is 'true'"},
+		},
+		{
+			in:   "unit(ms)",
+			want: Subtype{Kind: SubtypeUnit, Unit: "ms"},
+		},
+		{
+			in:   "ottl",
+			want: Subtype{Kind: SubtypeLanguage, Language: "ottl"},
+		},
+		{
+			in:   "header",
+			want: Subtype{Kind: SubtypeHeader},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			st, err := ParseSubtype(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *st)
+
+			out := tt.out
+			if out == "" {
+				out = tt.in
+			}
+			assert.Equal(t, out, st.String())
+			again, err := ParseSubtype(st.String())
+			require.NoError(t, err)
+			assert.Equal(t, st, again)
+		})
+	}
+}
+
+func TestParseSubtype_Errors(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{"oneof()", `malformed subtype "oneof()": oneof needs at least one option`},
+		{"oneof(a, , b)", `malformed subtype "oneof(a, , b)": options can't be empty`},
+		{"oneof(a, b, a)", `malformed subtype "oneof(a, b, a)": option a is repeated`},
+		{`oneof(a "label" extra)`, `malformed subtype "oneof(a \"label\" extra)": option a "label" extra must be a value followed by an optional quoted label`},
+		{`oneof("a, b)`, `malformed subtype "oneof(\"a, b)": unterminated string`},
+		{"label()", `malformed subtype "label()": label takes exactly one argument`},
+		{"label(a, b)", `malformed subtype "label(a, b)": label takes exactly one argument`},
+		{`label("")`, `malformed subtype "label(\"\")": label can't be empty`},
+		{"someof(a, b)", `unknown subtype "someof(a, b)"`},
+		{"python", `unknown subtype "python"`},
+		{"oneof(a", `malformed subtype "oneof(a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			_, err := ParseSubtype(tt.in)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTemplateProperty_UnmarshalSubtype(t *testing.T) {
+	var tp TemplateProperty
+	require.NoError(t, y.Unmarshal([]byte(`
+name: Verbosity
+type: string
+subtype: oneof(basic, normal)
+`), &tp))
+	require.NotNil(t, tp.Subtype)
+	assert.Equal(t, []string{"basic", "normal"}, tp.Subtype.OptionValues())
+
+	require.NoError(t, y.Unmarshal([]byte(`
+name: Patterns
+type: checklist
+subtype:
+  - id: ssn
+    displayName: Social Security number
+    value: '\d{3}-\d{2}-\d{4}'
+`), &tp))
+	assert.Equal(t, SubtypeChecklist, tp.Subtype.Kind)
+	assert.Equal(t, []ChecklistItem{{ID: "ssn", DisplayName: "Social Security number", Value: `\d{3}-\d{2}-\d{4}`}}, tp.Subtype.Items)
+
+	// subtypes are written back the way they were read
+	out, err := y.Marshal(tp)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "subtype:\n    - id: ssn\n")
+	tp.Type = hpsf.PTYPE_BOOL
+	tp.Subtype = &Subtype{Kind: SubtypeLabel, Label: "Enabled"}
+	out, err = y.Marshal(tp)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `subtype: label("Enabled")`)
+	js, err := json.Marshal(tp.Subtype)
+	require.NoError(t, err)
+	assert.Equal(t, `"label(\"Enabled\")"`, string(js))
+
+	errTests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "wrong type",
+			yaml:    "name: Level\ntype: int\nsubtype: label(\"Level\")",
+			wantErr: "property Level: a label subtype can't be used with a int property",
+		},
+		{
+			name:    "malformed",
+			yaml:    "name: Level\ntype: string\nsubtype: oneof(",
+			wantErr: `malformed subtype "oneof("`,
+		},
+		{
+			name:    "incomplete checklist item",
+			yaml:    "name: Patterns\ntype: checklist\nsubtype:\n  - id: ssn\n    value: x",
+			wantErr: `checklist item "ssn" must have an id, displayName, and value`,
+		},
+		{
+			name:    "repeated checklist item",
+			yaml:    "name: Patterns\ntype: checklist\nsubtype:\n  - {id: a, displayName: A, value: x}\n  - {id: a, displayName: B, value: y}",
+			wantErr: `checklist item "a" is repeated`,
+		},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tp TemplateProperty
+			assert.EqualError(t, y.Unmarshal([]byte(tt.yaml), &tp), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// A ComponentUpgrade describes how to upgrade a component in an HPSF document
+// from one major version of its template to the next. Components declare the
+// upgrades from each of their older major versions, so that documents written
+// against an older version can still be used after a breaking change.
+//
+// All of the rules refer to properties and ports by their names in the older
+// version. They are applied in this order:
+//   - RemoveProperties lists properties that no longer exist
+//   - MapValues replaces property values; the keys are property names, and
+//     the values map an old value (written as a string) to its replacement
+//   - RenameProperties maps old property names to new ones
+//   - RenamePorts maps old port names to new ones; connections to the
+//     component are updated to match
+type ComponentUpgrade struct {
+	From             string                    `yaml:"from"`
+	Description      string                    `yaml:"description,omitempty"`
+	RemoveProperties []string                  `yaml:"removeProperties,omitempty"`
+	MapValues        map[string]map[string]any `yaml:"mapValues,omitempty"`
+	RenameProperties map[string]string         `yaml:"renameProperties,omitempty"`
+	RenamePorts      map[string]string         `yaml:"renamePorts,omitempty"`
+}
+
+// MapValue returns the replacement for a property value, if there is one.
+// Values are compared by their string form, so that a rule written as "1"
+// matches both the string "1" and the number 1.
+func (u ComponentUpgrade) MapValue(property string, value any) (any, bool) {
+	values, ok := u.MapValues[property]
+	if !ok {
+		return nil, false
+	}
+	replacement, ok := values[fmt.Sprint(value)]
+	return replacement, ok
+}
+
+// nextMajor returns the major version after the given one, e.g. v1 for v0.
+func nextMajor(major string) (string, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(major, "v"))
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("v%d", n+1), true
+}
+
+// UpgradePlan returns the upgrades that would bring a component at the given
+// version up to this template's version, in the order they should be applied.
+// The result is empty if the version is already compatible with the template.
+// It returns false if the component can't be used with this template, either
+// because the version is newer than the template's or because there is a
+// major version with no upgrade declared from it.
+func (t *TemplateComponent) UpgradePlan(version string) ([]ComponentUpgrade, bool) {
+	if version == "" || version == t.Version {
+		return nil, true
+	}
+	if !semver.IsValid(t.Version) || !semver.IsValid(version) {
+		return nil, false
+	}
+	if semver.Compare(t.Version, version) < 0 {
+		return nil, false
+	}
+
+	upgrades := make(map[string]ComponentUpgrade)
+	for _, u := range t.Upgrades {
+		upgrades[u.From] = u
+	}
+	var plan []ComponentUpgrade
+	target := semver.Major(t.Version)
+	for major := semver.Major(version); major != target; {
+		u, ok := upgrades[major]
+		if !ok {
+			return nil, false
+		}
+		plan = append(plan, u)
+		if major, ok = nextMajor(major); !ok {
+			return nil, false
+		}
+	}
+	return plan, true
+}
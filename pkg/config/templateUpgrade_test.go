@@ -0,0 +1,81 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	y "gopkg.in/yaml.v3"
+)
+
+const upgradeTestYAML = `kind: Thing
+version: v2.1.0
+upgrades:
+  - from: v1
+    description: Mode values were renamed
+    mapValues:
+      Mode:
+        fast: quick
+        "1": one
+  - from: v0
+    removeProperties: [Legacy]
+    renameProperties:
+      Old: New
+    renamePorts:
+      Spans: Traces
+`
+
+func TestTemplateComponent_UpgradePlan(t *testing.T) {
+	var tc TemplateComponent
+	require.NoError(t, y.Unmarshal([]byte(upgradeTestYAML), &tc))
+	require.Len(t, tc.Upgrades, 2)
+	assert.Equal(t, map[string]string{"Spans": "Traces"}, tc.Upgrades[1].RenamePorts)
+
+	tests := []struct {
+		version string
+		froms   []string
+		ok      bool
+	}{
+		{"", nil, true},
+		{"v2.1.0", nil, true},
+		{"v2.0.0", nil, true},
+		{"v2.2.0", nil, false},
+		{"v1.3.0", []string{"v1"}, true},
+		{"v0.1.0", []string{"v0", "v1"}, true},
+		{"v3.0.0", nil, false},
+		{"invalid", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			plan, ok := tc.UpgradePlan(tt.version)
+			assert.Equal(t, tt.ok, ok)
+			var froms []string
+			for _, u := range plan {
+				froms = append(froms, u.From)
+			}
+			assert.Equal(t, tt.froms, froms)
+		})
+	}
+
+	// a missing step means there's no way to upgrade
+	tc.Upgrades = tc.Upgrades[:1]
+	_, ok := tc.UpgradePlan("v0.1.0")
+	assert.False(t, ok)
+}
+
+func TestComponentUpgrade_MapValue(t *testing.T) {
+	var tc TemplateComponent
+	require.NoError(t, y.Unmarshal([]byte(upgradeTestYAML), &tc))
+	u := tc.Upgrades[0]
+
+	v, ok := u.MapValue("Mode", "fast")
+	assert.True(t, ok)
+	assert.Equal(t, "quick", v)
+	v, ok = u.MapValue("Mode", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "one", v)
+	_, ok = u.MapValue("Mode", "slow")
+	assert.False(t, ok)
+	_, ok = u.MapValue("Other", "fast")
+	assert.False(t, ok)
+}
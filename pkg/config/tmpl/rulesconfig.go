@@ -157,32 +157,60 @@ func (rc *RulesConfig) RenderToMap(m map[string]any) map[string]any {
 // promote the sampler to the top level.
 func (rc *RulesConfig) maybePromoteSingleRuleSampler() {
 	for env, sampler := range rc.Samplers {
-		if sampler != nil && sampler.RulesBasedSampler != nil && len(sampler.RulesBasedSampler.Rules) == 1 {
-			rule := sampler.RulesBasedSampler.Rules[0]
-			if len(rule.Conditions) == 0 {
-				if rule.Sampler != nil {
-					// Replace the V2SamplerChoice with the underlying sampler
-					if rule.Sampler.DynamicSampler != nil {
-						rc.Samplers[env] = &V2SamplerChoice{DynamicSampler: rule.Sampler.DynamicSampler}
-					} else if rule.Sampler.EMADynamicSampler != nil {
-						rc.Samplers[env] = &V2SamplerChoice{EMADynamicSampler: rule.Sampler.EMADynamicSampler}
-					} else if rule.Sampler.EMAThroughputSampler != nil {
-						rc.Samplers[env] = &V2SamplerChoice{EMAThroughputSampler: rule.Sampler.EMAThroughputSampler}
-					} else if rule.Sampler.WindowedThroughputSampler != nil {
-						rc.Samplers[env] = &V2SamplerChoice{WindowedThroughputSampler: rule.Sampler.WindowedThroughputSampler}
-					} else if rule.Sampler.TotalThroughputSampler != nil {
-						rc.Samplers[env] = &V2SamplerChoice{TotalThroughputSampler: rule.Sampler.TotalThroughputSampler}
-					} else if rule.Sampler.DeterministicSampler != nil {
-						rc.Samplers[env] = &V2SamplerChoice{DeterministicSampler: rule.Sampler.DeterministicSampler}
-					}
-				} else if !rule.Drop {
-					// The rules sampler had no conditions, no samplers set, and was not dropping.
-					// We default to grabbing the 1 rule's SampleRate and making a deterministic sampler.
-					rc.Samplers[env] = &V2SamplerChoice{DeterministicSampler: &DeterministicSamplerConfig{SampleRate: rule.SampleRate}}
-				}
-			}
+		if promoted, _ := promotedSampler(sampler); promoted != nil {
+			rc.Samplers[env] = promoted
+		}
+	}
+}
+
+// PromotedSamplers returns the environments whose rules-based sampler has a
+// single rule with no conditions, which RenderYAML writes out as the rule's
+// sampler instead, mapped to the type of that sampler (like
+// "DeterministicSampler").
+func (rc *RulesConfig) PromotedSamplers() map[string]string {
+	promoted := make(map[string]string)
+	for env, sampler := range rc.Samplers {
+		if choice, samplerType := promotedSampler(sampler); choice != nil {
+			promoted[env] = samplerType
 		}
 	}
+	return promoted
+}
+
+// promotedSampler returns the sampler that should replace a rules-based sampler
+// with a single rule and no conditions, along with its type, or nil if the
+// sampler should be left alone.
+func promotedSampler(sampler *V2SamplerChoice) (*V2SamplerChoice, string) {
+	if sampler == nil || sampler.RulesBasedSampler == nil || len(sampler.RulesBasedSampler.Rules) != 1 {
+		return nil, ""
+	}
+	rule := sampler.RulesBasedSampler.Rules[0]
+	if len(rule.Conditions) != 0 {
+		return nil, ""
+	}
+	if rule.Sampler != nil {
+		// Replace the V2SamplerChoice with the underlying sampler
+		if rule.Sampler.DynamicSampler != nil {
+			return &V2SamplerChoice{DynamicSampler: rule.Sampler.DynamicSampler}, "DynamicSampler"
+		} else if rule.Sampler.EMADynamicSampler != nil {
+			return &V2SamplerChoice{EMADynamicSampler: rule.Sampler.EMADynamicSampler}, "EMADynamicSampler"
+		} else if rule.Sampler.EMAThroughputSampler != nil {
+			return &V2SamplerChoice{EMAThroughputSampler: rule.Sampler.EMAThroughputSampler}, "EMAThroughputSampler"
+		} else if rule.Sampler.WindowedThroughputSampler != nil {
+			return &V2SamplerChoice{WindowedThroughputSampler: rule.Sampler.WindowedThroughputSampler}, "WindowedThroughputSampler"
+		} else if rule.Sampler.TotalThroughputSampler != nil {
+			return &V2SamplerChoice{TotalThroughputSampler: rule.Sampler.TotalThroughputSampler}, "TotalThroughputSampler"
+		} else if rule.Sampler.DeterministicSampler != nil {
+			return &V2SamplerChoice{DeterministicSampler: rule.Sampler.DeterministicSampler}, "DeterministicSampler"
+		}
+		return nil, ""
+	}
+	if !rule.Drop {
+		// The rules sampler had no conditions, no samplers set, and was not dropping.
+		// We default to grabbing the 1 rule's SampleRate and making a deterministic sampler.
+		return &V2SamplerChoice{DeterministicSampler: &DeterministicSamplerConfig{SampleRate: rule.SampleRate}}, "DeterministicSampler"
+	}
+	return nil, ""
 }
 
 func (rc *RulesConfig) RenderYAML() ([]byte, error) {
@@ -235,6 +263,16 @@ func (rc *RulesConfig) Merge(other TemplateConfig) error {
 		return fmt.Errorf("cannot merge %T with RulesConfig", other)
 	}
 
+	// a condition can carry a whole rule, which is merged after its other kvs
+	var rule any
+	if otherRC.compType == Condition {
+		var kvs map[string]any
+		kvs, rule = splitRule(otherRC.kvs)
+		stripped := *otherRC
+		stripped.kvs = kvs
+		otherRC = &stripped
+	}
+
 	// All merges convert the kvs (which is a path-based key and value) to a renderable Refinery configuration,
 	// and also flag the output with type "output".
 	// Our possible merge types are:
@@ -337,6 +375,12 @@ func (rc *RulesConfig) Merge(other TemplateConfig) error {
 				}
 			}
 		}
+		if rule != nil {
+			ruleIndex, _ := strconv.Atoi(rc.meta[MetaPipelineIndex])
+			if err := mergeRule(sampler, ruleIndex, rule); err != nil {
+				return err
+			}
+		}
 		rc.Samplers[rc.meta[MetaEnv]] = sampler
 
 	case Output:
@@ -414,6 +458,11 @@ func (rc *RulesConfig) Merge(other TemplateConfig) error {
 					return err
 				}
 			}
+			if rule != nil {
+				if err := mergeRule(sampler, ruleIndex, rule); err != nil {
+					return err
+				}
+			}
 
 			rc.Samplers[rc.meta[MetaEnv]] = sampler
 		case Sampler:
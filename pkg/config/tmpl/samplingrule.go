@@ -0,0 +1,128 @@
+package tmpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/honeycombio/hpsf/pkg/config/decorator"
+	y "gopkg.in/yaml.v3"
+)
+
+// RuleKey is the key under which a condition component passes a whole
+// sampling rule (from a property of type "rule") to Merge. The rule's
+// conditions, scope, and sampler are merged into the rule for the pipeline,
+// alongside any conditions the component specifies with its other keys.
+const RuleKey = "Rule"
+
+// ParseRule converts the value of a rule property, which is a map using the
+// same keys as a rule in a Refinery rules file, into a RulesBasedSamplerRule.
+// It returns an error if the value has keys that Refinery wouldn't recognize,
+// or values of the wrong type, or if it doesn't hang together as a rule.
+func ParseRule(value any) (*RulesBasedSamplerRule, error) {
+	if s, ok := value.(string); ok {
+		value = decorator.Undecorate(s)
+	}
+	if _, ok := value.(map[string]any); !ok {
+		return nil, fmt.Errorf("expected a dictionary for a rule, got %T", value)
+	}
+
+	// we round-trip through YAML so that the checking is done by the same
+	// struct tags that we use to render the rules
+	data, err := y.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var rule RulesBasedSamplerRule
+	dec := y.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&rule); err != nil {
+		return nil, err
+	}
+
+	switch rule.Scope {
+	case "", "trace", "span":
+	default:
+		return nil, fmt.Errorf("scope must be trace or span, not %s", rule.Scope)
+	}
+	for i, cond := range rule.Conditions {
+		if cond == nil || cond.Operator == "" {
+			return nil, fmt.Errorf("condition %d must have an Operator", i+1)
+		}
+	}
+	if rule.Sampler != nil && samplerCount(rule.Sampler) != 1 {
+		return nil, errors.New("Sampler must specify exactly one sampler")
+	}
+	return &rule, nil
+}
+
+func samplerCount(s *RulesBasedDownstreamSampler) int {
+	n := 0
+	for _, set := range []bool{
+		s.DynamicSampler != nil,
+		s.EMADynamicSampler != nil,
+		s.EMAThroughputSampler != nil,
+		s.WindowedThroughputSampler != nil,
+		s.TotalThroughputSampler != nil,
+		s.DeterministicSampler != nil,
+	} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// splitRule separates a rule passed under RuleKey from the rest of the kvs;
+// it returns the kvs unchanged if there's no rule.
+func splitRule(kvs map[string]any) (map[string]any, any) {
+	rule, ok := kvs[RuleKey]
+	if !ok {
+		return kvs, nil
+	}
+	kvs = maps.Clone(kvs)
+	delete(kvs, RuleKey)
+	return kvs, rule
+}
+
+// mergeRule merges a rule into the rule at ruleIndex of the sampler. The
+// conditions are appended to any that are already there, and the scopes are
+// merged the same way as for condition components; the name and the sampling
+// settings are only set if the rule specifies them.
+func mergeRule(sampler *V2SamplerChoice, ruleIndex int, value any) error {
+	rule, err := ParseRule(value)
+	if err != nil {
+		return fmt.Errorf("invalid rule: %w", err)
+	}
+
+	if sampler.RulesBasedSampler == nil {
+		sampler.RulesBasedSampler = &RulesBasedSamplerConfig{}
+	}
+	for len(sampler.RulesBasedSampler.Rules) <= ruleIndex {
+		sampler.RulesBasedSampler.Rules = append(sampler.RulesBasedSampler.Rules, &RulesBasedSamplerRule{})
+	}
+	target := sampler.RulesBasedSampler.Rules[ruleIndex]
+
+	target.Conditions = append(target.Conditions, rule.Conditions...)
+	if rule.Scope != "" {
+		currentScope := "trace" // default scope
+		if target.Scope != "" {
+			currentScope = target.Scope
+		}
+		target.Scope = mergeScopeValues(currentScope, rule.Scope)
+	}
+	if rule.Name != "" {
+		target.Name = rule.Name
+	}
+	if rule.SampleRate != 0 {
+		target.SampleRate = rule.SampleRate
+	}
+	if rule.Drop {
+		target.Drop = true
+	}
+	if rule.Sampler != nil {
+		target.Sampler = rule.Sampler
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package tmpl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/honeycombio/hpsf/pkg/config/decorator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule(map[string]any{
+		"Name":  "slow checkout",
+		"Scope": "span",
+		"Conditions": []any{
+			map[string]any{"Field": "duration_ms", "Operator": ">", "Value": 1000, "Datatype": "int"},
+		},
+		"Sampler": map[string]any{
+			"EMADynamicSampler": map[string]any{"GoalSampleRate": 10, "AdjustmentInterval": "15s"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "slow checkout", rule.Name)
+	assert.Equal(t, "span", rule.Scope)
+	require.Len(t, rule.Conditions, 1)
+	assert.Equal(t, "duration_ms", rule.Conditions[0].Field)
+	assert.Equal(t, 1000, rule.Conditions[0].Value)
+	assert.Equal(t, 10, rule.Sampler.EMADynamicSampler.GoalSampleRate)
+	assert.Equal(t, Duration(15*time.Second), rule.Sampler.EMADynamicSampler.AdjustmentInterval)
+
+	// rules arrive at Merge encoded by the templates
+	rule, err = ParseRule(decorator.EncodeAsMap(map[string]any{"SampleRate": 5}))
+	require.NoError(t, err)
+	assert.Equal(t, 5, rule.SampleRate)
+
+	tests := []struct {
+		name    string
+		value   any
+		wantErr string
+	}{
+		{"not a map", []any{"a"}, "expected a dictionary for a rule, got []interface {}"},
+		{"unknown key", map[string]any{"Condition": []any{}}, "field Condition not found in type tmpl.RulesBasedSamplerRule"},
+		{"wrong type", map[string]any{"SampleRate": "lots"}, "cannot unmarshal !!str `lots` into int"},
+		{"bad scope", map[string]any{"Scope": "everything"}, "scope must be trace or span, not everything"},
+		{"no operator", map[string]any{"Conditions": []any{map[string]any{"Field": "a"}}}, "condition 1 must have an Operator"},
+		{"no sampler", map[string]any{"Sampler": map[string]any{}}, "Sampler must specify exactly one sampler"},
+		{"two samplers", map[string]any{"Sampler": map[string]any{
+			"DynamicSampler":       map[string]any{"SampleRate": 2},
+			"DeterministicSampler": map[string]any{"SampleRate": 3},
+		}}, "Sampler must specify exactly one sampler"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRule(tt.value)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestMergeRule(t *testing.T) {
+	start := NewRulesConfig(StartSampling, map[string]string{MetaPipelineIndex: "0", MetaEnv: "__default__"}, nil)
+	cond := NewRulesConfig(Condition, map[string]string{MetaPipelineIndex: "0", "scope": "trace"}, map[string]any{
+		"Field":    "error",
+		"Operator": "exists",
+	})
+	require.NoError(t, start.Merge(cond))
+
+	ruleCond := NewRulesConfig(Condition, map[string]string{MetaPipelineIndex: "0"}, map[string]any{
+		RuleKey: decorator.EncodeAsMap(map[string]any{
+			"Scope": "span",
+			"Conditions": []any{
+				map[string]any{"Field": "http.status_code", "Operator": ">=", "Value": 500},
+			},
+			"Sampler": map[string]any{"DeterministicSampler": map[string]any{"SampleRate": 4}},
+		}),
+	})
+	require.NoError(t, start.Merge(ruleCond))
+
+	rules := start.Samplers["__default__"].RulesBasedSampler.Rules
+	require.Len(t, rules, 1)
+	require.Len(t, rules[0].Conditions, 2)
+	assert.Equal(t, "error", rules[0].Conditions[0].Field)
+	assert.Equal(t, "http.status_code", rules[0].Conditions[1].Field)
+	assert.Equal(t, 500, rules[0].Conditions[1].Value)
+	assert.Equal(t, "span", rules[0].Scope)
+	assert.Equal(t, 4, rules[0].Sampler.DeterministicSampler.SampleRate)
+
+	// the rule isn't treated as a condition key
+	start = NewRulesConfig(StartSampling, map[string]string{MetaPipelineIndex: "1", MetaEnv: "__default__"}, nil)
+	require.NoError(t, start.Merge(ruleCond))
+	rules = start.Samplers["__default__"].RulesBasedSampler.Rules
+	require.Len(t, rules, 2)
+	require.Len(t, rules[1].Conditions, 1)
+	assert.Equal(t, "http.status_code", rules[1].Conditions[0].Field)
+
+	bad := NewRulesConfig(Condition, map[string]string{MetaPipelineIndex: "0"}, map[string]any{
+		RuleKey: decorator.EncodeAsMap(map[string]any{"Scope": "nowhere"}),
+	})
+	assert.EqualError(t, start.Merge(bad), "invalid rule: scope must be trace or span, not nowhere")
+}
@@ -1,6 +1,8 @@
 package data
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/honeycombio/hpsf/pkg/config"
@@ -95,3 +97,33 @@ func TestDefaultConfigurationIsValidYAML(t *testing.T) {
 	err = hpsf.EnsureHPSFYAML(data)
 	require.NoError(t, err)
 }
+
+// templates and examples carry their own version, which isn't a format
+// version, so they have to migrate cleanly
+func TestMigrateTemplatesAndExamples(t *testing.T) {
+	templates, err := LoadEmbeddedTemplates()
+	require.NoError(t, err)
+	for kind, template := range templates {
+		t.Run(kind, func(t *testing.T) {
+			migrated, steps, err := hpsf.Migrate(&template, "")
+			require.NoError(t, err)
+			require.Empty(t, steps)
+			require.Equal(t, template.Version, migrated.Version)
+		})
+	}
+
+	examples, err := filepath.Glob(filepath.Join("..", "..", "examples", "*.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, examples)
+	for _, path := range examples {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			h, err := hpsf.FromYAML(string(data))
+			require.NoError(t, err)
+			_, steps, err := hpsf.Migrate(&h, "")
+			require.NoError(t, err)
+			require.Empty(t, steps)
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/honeycombio/hpsf/pkg/data"
 	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/translator"
 	"gopkg.in/yaml.v3"
 )
 
@@ -40,6 +42,7 @@ type RulesBasedSampler struct {
 // Generator provides functionality to convert Refinery configurations to HPSF workflows
 type Generator struct {
 	componentCounter int
+	warnings         []string
 }
 
 // NewGenerator creates a new Generator instance
@@ -47,217 +50,175 @@ func NewGenerator() *Generator {
 	return &Generator{componentCounter: 1}
 }
 
-// GenerateWorkflow creates an HPSF workflow from Refinery rules
+// GenerateWorkflow creates an HPSF workflow from Refinery rules. Settings that
+// the components can't take, like a SampleRate of 0, are left out of the
+// workflow; Warnings describes them.
 func (g *Generator) GenerateWorkflow(rulesData []byte, environment string) (*hpsf.HPSF, error) {
+	g.warnings = nil
+
 	// Parse the Refinery rules
 	var rules RefineryRules
 	if err := yaml.Unmarshal(rulesData, &rules); err != nil {
 		return nil, fmt.Errorf("failed to parse Refinery rules: %w", err)
 	}
 
-	// Create the HPSF workflow
-	workflow := &hpsf.HPSF{
-		Kind:        "HPSF",
-		Version:     "v1",
-		Name:        "Generated_Refinery_Workflow",
-		Summary:     "Generated from Refinery sampling rules",
-		Description: "HPSF workflow automatically generated from Refinery sampling rules",
-		Components:  []*hpsf.Component{},
-		Connections: []*hpsf.Connection{},
+	// The builder checks kinds, ports, and properties against the embedded components
+	components, err := data.LoadEmbeddedComponents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load components: %w", err)
 	}
+	t := translator.NewEmptyTranslator()
+	t.InstallComponents(components)
 
-	// Generate components and connections
-	receiverComponent := g.generateOTelReceiver()
-	workflow.Components = append(workflow.Components, receiverComponent)
+	// Create the HPSF workflow
+	b := t.NewBuilder("Generated_Refinery_Workflow").Version("v1")
+	b.Document().Summary = "Generated from Refinery sampling rules"
+	b.Document().Description = "HPSF workflow automatically generated from Refinery sampling rules"
 
-	startSamplingComponent := g.generateStartSampling()
-	workflow.Components = append(workflow.Components, startSamplingComponent)
+	// Generate components and connections
+	receiverComponent := g.generateOTelReceiver(b)
+	startSamplingComponent := g.generateStartSampling(b)
 
 	// Connect OTel Receiver to Start Sampling
-	workflow.Connections = append(workflow.Connections, g.createConnection(
-		receiverComponent.Name, "Traces", hpsf.CTYPE_TRACES,
-		startSamplingComponent.Name, "Traces", hpsf.CTYPE_TRACES,
-	))
-	workflow.Connections = append(workflow.Connections, g.createConnection(
-		receiverComponent.Name, "Logs", hpsf.CTYPE_LOGS,
-		startSamplingComponent.Name, "Logs", hpsf.CTYPE_LOGS,
-	))
+	receiverComponent.Connect("Traces", startSamplingComponent).Connect("Logs", startSamplingComponent)
 
 	// Generate sampling components from rules
-	ruleComponents, ruleConnections, err := g.generateSamplingComponents(rules, startSamplingComponent.Name, environment)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate sampling components: %w", err)
-	}
-
-	workflow.Components = append(workflow.Components, ruleComponents...)
-	workflow.Connections = append(workflow.Connections, ruleConnections...)
+	samplerComponents := g.generateSamplingComponents(b, rules, startSamplingComponent, environment)
 
 	// Always generate Honeycomb exporter
-	exporterComponent := g.generateHoneycombExporter()
-	workflow.Components = append(workflow.Components, exporterComponent)
+	exporterComponent := g.generateHoneycombExporter(b)
 
 	// Connect OTel Receiver metrics directly to Honeycomb exporter (metrics bypass sampling)
-	workflow.Connections = append(workflow.Connections, g.createConnection(
-		receiverComponent.Name, "Metrics", hpsf.CTYPE_METRICS,
-		exporterComponent.Name, "Metrics", hpsf.CTYPE_METRICS,
-	))
+	receiverComponent.Connect("Metrics", exporterComponent)
 
 	// Connect all samplers to the Honeycomb exporter
-	for _, component := range ruleComponents {
-		if g.isSamplerComponent(component.Kind) {
-			workflow.Connections = append(workflow.Connections, g.createConnection(
-				component.Name, "Events", hpsf.CTYPE_HONEY,
-				exporterComponent.Name, "Events", hpsf.CTYPE_HONEY,
-			))
-		}
+	for _, component := range samplerComponents {
+		component.Connect("Events", exporterComponent)
 	}
 
+	workflow, err := b.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate workflow: %w", err)
+	}
 	return workflow, nil
 }
 
-// generateOTelReceiver creates an OpenTelemetry Collector Receiver component
-func (g *Generator) generateOTelReceiver() *hpsf.Component {
-	return &hpsf.Component{
-		Name: g.getNextComponentName("OTel_Receiver"),
-		Kind: "OTelReceiver",
+// Warnings returns a description of each setting from the rules that the last
+// call to GenerateWorkflow left out of the workflow.
+func (g *Generator) Warnings() []string {
+	return g.warnings
+}
+
+// set sets a property to a value from the rules. If the component can't take
+// the value, it's left out with a warning, so the rest of the rules can still
+// be converted.
+func (g *Generator) set(component *translator.BuilderComponent, property string, value any) {
+	if err := component.TrySet(property, value); err != nil {
+		g.warnings = append(g.warnings, fmt.Sprintf("%v; it was left out", err))
 	}
 }
 
+// generateOTelReceiver creates an OpenTelemetry Collector Receiver component
+func (g *Generator) generateOTelReceiver(b *translator.Builder) *translator.BuilderComponent {
+	return b.Add("OTelReceiver").Named(g.getNextComponentName("OTel_Receiver"))
+}
+
 // generateStartSampling creates a Start Sampling component
-func (g *Generator) generateStartSampling() *hpsf.Component {
-	return &hpsf.Component{
-		Name: g.getNextComponentName("Start_Sampling"),
-		Kind: "SamplingSequencer",
-	}
+func (g *Generator) generateStartSampling(b *translator.Builder) *translator.BuilderComponent {
+	return b.Add("SamplingSequencer").Named(g.getNextComponentName("Start_Sampling"))
 }
 
-// generateSamplingComponents creates condition and sampler components based on rules
-func (g *Generator) generateSamplingComponents(rules RefineryRules, startSamplingName string, environment string) ([]*hpsf.Component, []*hpsf.Connection, error) {
-	var components []*hpsf.Component
-	var connections []*hpsf.Connection
+// generateSamplingComponents creates condition and sampler components based on
+// rules, and returns the samplers, which send their events to the exporter
+func (g *Generator) generateSamplingComponents(b *translator.Builder, rules RefineryRules, startSampling *translator.BuilderComponent, environment string) []*translator.BuilderComponent {
+	var samplers []*translator.BuilderComponent
 
 	ruleIndex := 0
 
 	// Process the specified environment's samplers
-	for env, samplers := range rules.Samplers {
+	for env, samplerConfigs := range rules.Samplers {
 		if env != environment {
 			// Skip environments that don't match the specified environment
 			continue
 		}
 
 		// Sort sampler types for deterministic ordering
-		samplerTypes := make([]string, 0, len(samplers))
-		for samplerType := range samplers {
+		samplerTypes := make([]string, 0, len(samplerConfigs))
+		for samplerType := range samplerConfigs {
 			samplerTypes = append(samplerTypes, samplerType)
 		}
 		sort.Strings(samplerTypes)
 
 		for _, samplerType := range samplerTypes {
-			samplerConfig := samplers[samplerType]
+			samplerConfig := samplerConfigs[samplerType]
+			var samplerComponent *translator.BuilderComponent
 			switch samplerType {
 			case "RulesBasedSampler":
-				ruleComponents, ruleConnections := g.generateRulesBasedSampler(samplerConfig, startSamplingName, &ruleIndex)
-				components = append(components, ruleComponents...)
-				connections = append(connections, ruleConnections...)
-
+				samplers = append(samplers, g.generateRulesBasedSampler(b, samplerConfig, startSampling, &ruleIndex)...)
+				continue
 			case "DeterministicSampler":
-				samplerComponent := g.generateDeterministicSampler(samplerConfig)
-				components = append(components, samplerComponent)
-
-				// Connect from Start Sampling to this sampler
-				connections = append(connections, g.createConnection(
-					startSamplingName, fmt.Sprintf("Rule %d", ruleIndex+1), hpsf.CTYPE_SAMPLE,
-					samplerComponent.Name, "Sample", hpsf.CTYPE_SAMPLE,
-				))
-				ruleIndex++
-
+				samplerComponent = g.generateDeterministicSampler(b, samplerConfig)
 			case "EMAThroughputSampler":
-				samplerComponent := g.generateEMAThroughputSampler(samplerConfig)
-				components = append(components, samplerComponent)
-
-				// Connect from Start Sampling to this sampler
-				connections = append(connections, g.createConnection(
-					startSamplingName, fmt.Sprintf("Rule %d", ruleIndex+1), hpsf.CTYPE_SAMPLE,
-					samplerComponent.Name, "Sample", hpsf.CTYPE_SAMPLE,
-				))
-				ruleIndex++
-
+				samplerComponent = g.generateEMAThroughputSampler(b, samplerConfig)
 			case "EMADynamicSampler":
-				samplerComponent := g.generateEMADynamicSampler(samplerConfig)
-				components = append(components, samplerComponent)
-
-				// Connect from Start Sampling to this sampler
-				connections = append(connections, g.createConnection(
-					startSamplingName, fmt.Sprintf("Rule %d", ruleIndex+1), hpsf.CTYPE_SAMPLE,
-					samplerComponent.Name, "Sample", hpsf.CTYPE_SAMPLE,
-				))
-				ruleIndex++
+				samplerComponent = g.generateEMADynamicSampler(b, samplerConfig)
+			default:
+				continue
 			}
+
+			// Connect from Start Sampling to this sampler
+			ruleIndex++
+			startSampling.Connect(fmt.Sprintf("Rule %d", ruleIndex), samplerComponent)
+			samplers = append(samplers, samplerComponent)
 		}
 	}
 
-	return components, connections, nil
+	return samplers
 }
 
-// generateRulesBasedSampler creates components for rules-based sampling
-func (g *Generator) generateRulesBasedSampler(samplerConfig interface{}, startSamplingName string, ruleIndex *int) ([]*hpsf.Component, []*hpsf.Connection) {
-	var components []*hpsf.Component
-	var connections []*hpsf.Connection
+// generateRulesBasedSampler creates components for rules-based sampling. Each
+// rule gets its own output of Start Sampling, which leads through the rule's
+// condition (if it has one) to its sampler or dropper. It returns the samplers.
+func (g *Generator) generateRulesBasedSampler(b *translator.Builder, samplerConfig interface{}, startSampling *translator.BuilderComponent, ruleIndex *int) []*translator.BuilderComponent {
+	var samplers []*translator.BuilderComponent
 
 	// Convert the sampler config to RulesBasedSampler struct
 	configBytes, _ := yaml.Marshal(samplerConfig)
 	var rulesBasedSampler RulesBasedSampler
 	yaml.Unmarshal(configBytes, &rulesBasedSampler)
 
-	var previousComponent string = startSamplingName
-	var previousPort string
-
 	for _, rule := range rulesBasedSampler.Rules {
 		*ruleIndex++
-		previousPort = fmt.Sprintf("Rule %d", *ruleIndex)
+		previousComponent := startSampling
+		previousPort := fmt.Sprintf("Rule %d", *ruleIndex)
 
 		// Generate condition components for this rule
 		if len(rule.Conditions) > 0 {
-			conditionComponent := g.generateConditionComponent(rule.Conditions, rule.Name)
-			components = append(components, conditionComponent)
-
-			// Connect from previous component to condition
-			connections = append(connections, g.createConnection(
-				previousComponent, previousPort, hpsf.CTYPE_SAMPLE,
-				conditionComponent.Name, "Sample", hpsf.CTYPE_SAMPLE,
-			))
+			conditionComponent := g.generateConditionComponent(b, rule.Conditions, rule.Name)
+			previousComponent.Connect(previousPort, conditionComponent)
 
-			previousComponent = conditionComponent.Name
-			previousPort = "Sample"
+			previousComponent = conditionComponent
+			previousPort = "And"
 		}
 
 		// Generate sampler component for this rule
 		if rule.Drop {
 			// Generate Dropper component
-			dropperComponent := g.generateDropperComponent(rule.Name)
-			components = append(components, dropperComponent)
-
-			connections = append(connections, g.createConnection(
-				previousComponent, previousPort, hpsf.CTYPE_SAMPLE,
-				dropperComponent.Name, "Sample", hpsf.CTYPE_SAMPLE,
-			))
+			dropperComponent := g.generateDropperComponent(b, rule.Name)
+			previousComponent.Connect(previousPort, dropperComponent)
 		} else {
 			// Generate appropriate sampler component
-			samplerComponent := g.generateRuleSampler(rule)
-			components = append(components, samplerComponent)
-
-			connections = append(connections, g.createConnection(
-				previousComponent, previousPort, hpsf.CTYPE_SAMPLE,
-				samplerComponent.Name, "Sample", hpsf.CTYPE_SAMPLE,
-			))
+			samplerComponent := g.generateRuleSampler(b, rule)
+			previousComponent.Connect(previousPort, samplerComponent)
+			samplers = append(samplers, samplerComponent)
 		}
 	}
 
-	return components, connections
+	return samplers
 }
 
 // Helper methods for component generation
-func (g *Generator) generateDeterministicSampler(config interface{}) *hpsf.Component {
+func (g *Generator) generateDeterministicSampler(b *translator.Builder, config interface{}) *translator.BuilderComponent {
 	sampleRate := 100 // default
 
 	// Handle both map types that can come from YAML unmarshaling
@@ -276,30 +237,20 @@ func (g *Generator) generateDeterministicSampler(config interface{}) *hpsf.Compo
 		}
 	}
 
-	return &hpsf.Component{
-		Name: g.getNextComponentName("Deterministic_Sampler"),
-		Kind: "DeterministicSampler",
-		Properties: []hpsf.Property{
-			{Name: "SampleRate", Value: sampleRate},
-		},
-	}
+	component := b.Add("DeterministicSampler").Named(g.getNextComponentName("Deterministic_Sampler"))
+	g.set(component, "SampleRate", sampleRate)
+	return component
 }
 
 // generateEMAThroughputSampler creates an EMA Throughput sampler component
-func (g *Generator) generateEMAThroughputSampler(config interface{}) *hpsf.Component {
-	component := &hpsf.Component{
-		Name:       g.getNextComponentName("EMA_Throughput_Sampler"),
-		Kind:       "EMAThroughputSampler",
-		Properties: []hpsf.Property{},
-	}
+func (g *Generator) generateEMAThroughputSampler(b *translator.Builder, config interface{}) *translator.BuilderComponent {
+	component := b.Add("EMAThroughputSampler").Named(g.getNextComponentName("EMA_Throughput_Sampler"))
 
 	// Handle both map types that can come from YAML unmarshaling
 	switch configMap := config.(type) {
 	case map[interface{}]interface{}:
 		if goalThroughput, ok := configMap["GoalThroughputPerSec"]; ok {
-			component.Properties = append(component.Properties, hpsf.Property{
-				Name: "GoalThroughputPerSec", Value: goalThroughput,
-			})
+			g.set(component, "GoalThroughputPerSec", goalThroughput)
 		}
 		if fieldList, ok := configMap["FieldList"]; ok {
 			if fields, ok := fieldList.([]interface{}); ok {
@@ -309,16 +260,12 @@ func (g *Generator) generateEMAThroughputSampler(config interface{}) *hpsf.Compo
 						stringFields = append(stringFields, str)
 					}
 				}
-				component.Properties = append(component.Properties, hpsf.Property{
-					Name: "FieldList", Value: stringFields,
-				})
+				g.set(component, "FieldList", stringFields)
 			}
 		}
 	case map[string]interface{}:
 		if goalThroughput, ok := configMap["GoalThroughputPerSec"]; ok {
-			component.Properties = append(component.Properties, hpsf.Property{
-				Name: "GoalThroughputPerSec", Value: goalThroughput,
-			})
+			g.set(component, "GoalThroughputPerSec", goalThroughput)
 		}
 		if fieldList, ok := configMap["FieldList"]; ok {
 			if fields, ok := fieldList.([]interface{}); ok {
@@ -328,9 +275,7 @@ func (g *Generator) generateEMAThroughputSampler(config interface{}) *hpsf.Compo
 						stringFields = append(stringFields, str)
 					}
 				}
-				component.Properties = append(component.Properties, hpsf.Property{
-					Name: "FieldList", Value: stringFields,
-				})
+				g.set(component, "FieldList", stringFields)
 			}
 		}
 	}
@@ -339,20 +284,14 @@ func (g *Generator) generateEMAThroughputSampler(config interface{}) *hpsf.Compo
 }
 
 // generateEMADynamicSampler creates an EMA Dynamic sampler component
-func (g *Generator) generateEMADynamicSampler(config interface{}) *hpsf.Component {
-	component := &hpsf.Component{
-		Name:       g.getNextComponentName("EMA_Dynamic_Sampler"),
-		Kind:       "EMADynamicSampler",
-		Properties: []hpsf.Property{},
-	}
+func (g *Generator) generateEMADynamicSampler(b *translator.Builder, config interface{}) *translator.BuilderComponent {
+	component := b.Add("EMADynamicSampler").Named(g.getNextComponentName("EMA_Dynamic_Sampler"))
 
 	// Handle both map types that can come from YAML unmarshaling
 	switch configMap := config.(type) {
 	case map[interface{}]interface{}:
 		if goalSampleRate, ok := configMap["GoalSampleRate"]; ok {
-			component.Properties = append(component.Properties, hpsf.Property{
-				Name: "GoalSampleRate", Value: goalSampleRate,
-			})
+			g.set(component, "GoalSampleRate", goalSampleRate)
 		}
 		if fieldList, ok := configMap["FieldList"]; ok {
 			if fields, ok := fieldList.([]interface{}); ok {
@@ -362,16 +301,12 @@ func (g *Generator) generateEMADynamicSampler(config interface{}) *hpsf.Componen
 						stringFields = append(stringFields, str)
 					}
 				}
-				component.Properties = append(component.Properties, hpsf.Property{
-					Name: "FieldList", Value: stringFields,
-				})
+				g.set(component, "FieldList", stringFields)
 			}
 		}
 	case map[string]interface{}:
 		if goalSampleRate, ok := configMap["GoalSampleRate"]; ok {
-			component.Properties = append(component.Properties, hpsf.Property{
-				Name: "GoalSampleRate", Value: goalSampleRate,
-			})
+			g.set(component, "GoalSampleRate", goalSampleRate)
 		}
 		if fieldList, ok := configMap["FieldList"]; ok {
 			if fields, ok := fieldList.([]interface{}); ok {
@@ -381,9 +316,7 @@ func (g *Generator) generateEMADynamicSampler(config interface{}) *hpsf.Componen
 						stringFields = append(stringFields, str)
 					}
 				}
-				component.Properties = append(component.Properties, hpsf.Property{
-					Name: "FieldList", Value: stringFields,
-				})
+				g.set(component, "FieldList", stringFields)
 			}
 		}
 	}
@@ -391,7 +324,7 @@ func (g *Generator) generateEMADynamicSampler(config interface{}) *hpsf.Componen
 	return component
 }
 
-func (g *Generator) generateConditionComponent(conditions []RuleCondition, ruleName string) *hpsf.Component {
+func (g *Generator) generateConditionComponent(b *translator.Builder, conditions []RuleCondition, ruleName string) *translator.BuilderComponent {
 	// For simplicity, we'll generate a single condition component for the first condition
 	// In a more sophisticated implementation, we might handle multiple conditions
 	if len(conditions) == 0 {
@@ -413,7 +346,7 @@ func (g *Generator) generateConditionComponent(conditions []RuleCondition, ruleN
 		}
 		properties = []hpsf.Property{
 			{Name: "Field", Value: field},
-			{Name: "ShouldExist", Value: condition.Operator == "exists"},
+			{Name: "Operator", Value: condition.Operator},
 		}
 
 	case ">=", ">", "<=", "<", "=", "!=":
@@ -428,13 +361,13 @@ func (g *Generator) generateConditionComponent(conditions []RuleCondition, ruleN
 			kind = "CompareStringFieldCondition"
 		}
 
-		// Always use single Field - take first field from Fields array if available
-		field := condition.Field
-		if len(condition.Fields) > 0 {
-			field = condition.Fields[0]
+		// Comparisons can check several fields
+		fields := condition.Fields
+		if len(fields) == 0 {
+			fields = []string{condition.Field}
 		}
 		properties = []hpsf.Property{
-			{Name: "Field", Value: field},
+			{Name: "Fields", Value: fields},
 			{Name: "Operator", Value: condition.Operator},
 			{Name: "Value", Value: condition.Value},
 		}
@@ -448,7 +381,7 @@ func (g *Generator) generateConditionComponent(conditions []RuleCondition, ruleN
 		}
 		properties = []hpsf.Property{
 			{Name: "Field", Value: field},
-			{Name: "Value", Value: condition.Value},
+			{Name: "Substring", Value: condition.Value},
 		}
 
 	case "starts-with":
@@ -460,7 +393,7 @@ func (g *Generator) generateConditionComponent(conditions []RuleCondition, ruleN
 		}
 		properties = []hpsf.Property{
 			{Name: "Field", Value: field},
-			{Name: "Value", Value: condition.Value},
+			{Name: "Prefix", Value: condition.Value},
 		}
 
 	default:
@@ -473,39 +406,29 @@ func (g *Generator) generateConditionComponent(conditions []RuleCondition, ruleN
 		}
 		properties = []hpsf.Property{
 			{Name: "Field", Value: field},
-			{Name: "ShouldExist", Value: true},
+			{Name: "Operator", Value: "exists"},
 		}
 	}
 
-	return &hpsf.Component{
-		Name:       g.getNextComponentName(fmt.Sprintf("Condition_%s", ruleName)),
-		Kind:       kind,
-		Properties: properties,
+	component := b.Add(kind).Named(g.getNextComponentName(fmt.Sprintf("Condition_%s", ruleName)))
+	for _, property := range properties {
+		g.set(component, property.Name, property.Value)
 	}
+	return component
 }
 
-func (g *Generator) generateDropperComponent(ruleName string) *hpsf.Component {
-	return &hpsf.Component{
-		Name: g.getNextComponentName(fmt.Sprintf("Drop_%s", ruleName)),
-		Kind: "Dropper",
-	}
+func (g *Generator) generateDropperComponent(b *translator.Builder, ruleName string) *translator.BuilderComponent {
+	return b.Add("Dropper").Named(g.getNextComponentName(fmt.Sprintf("Drop_%s", ruleName)))
 }
 
-func (g *Generator) generateRuleSampler(rule SamplingRule) *hpsf.Component {
-	return &hpsf.Component{
-		Name: g.getNextComponentName(fmt.Sprintf("Sample_%s", rule.Name)),
-		Kind: "DeterministicSampler",
-		Properties: []hpsf.Property{
-			{Name: "SampleRate", Value: rule.SampleRate},
-		},
-	}
+func (g *Generator) generateRuleSampler(b *translator.Builder, rule SamplingRule) *translator.BuilderComponent {
+	component := b.Add("DeterministicSampler").Named(g.getNextComponentName(fmt.Sprintf("Sample_%s", rule.Name)))
+	g.set(component, "SampleRate", rule.SampleRate)
+	return component
 }
 
-func (g *Generator) generateHoneycombExporter() *hpsf.Component {
-	return &hpsf.Component{
-		Name: g.getNextComponentName("Send_to_Honeycomb"),
-		Kind: "HoneycombExporter",
-	}
+func (g *Generator) generateHoneycombExporter(b *translator.Builder) *translator.BuilderComponent {
+	return b.Add("HoneycombExporter").Named(g.getNextComponentName("Send_to_Honeycomb"))
 }
 
 // Helper methods
@@ -514,34 +437,3 @@ func (g *Generator) getNextComponentName(baseName string) string {
 	g.componentCounter++
 	return name
 }
-
-func (g *Generator) createConnection(srcComp, srcPort string, srcType hpsf.ConnectionType, destComp, destPort string, destType hpsf.ConnectionType) *hpsf.Connection {
-	return &hpsf.Connection{
-		Source: hpsf.ConnectionPort{
-			Component: srcComp,
-			PortName:  srcPort,
-			Type:      srcType,
-		},
-		Destination: hpsf.ConnectionPort{
-			Component: destComp,
-			PortName:  destPort,
-			Type:      destType,
-		},
-	}
-}
-
-func (g *Generator) isSamplerComponent(kind string) bool {
-	samplerKinds := []string{
-		"DeterministicSampler",
-		"EMAThroughputSampler",
-		"EMADynamicSampler",
-		"KeepAllSampler",
-	}
-
-	for _, samplerKind := range samplerKinds {
-		if kind == samplerKind {
-			return true
-		}
-	}
-	return false
-}
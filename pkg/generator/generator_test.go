@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/data"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rulesTestYAML = `RulesVersion: 2
+Samplers:
+  __default__:
+    RulesBasedSampler:
+      Rules:
+        - Name: Errors
+          SampleRate: 1
+          Conditions:
+            - Fields: ["error", "exception"]
+              Operator: exists
+        - Name: Slow
+          SampleRate: 2
+          Conditions:
+            - Fields: ["duration_ms", "latency_ms"]
+              Operator: ">="
+              Value: 5000
+              Datatype: int
+        - Name: Queries
+          SampleRate: 3
+          Conditions:
+            - Field: db.statement
+              Operator: contains
+              Value: SELECT
+        - Name: Health
+          Drop: true
+          Conditions:
+            - Field: http.route
+              Operator: starts-with
+              Value: /health
+        - Name: Default
+          SampleRate: 100
+`
+
+func newTestTranslator(t *testing.T) *translator.Translator {
+	components, err := data.LoadEmbeddedComponents()
+	require.NoError(t, err)
+	tr := translator.NewEmptyTranslator()
+	tr.InstallComponents(components)
+	return tr
+}
+
+// destination returns the name of the component connected to a port, or ""
+func destination(g *hpsf.Graph, component, port string) string {
+	conns := g.PortOutputs(component, port)
+	if len(conns) != 1 {
+		return ""
+	}
+	return conns[0].Destination.Component
+}
+
+func TestGenerateWorkflow_Rules(t *testing.T) {
+	w, err := NewGenerator().GenerateWorkflow([]byte(rulesTestYAML), "__default__")
+	require.NoError(t, err)
+	g := hpsf.NewGraph(w)
+
+	// each rule has its own output of Start Sampling, in order, and the
+	// rule's condition passes matching data on from its And port
+	tests := []struct {
+		port      string
+		condition string
+		kind      string
+		props     map[string]any
+		next      string
+	}{
+		{"Rule 1", "Condition_Errors_3", "FieldExistsCondition",
+			map[string]any{"Field": "error", "Operator": "exists"}, "Sample_Errors_4"},
+		{"Rule 2", "Condition_Slow_5", "CompareIntegerFieldCondition",
+			map[string]any{"Fields": []string{"duration_ms", "latency_ms"}, "Operator": ">=", "Value": 5000}, "Sample_Slow_6"},
+		{"Rule 3", "Condition_Queries_7", "FieldContainsCondition",
+			map[string]any{"Field": "db.statement", "Substring": "SELECT"}, "Sample_Queries_8"},
+		{"Rule 4", "Condition_Health_9", "FieldStartsWithCondition",
+			map[string]any{"Field": "http.route", "Prefix": "/health"}, "Drop_Health_10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.port, func(t *testing.T) {
+			assert.Equal(t, tt.condition, destination(g, "Start_Sampling_2", tt.port))
+			c := g.Component(tt.condition)
+			require.NotNil(t, c)
+			assert.Equal(t, tt.kind, c.Kind)
+			for name, value := range tt.props {
+				p := c.GetProperty(name)
+				require.NotNil(t, p, name)
+				assert.Equal(t, value, p.Value, name)
+			}
+			assert.Equal(t, tt.next, destination(g, tt.condition, "And"))
+		})
+	}
+
+	// the rule without conditions goes straight to its sampler
+	assert.Equal(t, "Sample_Default_11", destination(g, "Start_Sampling_2", "Rule 5"))
+
+	tr := newTestTranslator(t)
+	require.NoError(t, tr.ValidateConfig(w))
+}
+
+// The files in tests/refinery2hpsf are what `make test_refinery_generation`
+// compares against, so the generator has to keep producing them.
+func TestGenerateWorkflow_Examples(t *testing.T) {
+	dir := filepath.Join("..", "..", "tests", "refinery2hpsf")
+	tests := []struct {
+		name        string
+		environment string
+		valid       bool
+	}{
+		// these list several samplers for one environment, which each become
+		// a rule without conditions; only the last rule may have none (see
+		// Limitations in README.md)
+		{"01-simple", "__default__", false},
+		{"03-comprehensive", "__default__", false},
+
+		{"02-complex", "__default__", true},
+		{"04-specify-environment", "MyEnvironment", true},
+	}
+	tr := newTestTranslator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := os.ReadFile(filepath.Join(dir, tt.name+"-refinery.yaml"))
+			require.NoError(t, err)
+			expected, err := os.ReadFile(filepath.Join(dir, tt.name+"-workflow.yaml"))
+			require.NoError(t, err)
+
+			gen := NewGenerator()
+			w, err := gen.GenerateWorkflow(rules, tt.environment)
+			require.NoError(t, err)
+			assert.Empty(t, gen.Warnings())
+			out, err := w.AsYAML()
+			require.NoError(t, err)
+			assert.Equal(t, strings.TrimSpace(string(expected)), strings.TrimSpace(out))
+
+			err = tr.ValidateConfig(w)
+			if tt.valid {
+				require.NoError(t, err)
+				return
+			}
+			report := hpsf.NewReport(err)
+			require.NotEmpty(t, report.Problems)
+			for _, p := range report.Problems {
+				assert.Equal(t, hpsf.CODE_SAMPLING_PATHS, p.Code, p.Message)
+			}
+		})
+	}
+}
+
+func TestGenerateWorkflow_UnconvertibleSettings(t *testing.T) {
+	gen := NewGenerator()
+	w, err := gen.GenerateWorkflow([]byte(`RulesVersion: 2
+Samplers:
+  __default__:
+    DeterministicSampler:
+      SampleRate: 0
+    EMAThroughputSampler:
+      GoalThroughputPerSec: 2.5
+      FieldList: [service.name]
+`), "__default__")
+	require.NoError(t, err)
+
+	// the settings the components can't take are left out with a warning,
+	// and the rest of the workflow is still generated
+	require.Len(t, gen.Warnings(), 2)
+	assert.Contains(t, gen.Warnings()[0], "can't set property SampleRate to 0")
+	assert.Contains(t, gen.Warnings()[1], "can't set property GoalThroughputPerSec to 2.5")
+	assert.Contains(t, gen.Warnings()[1], "it was left out")
+
+	g := hpsf.NewGraph(w)
+	sampler := g.Component("Deterministic_Sampler_3")
+	require.NotNil(t, sampler)
+	assert.Nil(t, sampler.GetProperty("SampleRate"))
+	ema := g.Component("EMA_Throughput_Sampler_4")
+	require.NotNil(t, ema)
+	assert.Nil(t, ema.GetProperty("GoalThroughputPerSec"))
+	assert.Equal(t, []string{"service.name"}, ema.GetProperty("FieldList").Value)
+
+	// warnings are for the last workflow only
+	_, err = gen.GenerateWorkflow([]byte(rulesTestYAML), "__default__")
+	require.NoError(t, err)
+	assert.Empty(t, gen.Warnings())
+}
@@ -0,0 +1,254 @@
+// contains the logic for composing a workflow from several fragments.
+package hpsf
+
+import (
+	"fmt"
+
+	y "gopkg.in/yaml.v3"
+)
+
+// Compose combines several documents, or fragments, into one workflow: for
+// example, a logs pipeline and a traces sampling pipeline owned by different
+// teams, which have to be shipped as a single collector configuration. The
+// fragments aren't modified.
+//
+// The components and connections of the fragments are combined in order.
+// Receivers and exporters are shared: if one has the same kind and settings
+// as a receiver or exporter from an earlier fragment, the two become one
+// component, which keeps the earlier name and position. Other components
+// whose names collide with earlier ones are renamed with UniqueComponentName,
+// so the result only depends on the order of the fragments. Connections that
+// end up joining the same ports are only included once.
+//
+// Containers are combined the same way: a container that's defined the same
+// as one from an earlier fragment is shared, and one whose name is taken by a
+// different definition is renamed, along with the fragment's instances of it.
+//
+// Without templates, receivers and exporters are recognized by their style if
+// they have one, and otherwise by their connections within their fragment:
+// a receiver has no inputs, and an exporter has no outputs. Components that
+// receive sampling data are part of a sampling rule, so they're never shared.
+//
+// The laid out components of each fragment are moved below those of the
+// fragments before it, so that the pieces don't overlap. The kind, version,
+// name, summary, and description are taken from the first fragment; callers
+// will usually want to set their own.
+func Compose(fragments ...*HPSF) *HPSF {
+	composed := &HPSF{}
+	var layout *Layout
+	var shared []*Component
+	bottom, placed, first := 0, false, true
+	for _, fragment := range fragments {
+		if fragment == nil {
+			continue
+		}
+		f := fragment.Clone()
+		if first {
+			composed.Kind, composed.Version, composed.Name = f.Kind, f.Version, f.Name
+			composed.Summary, composed.Description = f.Summary, f.Description
+			composed.FormatVersion, composed.LibraryVersion = f.FormatVersion, f.LibraryVersion
+			first = false
+		}
+		composeContainers(composed, f)
+
+		// each shared component from earlier fragments can stand in for at
+		// most one component of this fragment
+		g := NewGraph(f)
+		var entries []LayoutComponent
+		names := make(map[string]string, len(f.Components))
+		used := make(map[*Component]bool)
+		var added, newShared []*Component
+		for _, c := range f.Components {
+			endpoint := isSharedEndpoint(g, c)
+			if endpoint {
+				if match := findShared(shared, used, c); match != nil {
+					used[match] = true
+					names[c.Name] = match.Name
+					continue
+				}
+			}
+			names[c.Name] = composed.UniqueComponentName(c.Name)
+			added = append(added, c)
+			composed.Components = append(composed.Components, c)
+			if endpoint {
+				newShared = append(newShared, c)
+			}
+			// the fragment's layout still uses the old name
+			if lc := f.layoutComponent(c.Name); lc != nil {
+				lc.Name = names[c.Name]
+				entries = append(entries, *lc)
+			}
+			c.Name = names[c.Name]
+		}
+		shared = append(shared, newShared...)
+
+		for _, conn := range f.Connections {
+			if name, ok := names[conn.Source.Component]; ok {
+				conn.Source.Component = name
+			}
+			if name, ok := names[conn.Destination.Component]; ok {
+				conn.Destination.Component = name
+			}
+			if !composed.hasConnection(conn) {
+				composed.Connections = append(composed.Connections, conn)
+			}
+		}
+
+		// move this fragment's part of the layout below what's already there
+		if len(entries) > 0 && layout == nil {
+			layout = &Layout{}
+		}
+		top, end, found := layoutExtent(entries)
+		if found {
+			dy := 0
+			if placed {
+				dy = bottom + DefaultNodeSize().Height - top
+			}
+			for _, lc := range entries {
+				if lc.Position != nil {
+					lc.Position.Y += dy
+				}
+			}
+			bottom, placed = end+dy, true
+		}
+		if layout != nil {
+			layout.Components = append(layout.Components, entries...)
+		}
+	}
+	composed.Layout = layout
+	return composed
+}
+
+// composeContainers adds the container definitions of the fragment f to the
+// composed document, renaming the ones whose names are taken by a different
+// definition and updating f's instances to match. Renaming a container can
+// make a container that uses it different too, so we keep going until no more
+// names change.
+func composeContainers(composed, f *HPSF) {
+	renames := make(map[string]string)
+	rename := func(kind string) string {
+		if name, ok := renames[kind]; ok {
+			return name
+		}
+		return kind
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, c := range f.Containers {
+			if _, ok := renames[c.Name]; ok {
+				continue
+			}
+			existing := composed.getContainer(c.Name)
+			if existing == nil {
+				continue
+			}
+			renamed := c
+			renamed.Components = make([]Component, len(c.Components))
+			for i, ic := range c.Components {
+				ic.Kind = rename(ic.Kind)
+				renamed.Components[i] = ic
+			}
+			if !sameContainer(existing, &renamed) {
+				renames[c.Name] = uniqueContainerName(c.Name, composed, f, renames)
+				changed = true
+			}
+		}
+	}
+
+	for _, c := range f.Components {
+		c.Kind = rename(c.Kind)
+	}
+	for _, c := range f.Containers {
+		c.Name = rename(c.Name)
+		for i := range c.Components {
+			c.Components[i].Kind = rename(c.Components[i].Kind)
+		}
+		// anything left with a name that's taken is the same as the earlier one
+		if composed.getContainer(c.Name) == nil {
+			composed.Containers = append(composed.Containers, c)
+		}
+	}
+}
+
+// sameContainer reports whether two containers have the same definition.
+// They're compared as YAML so that source locations don't count.
+func sameContainer(a, b *Container) bool {
+	ya, _ := y.Marshal(a)
+	yb, _ := y.Marshal(b)
+	return string(ya) == string(yb)
+}
+
+// uniqueContainerName returns a name like "name 2" that isn't used by a
+// container of the composed document or of the fragment, or by an earlier
+// rename.
+func uniqueContainerName(name string, composed, f *HPSF, renames map[string]string) string {
+	taken := make(map[string]bool)
+	for _, h := range []*HPSF{composed, f} {
+		for _, c := range h.Containers {
+			taken[c.Name] = true
+		}
+	}
+	for _, r := range renames {
+		taken[r] = true
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s %d", name, n)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// isSharedEndpoint reports whether a component is a receiver or an exporter
+// that Compose can share between fragments.
+func isSharedEndpoint(g *Graph, c *Component) bool {
+	if c.Style != "" {
+		return c.Style == "receiver" || c.Style == "exporter"
+	}
+	inputs := g.Inputs(c.Name)
+	if len(inputs) == 0 {
+		return true
+	}
+	if len(g.Outputs(c.Name)) > 0 {
+		return false
+	}
+	for _, conn := range inputs {
+		if conn.Source.Type == CTYPE_SAMPLE {
+			return false
+		}
+	}
+	return true
+}
+
+// findShared returns the first unused shared component with the same kind and
+// settings as c, or nil if there isn't one.
+func findShared(shared []*Component, used map[*Component]bool, c *Component) *Component {
+	for _, s := range shared {
+		if !used[s] && sameSettings(s, c) {
+			return s
+		}
+	}
+	return nil
+}
+
+// layoutExtent returns the top of the highest and the bottom of the lowest of
+// the positioned components, or false if none of them has a position.
+func layoutExtent(entries []LayoutComponent) (top, bottom int, ok bool) {
+	for _, lc := range entries {
+		if lc.Position == nil {
+			continue
+		}
+		height := DefaultNodeSize().Height
+		if lc.Size != nil {
+			height = lc.Size.H
+		}
+		if !ok || lc.Position.Y < top {
+			top = lc.Position.Y
+		}
+		if !ok || lc.Position.Y+height > bottom {
+			bottom = lc.Position.Y + height
+		}
+		ok = true
+	}
+	return top, bottom, ok
+}
@@ -0,0 +1,292 @@
+package hpsf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const composeLogsYAML = `
+kind: HPSF
+version: v1
+name: Logs
+components:
+  - name: Receiver
+    kind: OTelReceiver
+    properties:
+      - name: GRPCPort
+        value: 4317
+  - name: Filter
+    kind: FilterProcessor
+  - name: Honeycomb
+    kind: HoneycombExporter
+    properties:
+      - name: APIKey
+        value: ${HONEYCOMB_API_KEY}
+connections:
+  - source: {component: Receiver, port: Logs, type: OTelLogs}
+    destination: {component: Filter, port: Logs, type: OTelLogs}
+  - source: {component: Filter, port: Logs, type: OTelLogs}
+    destination: {component: Honeycomb, port: Logs, type: OTelLogs}
+layout:
+  components:
+    - name: Receiver
+      position: {x: 0, y: 100}
+    - name: Filter
+      position: {x: 200, y: 100}
+    - name: Honeycomb
+      position: {x: 400, y: 150}
+`
+
+const composeTracesYAML = `
+kind: HPSF
+version: v1
+name: Traces
+components:
+  - name: OTel Receiver
+    kind: OTelReceiver
+    properties:
+      - name: GRPCPort
+        value: 4317
+  - name: Filter
+    kind: FilterProcessor
+  - name: Start Sampling
+    kind: SamplingSequencer
+  - name: Sampler
+    kind: DeterministicSampler
+  - name: Honeycomb
+    kind: HoneycombExporter
+    properties:
+      - name: APIKey
+        value: ${HONEYCOMB_API_KEY}
+connections:
+  - source: {component: OTel Receiver, port: Traces, type: OTelTraces}
+    destination: {component: Filter, port: Traces, type: OTelTraces}
+  - source: {component: Filter, port: Traces, type: OTelTraces}
+    destination: {component: Start Sampling, port: Traces, type: OTelTraces}
+  - source: {component: Start Sampling, port: Rule 1, type: SampleData}
+    destination: {component: Sampler, port: Sample, type: SampleData}
+  - source: {component: Sampler, port: Events, type: HoneycombEvents}
+    destination: {component: Honeycomb, port: Events, type: HoneycombEvents}
+layout:
+  components:
+    - name: OTel Receiver
+      position: {x: 0, y: 20}
+    - name: Filter
+      position: {x: 200, y: 20}
+      size: {w: 100, h: 80}
+    - name: Start Sampling
+      position: {x: 400, y: 40}
+    - name: Sampler
+      position: {x: 600, y: 40}
+    - name: Honeycomb
+      position: {x: 800, y: 40}
+`
+
+func composeNames(h *HPSF) []string {
+	var names []string
+	for _, c := range h.Components {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func composeConnections(h *HPSF) []string {
+	var conns []string
+	for _, c := range h.Connections {
+		conns = append(conns, c.Source.Component+"."+c.Source.PortName+" -> "+c.Destination.Component+"."+c.Destination.PortName)
+	}
+	return conns
+}
+
+func TestCompose(t *testing.T) {
+	logs := parseDiffYAML(t, composeLogsYAML)
+	traces := parseDiffYAML(t, composeTracesYAML)
+	tracesYAML, err := traces.AsYAML()
+	require.NoError(t, err)
+
+	h := Compose(logs, traces)
+
+	// the receiver and exporter are shared; the second filter is renamed
+	assert.Equal(t, "Logs", h.Name)
+	assert.Equal(t, []string{"Receiver", "Filter", "Honeycomb", "Filter 2", "Start Sampling", "Sampler"}, composeNames(h))
+	assert.Equal(t, []string{
+		"Receiver.Logs -> Filter.Logs",
+		"Filter.Logs -> Honeycomb.Logs",
+		"Receiver.Traces -> Filter 2.Traces",
+		"Filter 2.Traces -> Start Sampling.Traces",
+		"Start Sampling.Rule 1 -> Sampler.Sample",
+		"Sampler.Events -> Honeycomb.Events",
+	}, composeConnections(h))
+	require.NoError(t, h.Validate())
+
+	// the traces fragment starts one node height below the bottom of the logs
+	// fragment, which is the Honeycomb exporter at 150+50
+	require.NotNil(t, h.Layout)
+	positions := make(map[string]Pos)
+	for _, lc := range h.Layout.Components {
+		positions[lc.Name] = *lc.Position
+	}
+	assert.Equal(t, map[string]Pos{
+		"Receiver":       {X: 0, Y: 100},
+		"Filter":         {X: 200, Y: 100},
+		"Honeycomb":      {X: 400, Y: 150},
+		"Filter 2":       {X: 200, Y: 250},
+		"Start Sampling": {X: 400, Y: 270},
+		"Sampler":        {X: 600, Y: 270},
+	}, positions)
+
+	// the fragments are unchanged
+	after, err := traces.AsYAML()
+	require.NoError(t, err)
+	assert.Equal(t, tracesYAML, after)
+	assert.Equal(t, "Filter", logs.Components[1].Name)
+}
+
+func TestCompose_DifferentSettingsAreNotShared(t *testing.T) {
+	logs := parseDiffYAML(t, composeLogsYAML)
+	traces := parseDiffYAML(t, composeTracesYAML)
+	traces.Components[0].Properties[0].Value = 9999
+	traces.Components[4].SetProperty(Property{Name: "Dataset", Value: "traces"})
+
+	h := Compose(logs, traces)
+	assert.Equal(t, []string{
+		"Receiver", "Filter", "Honeycomb",
+		"OTel Receiver", "Filter 2", "Start Sampling", "Sampler", "Honeycomb 2",
+	}, composeNames(h))
+	assert.Contains(t, composeConnections(h), "Sampler.Events -> Honeycomb 2.Events")
+}
+
+func TestCompose_Deterministic(t *testing.T) {
+	logs := parseDiffYAML(t, composeLogsYAML)
+	traces := parseDiffYAML(t, composeTracesYAML)
+
+	first, err := Compose(logs, traces, logs).AsYAML()
+	require.NoError(t, err)
+	for range 5 {
+		again, err := Compose(logs, traces, logs).AsYAML()
+		require.NoError(t, err)
+		assert.Equal(t, first, again)
+	}
+
+	// composing the logs fragment twice shares its receiver and exporter but
+	// not its filter, and the connections into the shared exporter are merged
+	h := Compose(logs, logs)
+	assert.Equal(t, []string{"Receiver", "Filter", "Honeycomb", "Filter 2"}, composeNames(h))
+	assert.Equal(t, []string{
+		"Receiver.Logs -> Filter.Logs",
+		"Filter.Logs -> Honeycomb.Logs",
+		"Receiver.Logs -> Filter 2.Logs",
+		"Filter 2.Logs -> Honeycomb.Logs",
+	}, composeConnections(h))
+}
+
+func TestCompose_SharedEndpoints(t *testing.T) {
+	fragment := func() *HPSF {
+		return parseDiffYAML(t, `
+components:
+  - {name: Receiver, kind: OTelReceiver}
+  - {name: Start Sampling, kind: SamplingSequencer}
+  - {name: Drop, kind: Dropper}
+  - {name: Debug, kind: DebugExporter}
+  - {name: Debug Too, kind: DebugExporter}
+connections:
+  - source: {component: Receiver, port: Traces, type: OTelTraces}
+    destination: {component: Start Sampling, port: Traces, type: OTelTraces}
+  - source: {component: Start Sampling, port: Rule 1, type: SampleData}
+    destination: {component: Drop, port: Sample, type: SampleData}
+  - source: {component: Receiver, port: Logs, type: OTelLogs}
+    destination: {component: Debug, port: Logs, type: OTelLogs}
+  - source: {component: Receiver, port: Metrics, type: OTelMetrics}
+    destination: {component: Debug Too, port: Metrics, type: OTelMetrics}
+`)
+	}
+
+	// droppers take sampling data, so they aren't shared; each exporter of
+	// the first fragment stands in for only one of the second's
+	h := Compose(fragment(), fragment())
+	assert.Equal(t, []string{"Receiver", "Start Sampling", "Drop", "Debug", "Debug Too", "Start Sampling 2", "Drop 2"},
+		composeNames(h))
+	assert.Nil(t, h.Layout)
+
+	// a style says what a component is, whatever its connections
+	a, b := fragment(), fragment()
+	a.Components[3].Style, b.Components[3].Style = "processor", "processor"
+	h = Compose(a, b)
+	assert.Equal(t, []string{"Receiver", "Start Sampling", "Drop", "Debug", "Debug Too", "Start Sampling 2", "Drop 2", "Debug 2"},
+		composeNames(h))
+	assert.Contains(t, composeConnections(h), "Receiver.Logs -> Debug 2.Logs")
+
+	assert.Empty(t, Compose().Components)
+	assert.Equal(t, composeNames(fragment()), composeNames(Compose(nil, fragment())))
+}
+
+func TestCompose_Containers(t *testing.T) {
+	fragment := func(filter string) *HPSF {
+		return parseDiffYAML(t, `
+components:
+  - {name: Receiver, kind: OTelReceiver}
+  - {name: Block, kind: Box}
+  - {name: Wrap, kind: Outer}
+  - {name: Send, kind: Sender}
+connections:
+  - source: {component: Receiver, port: Traces, type: OTelTraces}
+    destination: {component: Block, port: In, type: OTelTraces}
+  - source: {component: Receiver, port: Logs, type: OTelLogs}
+    destination: {component: Wrap, port: In, type: OTelLogs}
+  - source: {component: Receiver, port: Metrics, type: OTelMetrics}
+    destination: {component: Send, port: In, type: OTelMetrics}
+containers:
+  - name: Box
+    components:
+      - {name: X, kind: `+filter+`}
+    ports:
+      - {name: In, component: X, port: Traces}
+  - name: Outer
+    components:
+      - {name: I, kind: Box}
+    ports:
+      - {name: In, component: I, port: In}
+  - name: Sender
+    components:
+      - {name: E, kind: DebugExporter}
+    ports:
+      - {name: In, component: E, port: Metrics}
+`)
+	}
+
+	// Box is defined differently, so it's renamed, and so is Outer, which
+	// uses it; Sender is the same in both, so it's shared along with its
+	// instance
+	h := Compose(fragment("FilterProcessor"), fragment("RedactionProcessor"))
+	names := []string{}
+	for _, c := range h.Containers {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"Box", "Outer", "Sender", "Box 2", "Outer 2"}, names)
+	assert.Equal(t, []string{"Receiver", "Block", "Wrap", "Send", "Block 2", "Wrap 2"}, composeNames(h))
+	assert.Equal(t, "Box 2", h.Components[4].Kind)
+	assert.Equal(t, "Outer 2", h.Components[5].Kind)
+	assert.Equal(t, "Box 2", h.Containers[4].Components[0].Kind)
+	require.NoError(t, h.Validate())
+
+	flat, err := h.Flatten()
+	require.NoError(t, err)
+	kinds := make(map[string]string)
+	for _, c := range flat.Components {
+		kinds[c.Name] = c.Kind
+	}
+	assert.Equal(t, map[string]string{
+		"Receiver":   "OTelReceiver",
+		"Block/X":    "FilterProcessor",
+		"Wrap/I/X":   "FilterProcessor",
+		"Send/E":     "DebugExporter",
+		"Block 2/X":  "RedactionProcessor",
+		"Wrap 2/I/X": "RedactionProcessor",
+	}, kinds)
+
+	// composing a fragment with itself shares all of its containers
+	h = Compose(fragment("FilterProcessor"), fragment("FilterProcessor"))
+	assert.Len(t, h.Containers, 3)
+}
@@ -0,0 +1,191 @@
+// contains the logic for expanding containers into ordinary components.
+package hpsf
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/honeycombio/hpsf/pkg/validator"
+)
+
+// getContainer returns the container with the given name, or nil if not found
+func (h *HPSF) getContainer(name string) *Container {
+	for i := range h.Containers {
+		if h.Containers[i].Name == name {
+			return &h.Containers[i]
+		}
+	}
+	return nil
+}
+
+// containerInstanceName is the name given to a component inside an instance of a container.
+func containerInstanceName(instance, component string) string {
+	return instance + "/" + component
+}
+
+// flattener holds the state needed while expanding the container instances in a document.
+type flattener struct {
+	h          *HPSF
+	components []*Component
+	// connections are collected with their endpoints as written, and resolved once
+	// every instance has been expanded
+	connections []*Connection
+	// publicPorts maps an instance name to the internal endpoints of its public ports
+	publicPorts map[string]map[string]ConnectionPort
+	result      validator.Result
+}
+
+// Flatten returns a document in which every instance of a container has been
+// replaced by copies of the container's components and connections. The
+// copies are named "instance/component" so they can't collide with each
+// other; if one collides with another component in the document, that's an
+// error. Properties set on an instance are copied to every internal property
+// its public props name, and connections to an instance's public ports are
+// rewired to the internal ports they expose. Containers may contain
+// instances of other containers, but not of themselves.
+//
+// The original document is not modified. If it has no containers, it is
+// returned as-is.
+func (h *HPSF) Flatten() (*HPSF, error) {
+	if len(h.Containers) == 0 {
+		return h, nil
+	}
+
+	f := &flattener{
+		h:           h,
+		publicPorts: make(map[string]map[string]ConnectionPort),
+		result:      validator.NewResult("container expansion errors"),
+	}
+	f.expand(h.Components, h.Connections, nil)
+	f.checkNames()
+	if !f.result.IsEmpty() {
+		return nil, f.result
+	}
+
+	for _, conn := range f.connections {
+		f.resolve(&conn.Source)
+		f.resolve(&conn.Destination)
+	}
+	if !f.result.IsEmpty() {
+		return nil, f.result
+	}
+
+	flat := *h
+	flat.Components = f.components
+	flat.Connections = f.connections
+	flat.Containers = nil
+	return &flat, nil
+}
+
+// expand adds the given components and connections to the flattened
+// document, recursively replacing container instances with their contents.
+// chain is the list of containers currently being expanded.
+func (f *flattener) expand(components []*Component, connections []*Connection, chain []string) {
+	for _, conn := range connections {
+		c := *conn
+		f.connections = append(f.connections, &c)
+	}
+
+	for _, comp := range components {
+		container := f.h.getContainer(comp.Kind)
+		if container == nil {
+			f.components = append(f.components, comp)
+			continue
+		}
+		if slices.Contains(chain, container.Name) {
+			f.result.Add(NewErrorf("container %s contains itself (%s)",
+				container.Name, strings.Join(append(chain, container.Name), " -> ")).
+				WithCode(CODE_RECURSIVE_CONTAINER).WithComponent(comp.Name).WithLocation(comp.loc))
+			continue
+		}
+
+		// copy the internal components so each instance can have its own properties
+		inner := make([]*Component, 0, len(container.Components))
+		byName := make(map[string]*Component)
+		for _, ic := range container.Components {
+			c := ic
+			c.Name = containerInstanceName(comp.Name, ic.Name)
+			c.Properties = slices.Clone(ic.Properties)
+			inner = append(inner, &c)
+			byName[ic.Name] = &c
+		}
+
+		// fan the instance properties out to the internal properties they stand for
+		for _, prop := range comp.Properties {
+			found := false
+			for _, pp := range container.Props {
+				if pp.Name != prop.Name {
+					continue
+				}
+				found = true
+				target, ok := byName[pp.Component]
+				if !ok {
+					// this is reported by Container.Validate
+					continue
+				}
+				target.SetProperty(Property{Name: pp.Property, Value: prop.Value, Type: prop.Type, loc: prop.loc})
+			}
+			if !found {
+				f.result.Add(NewErrorf("container %s has no public property %s", container.Name, prop.Name).
+					WithCode(CODE_PROPERTY_NOT_FOUND).WithComponent(comp.Name).WithProperty(prop.Name).WithLocation(prop.loc))
+			}
+		}
+
+		// remember where the public ports lead
+		ports := make(map[string]ConnectionPort)
+		for _, pp := range container.Ports {
+			ports[pp.Name] = ConnectionPort{
+				Component: containerInstanceName(comp.Name, pp.Component),
+				PortName:  pp.Port,
+			}
+		}
+		f.publicPorts[comp.Name] = ports
+
+		innerConns := make([]*Connection, 0, len(container.Connections))
+		for _, ic := range container.Connections {
+			c := *ic
+			c.Source.Component = containerInstanceName(comp.Name, ic.Source.Component)
+			c.Destination.Component = containerInstanceName(comp.Name, ic.Destination.Component)
+			innerConns = append(innerConns, &c)
+		}
+
+		f.expand(inner, innerConns, append(slices.Clone(chain), container.Name))
+	}
+}
+
+// checkNames reports components whose names collide once the instances have
+// been expanded, such as a component that was named "instance/component" by
+// hand. Without this, one of the two would silently replace the other in the
+// generated configuration.
+func (f *flattener) checkNames() {
+	nameSet := make(map[string]struct{})
+	for _, c := range f.components {
+		if _, exists := nameSet[c.GetSafeName()]; exists {
+			f.result.Add(NewError("duplicate component name after expanding containers").
+				WithCode(CODE_DUPLICATE_NAME).WithComponent(c.Name).WithLocation(c.loc))
+		} else {
+			nameSet[c.GetSafeName()] = struct{}{}
+		}
+	}
+}
+
+// resolve rewrites a connection endpoint that refers to a public port of a
+// container instance so that it refers to the internal port instead. Public
+// ports can expose the ports of nested instances, so we keep going until we
+// reach an ordinary component.
+func (f *flattener) resolve(cp *ConnectionPort) {
+	for {
+		ports, ok := f.publicPorts[cp.Component]
+		if !ok {
+			return
+		}
+		target, ok := ports[cp.PortName]
+		if !ok {
+			f.result.Add(NewErrorf("container instance has no public port %s", cp.PortName).
+				WithCode(CODE_PORT_NOT_FOUND).WithComponent(cp.Component).WithLocation(cp.loc))
+			return
+		}
+		cp.Component = target.Component
+		cp.PortName = target.PortName
+	}
+}
@@ -0,0 +1,272 @@
+package hpsf
+
+import (
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const containerTestYAML = `components:
+  - name: Receiver
+    kind: OTelReceiver
+  - name: Block
+    kind: RedactAndSend
+    properties:
+      - name: Key
+        value: abc123
+connections:
+  - source:
+      component: Receiver
+      port: Traces
+      type: OTelTraces
+    destination:
+      component: Block
+      port: In
+      type: OTelTraces
+containers:
+  - name: RedactAndSend
+    components:
+      - name: Redact
+        kind: RedactionProcessor
+      - name: Send
+        kind: HoneycombExporter
+        properties:
+          - name: APIKey
+            value: default
+    connections:
+      - source:
+          component: Redact
+          port: Traces
+          type: OTelTraces
+        destination:
+          component: Send
+          port: Traces
+          type: OTelTraces
+    ports:
+      - name: In
+        component: Redact
+        port: Traces
+    props:
+      - name: Key
+        component: Send
+        property: APIKey
+      - name: Key
+        component: Redact
+        property: Secret
+`
+
+func TestHPSF_Flatten(t *testing.T) {
+	h, err := FromYAML(containerTestYAML)
+	require.NoError(t, err)
+	require.NoError(t, h.Validate())
+
+	flat, err := h.Flatten()
+	require.NoError(t, err)
+
+	assert.Empty(t, flat.Containers)
+	names := []string{}
+	for _, c := range flat.Components {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"Receiver", "Block/Redact", "Block/Send"}, names)
+
+	// the public prop fans out to both internal components
+	assert.Equal(t, "abc123", flat.Components[2].GetProperty("APIKey").Value)
+	assert.Equal(t, "abc123", flat.Components[1].GetProperty("Secret").Value)
+
+	require.Len(t, flat.Connections, 2)
+	assert.Equal(t, "Receiver", flat.Connections[0].Source.Component)
+	assert.Equal(t, "Block/Redact", flat.Connections[0].Destination.Component)
+	assert.Equal(t, "Traces", flat.Connections[0].Destination.PortName)
+	assert.Equal(t, "Block/Redact", flat.Connections[1].Source.Component)
+	assert.Equal(t, "Block/Send", flat.Connections[1].Destination.Component)
+
+	// the original is untouched
+	assert.Equal(t, "Block", h.Connections[0].Destination.Component)
+	assert.Equal(t, "default", h.Containers[0].Components[1].Properties[0].Value)
+	require.NoError(t, flat.Validate())
+}
+
+func TestHPSF_FlattenNested(t *testing.T) {
+	h, err := FromYAML(`components:
+  - name: Outer
+    kind: Wrapper
+    properties:
+      - name: Key
+        value: xyz
+  - name: Receiver
+    kind: OTelReceiver
+connections:
+  - source:
+      component: Receiver
+      port: Traces
+      type: OTelTraces
+    destination:
+      component: Outer
+      port: Input
+      type: OTelTraces
+containers:
+  - name: Sender
+    components:
+      - name: Send
+        kind: HoneycombExporter
+    ports:
+      - name: In
+        component: Send
+        port: Traces
+    props:
+      - name: Key
+        component: Send
+        property: APIKey
+  - name: Wrapper
+    components:
+      - name: Inner
+        kind: Sender
+    ports:
+      - name: Input
+        component: Inner
+        port: In
+    props:
+      - name: Key
+        component: Inner
+        property: Key
+`)
+	require.NoError(t, err)
+
+	flat, err := h.Flatten()
+	require.NoError(t, err)
+	require.Len(t, flat.Components, 2)
+	assert.Equal(t, "Outer/Inner/Send", flat.Components[0].Name)
+	assert.Equal(t, "xyz", flat.Components[0].GetProperty("APIKey").Value)
+	assert.Equal(t, "Outer/Inner/Send", flat.Connections[0].Destination.Component)
+	assert.Equal(t, "Traces", flat.Connections[0].Destination.PortName)
+}
+
+func TestHPSF_FlattenErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "unknown public port",
+			input: `components:
+  - name: A
+    kind: Box
+  - name: B
+    kind: Thing
+connections:
+  - source:
+      component: B
+      port: Out
+      type: OTelTraces
+    destination:
+      component: A
+      port: Nope
+      type: OTelTraces
+containers:
+  - name: Box
+    components:
+      - name: X
+        kind: Thing
+`,
+			wantErr: "container instance has no public port Nope",
+		},
+		{
+			name: "unknown public prop",
+			input: `components:
+  - name: A
+    kind: Box
+    properties:
+      - name: Nope
+        value: 1
+containers:
+  - name: Box
+    components:
+      - name: X
+        kind: Thing
+`,
+			wantErr: "container Box has no public property Nope",
+		},
+		{
+			name: "recursive container",
+			input: `components:
+  - name: A
+    kind: Box
+containers:
+  - name: Box
+    components:
+      - name: X
+        kind: Box
+`,
+			wantErr: "container Box contains itself (Box -> Box)",
+		},
+		{
+			name: "instance component collides with a top-level component",
+			input: `components:
+  - name: box
+    kind: Box
+  - name: box/a
+    kind: Thing
+containers:
+  - name: Box
+    components:
+      - name: a
+        kind: Thing
+`,
+			wantErr: "duplicate component name after expanding containers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := FromYAML(tt.input)
+			require.NoError(t, err)
+			_, err = h.Flatten()
+			require.Error(t, err)
+			assert.Contains(t, err.(validator.Result).Unwrap().Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestContainer_Validate(t *testing.T) {
+	c := Container{
+		Name:       "Box",
+		Components: []Component{{Name: "X", Kind: "Thing"}},
+		Connections: []*Connection{
+			{
+				Source:      ConnectionPort{Component: "X", PortName: "Out", Type: CTYPE_TRACES},
+				Destination: ConnectionPort{Component: "Y", PortName: "In", Type: CTYPE_TRACES},
+			},
+		},
+		Ports: []PublicPort{{Name: "P", Component: "Z", Port: "In"}},
+		Props: []PublicProp{{Name: "Q", Component: "X"}},
+	}
+	err := c.Validate()
+	require.Error(t, err)
+	msg := err.(validator.Result).Unwrap().Error()
+	assert.Contains(t, msg, "destination component not found in container Box")
+	assert.Contains(t, msg, "PublicPort P refers to a component not found")
+	assert.Contains(t, msg, "PublicProp Property must be set")
+}
+
+func TestHPSF_ValidateDuplicateContainers(t *testing.T) {
+	h, err := FromYAML(`components:
+  - name: A
+    kind: Box
+containers:
+  - name: Box
+    components:
+      - name: X
+        kind: Thing
+  - name: Box
+    components:
+      - name: Y
+        kind: Thing
+`)
+	require.NoError(t, err)
+	err = h.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.(validator.Result).Unwrap().Error(), "duplicate container name Box")
+}
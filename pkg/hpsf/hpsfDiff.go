@@ -0,0 +1,437 @@
+// contains the logic for comparing two HPSF documents.
+package hpsf
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// A Rename records a component that has a different name in the second
+// document but was recognized as the same component.
+type Rename struct {
+	From string
+	To   string
+}
+
+// A PropertyChange records a property whose value differs between the two
+// documents. Old is nil if the property was added, and New is nil if it was
+// removed. Component is the name in the second document. Type is the type
+// the documents declare for the property, if any; String uses it to redact
+// secrets, so callers that know the component's template can fill it in.
+type PropertyChange struct {
+	Component string
+	Property  string
+	Type      PropType
+	Old       any
+	New       any
+}
+
+// A LayoutChange records a component whose position or size differs between
+// the two documents. Component is the name in the second document.
+type LayoutChange struct {
+	Component string
+	Old       LayoutComponent
+	New       LayoutComponent
+}
+
+// A FieldChange records a document-level field, like format_version or
+// name, whose value differs between the two documents. Field is the name
+// used in the YAML form, and an empty value means the field isn't set.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// A DiffResult describes the differences between two HPSF documents, grouped
+// by the kind of change so that the ones that affect behavior can be reviewed
+// separately from those that only move things around.
+type DiffResult struct {
+	FieldsChanged      []FieldChange
+	ContainersRemoved  []Container
+	ContainersAdded    []Container
+	ContainersChanged  []string
+	ComponentsRemoved  []*Component
+	ComponentsAdded    []*Component
+	ComponentsRenamed  []Rename
+	PropertiesChanged  []PropertyChange
+	ConnectionsRemoved []*Connection
+	ConnectionsAdded   []*Connection
+	LayoutChanged      []LayoutChange
+}
+
+// IsEmpty returns true if the documents are equivalent.
+func (d *DiffResult) IsEmpty() bool {
+	return len(d.FieldsChanged) == 0 &&
+		len(d.ContainersRemoved) == 0 &&
+		len(d.ContainersAdded) == 0 &&
+		len(d.ContainersChanged) == 0 &&
+		len(d.ComponentsRemoved) == 0 &&
+		len(d.ComponentsAdded) == 0 &&
+		len(d.ComponentsRenamed) == 0 &&
+		len(d.PropertiesChanged) == 0 &&
+		len(d.ConnectionsRemoved) == 0 &&
+		len(d.ConnectionsAdded) == 0 &&
+		len(d.LayoutChanged) == 0
+}
+
+// Diff compares two HPSF documents and reports how b differs from a. The
+// comparison ignores the order of containers, components, properties, and
+// connections. Containers are matched by name and reported as changed if
+// anything in their definition differs.
+//
+// Components are matched by name first. A component that is missing from the
+// other document by name is still treated as the same component if there is
+// exactly one candidate of the same kind with the same ports and connections;
+// in that case it is reported as a rename rather than as a removal and an
+// addition, and its property changes are reported as usual.
+func Diff(a, b *HPSF) *DiffResult {
+	d := &DiffResult{}
+	d.FieldsChanged = diffFields(a, b)
+	d.ContainersRemoved, d.ContainersAdded, d.ContainersChanged = diffContainers(a, b)
+
+	bg := NewGraph(b)
+	aToB := matchComponents(NewGraph(a), bg)
+	matchedB := make(map[string]bool)
+	for _, bn := range aToB {
+		matchedB[bn] = true
+	}
+
+	for _, ac := range a.Components {
+		bn, ok := aToB[ac.Name]
+		if !ok {
+			d.ComponentsRemoved = append(d.ComponentsRemoved, ac)
+			continue
+		}
+		if bn != ac.Name {
+			d.ComponentsRenamed = append(d.ComponentsRenamed, Rename{From: ac.Name, To: bn})
+		}
+		d.PropertiesChanged = append(d.PropertiesChanged, diffProperties(ac, bg.Component(bn))...)
+	}
+	for _, bc := range b.Components {
+		if !matchedB[bc.Name] {
+			d.ComponentsAdded = append(d.ComponentsAdded, bc)
+		}
+	}
+
+	// compare connections in terms of the names used in b; connections to
+	// removed components keep a key that can't collide with b's names
+	aKey := func(conn *Connection) string {
+		name := func(n string) string {
+			if bn, ok := aToB[n]; ok {
+				return bn
+			}
+			return "\x00" + n
+		}
+		return connectionKey(name(conn.Source.Component), conn.Source.PortName,
+			name(conn.Destination.Component), conn.Destination.PortName, conn.Source.Type)
+	}
+	bKey := func(conn *Connection) string {
+		return connectionKey(conn.Source.Component, conn.Source.PortName,
+			conn.Destination.Component, conn.Destination.PortName, conn.Source.Type)
+	}
+	aConns := make(map[string]bool)
+	for _, conn := range a.Connections {
+		aConns[aKey(conn)] = true
+	}
+	bConns := make(map[string]bool)
+	for _, conn := range b.Connections {
+		bConns[bKey(conn)] = true
+		if !aConns[bKey(conn)] {
+			d.ConnectionsAdded = append(d.ConnectionsAdded, conn)
+		}
+	}
+	for _, conn := range a.Connections {
+		if !bConns[aKey(conn)] {
+			d.ConnectionsRemoved = append(d.ConnectionsRemoved, conn)
+		}
+	}
+
+	// layout changes are only interesting for components that exist in both
+	for _, ac := range a.Components {
+		bn, ok := aToB[ac.Name]
+		if !ok {
+			continue
+		}
+		al, bl := a.layoutComponent(ac.Name), b.layoutComponent(bn)
+		if al == nil || bl == nil {
+			continue
+		}
+		if !reflect.DeepEqual(al.Position, bl.Position) || !reflect.DeepEqual(al.Size, bl.Size) {
+			d.LayoutChanged = append(d.LayoutChanged, LayoutChange{Component: bn, Old: *al, New: *bl})
+		}
+	}
+	return d
+}
+
+// diffFields compares the document-level fields of two documents.
+func diffFields(a, b *HPSF) []FieldChange {
+	fields := []FieldChange{
+		{"kind", a.Kind, b.Kind},
+		{"version", a.Version, b.Version},
+		{"format_version", a.FormatVersion, b.FormatVersion},
+		{"name", a.Name, b.Name},
+		{"summary", a.Summary, b.Summary},
+		{"description", a.Description, b.Description},
+		{"library_version", a.LibraryVersion, b.LibraryVersion},
+	}
+	var changes []FieldChange
+	for _, f := range fields {
+		if f.Old != f.New {
+			changes = append(changes, f)
+		}
+	}
+	return changes
+}
+
+// diffContainers compares the container definitions of two documents,
+// returning the ones only in a, the ones only in b, and the names of the ones
+// that are defined differently.
+func diffContainers(a, b *HPSF) (removed, added []Container, changed []string) {
+	for _, ac := range a.Containers {
+		bc := b.getContainer(ac.Name)
+		switch {
+		case bc == nil:
+			removed = append(removed, ac)
+		case !sameContainer(&ac, bc):
+			changed = append(changed, ac.Name)
+		}
+	}
+	for _, bc := range b.Containers {
+		if a.getContainer(bc.Name) == nil {
+			added = append(added, bc)
+		}
+	}
+	return removed, added, changed
+}
+
+func connectionKey(srcComp, srcPort, dstComp, dstPort string, typ ConnectionType) string {
+	return fmt.Sprintf("%s\x01%s\x01%s\x01%s\x01%s", srcComp, srcPort, dstComp, dstPort, typ)
+}
+
+// layoutComponent returns the layout entry for the named component, or nil if it has none.
+func (h *HPSF) layoutComponent(name string) *LayoutComponent {
+	if h.Layout == nil {
+		return nil
+	}
+	for i := range h.Layout.Components {
+		if h.Layout.Components[i].Name == name {
+			return &h.Layout.Components[i]
+		}
+	}
+	return nil
+}
+
+// matchComponents returns a map from the names of components in a to the
+// names of the components in b that correspond to them.
+func matchComponents(a, b *Graph) map[string]string {
+	aToB := make(map[string]string)
+	bToA := make(map[string]string)
+	for _, ac := range a.Components() {
+		if bc := b.Component(ac.Name); bc != nil && bc.Kind == ac.Kind {
+			aToB[ac.Name] = bc.Name
+			bToA[bc.Name] = ac.Name
+		}
+	}
+
+	// Match the remaining components by the shape of their connections. Peers
+	// that haven't been matched yet are wildcards, so we repeat until nothing
+	// changes; each new match can make the signatures of its neighbors more
+	// specific.
+	for {
+		aSigs := make(map[string]string)
+		for _, ac := range a.Components() {
+			if _, ok := aToB[ac.Name]; !ok {
+				aSigs[ac.Name] = connectionSignature(a, ac, func(n string) string { return aToB[n] })
+			}
+		}
+		bSigs := make(map[string]string)
+		for _, bc := range b.Components() {
+			if _, ok := bToA[bc.Name]; !ok {
+				bSigs[bc.Name] = connectionSignature(b, bc, func(n string) string {
+					if _, ok := bToA[n]; ok {
+						return n
+					}
+					return ""
+				})
+			}
+		}
+
+		progress := false
+		for _, ac := range a.Components() {
+			sig, ok := aSigs[ac.Name]
+			if !ok || sig == "" {
+				continue
+			}
+			candidates := matchingCandidates(ac, sig, b, bSigs)
+			if len(candidates) != 1 {
+				continue
+			}
+			bc := candidates[0]
+			// the match has to be unambiguous in both directions
+			if len(matchingCandidates(bc, sig, a, aSigs)) != 1 {
+				continue
+			}
+			aToB[ac.Name] = bc.Name
+			bToA[bc.Name] = ac.Name
+			delete(aSigs, ac.Name)
+			delete(bSigs, bc.Name)
+			progress = true
+		}
+		if !progress {
+			return aToB
+		}
+	}
+}
+
+// matchingCandidates returns the unmatched components in g that have the same
+// kind, ports, and connection signature as c.
+func matchingCandidates(c *Component, sig string, g *Graph, sigs map[string]string) []*Component {
+	var candidates []*Component
+	for _, hc := range g.Components() {
+		hsig, ok := sigs[hc.Name]
+		if ok && hsig == sig && hc.Kind == c.Kind && slices.Equal(hc.Ports, c.Ports) {
+			candidates = append(candidates, hc)
+		}
+	}
+	return candidates
+}
+
+// connectionSignature describes the connections of a component in a way that
+// doesn't depend on its name. peer translates the name of the component at
+// the other end into a common namespace, or returns "" if it can't.
+func connectionSignature(g *Graph, c *Component, peer func(string) string) string {
+	var sig []string
+	for _, conn := range g.Outputs(c.Name) {
+		sig = append(sig, fmt.Sprintf("out:%s>%s.%s:%s", conn.Source.PortName,
+			peer(conn.Destination.Component), conn.Destination.PortName, conn.Source.Type))
+	}
+	for _, conn := range g.Inputs(c.Name) {
+		sig = append(sig, fmt.Sprintf("in:%s<%s.%s:%s", conn.Destination.PortName,
+			peer(conn.Source.Component), conn.Source.PortName, conn.Destination.Type))
+	}
+	slices.Sort(sig)
+	return strings.Join(sig, ",")
+}
+
+// diffProperties compares the properties of two components that are known to correspond.
+func diffProperties(a, b *Component) []PropertyChange {
+	var changes []PropertyChange
+	for _, ap := range a.Properties {
+		bp := b.GetProperty(ap.Name)
+		switch {
+		case bp == nil:
+			changes = append(changes, PropertyChange{Component: b.Name, Property: ap.Name, Type: ap.Type, Old: ap.Value})
+		case !reflect.DeepEqual(ap.Value, bp.Value):
+			changes = append(changes, PropertyChange{Component: b.Name, Property: ap.Name, Type: declaredType(&ap, bp),
+				Old: ap.Value, New: bp.Value})
+		}
+	}
+	for _, bp := range b.Properties {
+		if a.GetProperty(bp.Name) == nil {
+			changes = append(changes, PropertyChange{Component: b.Name, Property: bp.Name, Type: bp.Type, New: bp.Value})
+		}
+	}
+	return changes
+}
+
+// declaredType returns the first type declared by any of the properties, or
+// "" if none of them declares one. Any of them may be nil.
+func declaredType(props ...*Property) PropType {
+	for _, p := range props {
+		if p != nil && p.Type != "" {
+			return p.Type
+		}
+	}
+	return ""
+}
+
+// String formats the differences as a report with one section per kind of change.
+func (d *DiffResult) String() string {
+	if d.IsEmpty() {
+		return "no differences\n"
+	}
+	var sb strings.Builder
+	section := func(title string, n int) {
+		if n > 0 {
+			fmt.Fprintf(&sb, "%s:\n", title)
+		}
+	}
+	conn := func(c *Connection) string {
+		return fmt.Sprintf("%s.%s -> %s.%s (%s)", c.Source.Component, c.Source.PortName,
+			c.Destination.Component, c.Destination.PortName, c.Source.Type)
+	}
+	value := func(t PropType, v any) string {
+		if v == nil {
+			return "<unset>"
+		}
+		return fmt.Sprintf("%v", t.Redact(v))
+	}
+	layout := func(l LayoutComponent) string {
+		var parts []string
+		if l.Position != nil {
+			parts = append(parts, fmt.Sprintf("at (%d, %d)", l.Position.X, l.Position.Y))
+		}
+		if l.Size != nil {
+			parts = append(parts, fmt.Sprintf("size %dx%d", l.Size.W, l.Size.H))
+		}
+		if len(parts) == 0 {
+			return "unplaced"
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	field := func(v string) string {
+		if v == "" {
+			return "<unset>"
+		}
+		return v
+	}
+
+	section("document changed", len(d.FieldsChanged))
+	for _, f := range d.FieldsChanged {
+		fmt.Fprintf(&sb, "  ~ %s: %s -> %s\n", f.Field, field(f.Old), field(f.New))
+	}
+	section("containers removed", len(d.ContainersRemoved))
+	for _, c := range d.ContainersRemoved {
+		fmt.Fprintf(&sb, "  - %s\n", c.Name)
+	}
+	section("containers added", len(d.ContainersAdded))
+	for _, c := range d.ContainersAdded {
+		fmt.Fprintf(&sb, "  + %s\n", c.Name)
+	}
+	section("containers changed", len(d.ContainersChanged))
+	for _, name := range d.ContainersChanged {
+		fmt.Fprintf(&sb, "  ~ %s\n", name)
+	}
+	section("components removed", len(d.ComponentsRemoved))
+	for _, c := range d.ComponentsRemoved {
+		fmt.Fprintf(&sb, "  - %s (%s)\n", c.Name, c.Kind)
+	}
+	section("components added", len(d.ComponentsAdded))
+	for _, c := range d.ComponentsAdded {
+		fmt.Fprintf(&sb, "  + %s (%s)\n", c.Name, c.Kind)
+	}
+	section("components renamed", len(d.ComponentsRenamed))
+	for _, r := range d.ComponentsRenamed {
+		fmt.Fprintf(&sb, "  ~ %s -> %s\n", r.From, r.To)
+	}
+	section("properties changed", len(d.PropertiesChanged))
+	for _, p := range d.PropertiesChanged {
+		fmt.Fprintf(&sb, "  ~ %s.%s: %s -> %s\n", p.Component, p.Property, value(p.Type, p.Old), value(p.Type, p.New))
+	}
+	section("connections rewired", len(d.ConnectionsRemoved)+len(d.ConnectionsAdded))
+	for _, c := range d.ConnectionsRemoved {
+		fmt.Fprintf(&sb, "  - %s\n", conn(c))
+	}
+	for _, c := range d.ConnectionsAdded {
+		fmt.Fprintf(&sb, "  + %s\n", conn(c))
+	}
+	section("layout moved", len(d.LayoutChanged))
+	for _, l := range d.LayoutChanged {
+		fmt.Fprintf(&sb, "  ~ %s: %s -> %s\n", l.Component, layout(l.Old), layout(l.New))
+	}
+	return sb.String()
+}
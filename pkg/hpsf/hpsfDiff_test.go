@@ -0,0 +1,270 @@
+package hpsf
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const diffBaseYAML = `components:
+  - name: Receiver
+    kind: OTelReceiver
+    properties:
+      - name: GRPCPort
+        value: 4317
+  - name: Sampler
+    kind: DeterministicSampler
+    properties:
+      - name: SampleRate
+        value: 10
+  - name: Exporter
+    kind: HoneycombExporter
+connections:
+  - source:
+      component: Receiver
+      port: Traces
+      type: OTelTraces
+    destination:
+      component: Sampler
+      port: Traces
+      type: OTelTraces
+  - source:
+      component: Sampler
+      port: Events
+      type: HoneycombEvents
+    destination:
+      component: Exporter
+      port: Events
+      type: HoneycombEvents
+layout:
+  components:
+    - name: Receiver
+      position:
+        x: 0
+        y: 0
+    - name: Sampler
+      position:
+        x: 100
+        y: 0
+    - name: Exporter
+      position:
+        x: 200
+        y: 0
+`
+
+func parseDiffYAML(t *testing.T, s string) *HPSF {
+	h, err := FromYAML(s)
+	require.NoError(t, err)
+	return &h
+}
+
+func TestDiff_Identical(t *testing.T) {
+	a := parseDiffYAML(t, diffBaseYAML)
+	b := parseDiffYAML(t, diffBaseYAML)
+	// reordering doesn't count as a change
+	b.Components[0], b.Components[2] = b.Components[2], b.Components[0]
+	b.Connections[0], b.Connections[1] = b.Connections[1], b.Connections[0]
+
+	d := Diff(a, b)
+	assert.True(t, d.IsEmpty())
+	assert.Equal(t, "no differences\n", d.String())
+}
+
+func TestDiff_Categories(t *testing.T) {
+	a := parseDiffYAML(t, diffBaseYAML)
+	b := parseDiffYAML(t, `components:
+  - name: Receiver
+    kind: OTelReceiver
+    properties:
+      - name: GRPCPort
+        value: 4318
+      - name: HTTPPort
+        value: 4319
+  - name: Sampler
+    kind: DeterministicSampler
+  - name: Debug
+    kind: DebugExporter
+connections:
+  - source:
+      component: Receiver
+      port: Traces
+      type: OTelTraces
+    destination:
+      component: Sampler
+      port: Traces
+      type: OTelTraces
+  - source:
+      component: Receiver
+      port: Logs
+      type: OTelLogs
+    destination:
+      component: Debug
+      port: Logs
+      type: OTelLogs
+layout:
+  components:
+    - name: Receiver
+      position:
+        x: 0
+        y: 50
+    - name: Sampler
+      position:
+        x: 100
+        y: 0
+`)
+
+	d := Diff(a, b)
+	require.Len(t, d.ComponentsRemoved, 1)
+	assert.Equal(t, "Exporter", d.ComponentsRemoved[0].Name)
+	require.Len(t, d.ComponentsAdded, 1)
+	assert.Equal(t, "Debug", d.ComponentsAdded[0].Name)
+	assert.Empty(t, d.ComponentsRenamed)
+	assert.Equal(t, []PropertyChange{
+		{Component: "Receiver", Property: "GRPCPort", Old: 4317, New: 4318},
+		{Component: "Receiver", Property: "HTTPPort", New: 4319},
+		{Component: "Sampler", Property: "SampleRate", Old: 10},
+	}, d.PropertiesChanged)
+	require.Len(t, d.ConnectionsRemoved, 1)
+	assert.Equal(t, "Exporter", d.ConnectionsRemoved[0].Destination.Component)
+	require.Len(t, d.ConnectionsAdded, 1)
+	assert.Equal(t, "Debug", d.ConnectionsAdded[0].Destination.Component)
+	require.Len(t, d.LayoutChanged, 1)
+	assert.Equal(t, "Receiver", d.LayoutChanged[0].Component)
+
+	assert.Equal(t, `components removed:
+  - Exporter (HoneycombExporter)
+components added:
+  + Debug (DebugExporter)
+properties changed:
+  ~ Receiver.GRPCPort: 4317 -> 4318
+  ~ Receiver.HTTPPort: <unset> -> 4319
+  ~ Sampler.SampleRate: 10 -> <unset>
+connections rewired:
+  - Sampler.Events -> Exporter.Events (HoneycombEvents)
+  + Receiver.Logs -> Debug.Logs (OTelLogs)
+layout moved:
+  ~ Receiver: at (0, 0) -> at (0, 50)
+`, d.String())
+}
+
+func TestDiff_DocumentFields(t *testing.T) {
+	a := parseDiffYAML(t, "kind: HPSF\nversion: v1\nname: Old Name\n"+diffBaseYAML)
+	b := parseDiffYAML(t, "kind: HPSF\nversion: v2\nformat_version: v1\nname: New Name\n"+diffBaseYAML)
+
+	d := Diff(a, b)
+	assert.Equal(t, []FieldChange{
+		{Field: "version", Old: "v1", New: "v2"},
+		{Field: "format_version", Old: "", New: "v1"},
+		{Field: "name", Old: "Old Name", New: "New Name"},
+	}, d.FieldsChanged)
+	assert.Equal(t, `document changed:
+  ~ version: v1 -> v2
+  ~ format_version: <unset> -> v1
+  ~ name: Old Name -> New Name
+`, d.String())
+}
+
+func TestDiff_Containers(t *testing.T) {
+	container := func(name, kind string) string {
+		return fmt.Sprintf(`  - name: %s
+    components:
+      - name: P
+        kind: %s
+    ports:
+      - name: In
+        component: P
+        port: In
+`, name, kind)
+	}
+	a := parseDiffYAML(t, diffBaseYAML+"containers:\n"+container("Kept", "Thing")+container("Edited", "Thing")+container("Dropped", "Thing"))
+	b := parseDiffYAML(t, diffBaseYAML+"containers:\n"+container("Edited", "Other")+container("New", "Thing")+container("Kept", "Thing"))
+
+	d := Diff(a, b)
+	require.Len(t, d.ContainersRemoved, 1)
+	assert.Equal(t, "Dropped", d.ContainersRemoved[0].Name)
+	require.Len(t, d.ContainersAdded, 1)
+	assert.Equal(t, "New", d.ContainersAdded[0].Name)
+	assert.Equal(t, []string{"Edited"}, d.ContainersChanged)
+	assert.Equal(t, `containers removed:
+  - Dropped
+containers added:
+  + New
+containers changed:
+  ~ Edited
+`, d.String())
+}
+
+func TestDiff_FollowsRenames(t *testing.T) {
+	a := parseDiffYAML(t, diffBaseYAML)
+	b := parseDiffYAML(t, diffBaseYAML)
+	// rename two adjacent components; the second can only be matched once the
+	// first one has been
+	b.Components[1].Name = "Sampler 1"
+	b.Components[2].Name = "Honeycomb"
+	b.Components[1].Properties[0].Value = 20
+	b.Connections[0].Destination.Component = "Sampler 1"
+	b.Connections[1].Source.Component = "Sampler 1"
+	b.Connections[1].Destination.Component = "Honeycomb"
+	b.Layout.Components[1].Name = "Sampler 1"
+	b.Layout.Components[2].Name = "Honeycomb"
+	b.Layout.Components[2].Position.Y = 10
+
+	d := Diff(a, b)
+	assert.Empty(t, d.ComponentsAdded)
+	assert.Empty(t, d.ComponentsRemoved)
+	assert.Empty(t, d.ConnectionsAdded)
+	assert.Empty(t, d.ConnectionsRemoved)
+	assert.Equal(t, []Rename{{From: "Sampler", To: "Sampler 1"}, {From: "Exporter", To: "Honeycomb"}}, d.ComponentsRenamed)
+	assert.Equal(t, []PropertyChange{{Component: "Sampler 1", Property: "SampleRate", Old: 10, New: 20}}, d.PropertiesChanged)
+	require.Len(t, d.LayoutChanged, 1)
+	assert.Equal(t, "Honeycomb", d.LayoutChanged[0].Component)
+}
+
+func TestDiff_AmbiguousRenamesAreNotFollowed(t *testing.T) {
+	a := parseDiffYAML(t, `components:
+  - name: R
+    kind: OTelReceiver
+  - name: A
+    kind: DebugExporter
+  - name: B
+    kind: DebugExporter
+connections:
+  - source: {component: R, port: Traces, type: OTelTraces}
+    destination: {component: A, port: Traces, type: OTelTraces}
+  - source: {component: R, port: Traces, type: OTelTraces}
+    destination: {component: B, port: Traces, type: OTelTraces}
+`)
+	b := parseDiffYAML(t, `components:
+  - name: R
+    kind: OTelReceiver
+  - name: C
+    kind: DebugExporter
+  - name: D
+    kind: DebugExporter
+connections:
+  - source: {component: R, port: Traces, type: OTelTraces}
+    destination: {component: C, port: Traces, type: OTelTraces}
+  - source: {component: R, port: Traces, type: OTelTraces}
+    destination: {component: D, port: Traces, type: OTelTraces}
+`)
+
+	d := Diff(a, b)
+	assert.Empty(t, d.ComponentsRenamed)
+	assert.Len(t, d.ComponentsRemoved, 2)
+	assert.Len(t, d.ComponentsAdded, 2)
+}
+
+func TestDiff_RedactsSecrets(t *testing.T) {
+	a := parseDiffYAML(t, diffBaseYAML)
+	b := parseDiffYAML(t, diffBaseYAML)
+	a.Components[2].SetProperty(Property{Name: "APIKey", Value: "abc123", Type: PTYPE_SECRET})
+	b.Components[2].SetProperty(Property{Name: "APIKey", Value: "${HONEYCOMB_API_KEY}", Type: PTYPE_SECRET})
+
+	d := Diff(a, b)
+	require.Len(t, d.PropertiesChanged, 1)
+	assert.Equal(t, PTYPE_SECRET, d.PropertiesChanged[0].Type)
+	assert.Contains(t, d.String(), "Exporter.APIKey: <redacted> -> ${HONEYCOMB_API_KEY}")
+	assert.NotContains(t, d.String(), "abc123")
+}
@@ -0,0 +1,62 @@
+package hpsf
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration converts the value of a duration property to a time.Duration.
+// Strings use Go's duration syntax, like "1m30s", which is also what the
+// collector and Refinery accept. Numbers, and strings that are just a number,
+// are counted in the given unit. Values that marshal themselves as text, like
+// the durations in Refinery's configuration structs, are parsed from that text.
+func ParseDuration(a any, unit time.Duration) (time.Duration, error) {
+	switch v := a.(type) {
+	case time.Duration:
+		return v, nil
+	case int:
+		return time.Duration(v) * unit, nil
+	case float64:
+		return durationOf(v, unit)
+	case string:
+		s := strings.TrimSpace(v)
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return durationOf(f, unit)
+		}
+		return time.ParseDuration(s)
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return 0, err
+		}
+		return time.ParseDuration(string(text))
+	default:
+		return 0, fmt.Errorf("expected duration, got %v", a)
+	}
+}
+
+func durationOf(f float64, unit time.Duration) (time.Duration, error) {
+	d := f * float64(unit)
+	if math.IsNaN(d) || math.Abs(d) > math.MaxInt64 {
+		return 0, fmt.Errorf("duration %v is out of range", f)
+	}
+	return time.Duration(math.Round(d)), nil
+}
+
+// FormatDuration writes a duration in the canonical form used for duration
+// properties and in generated configurations: Go's duration syntax without
+// trailing zero units, so one minute is "1m" rather than "1m0s" or "60s".
+func FormatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
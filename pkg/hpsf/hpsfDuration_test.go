@@ -0,0 +1,64 @@
+package hpsf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textDuration marshals itself as text, like the durations in Refinery's config
+type textDuration time.Duration
+
+func (d textDuration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value any
+		unit  time.Duration
+		want  time.Duration
+	}{
+		{"1m30s", time.Second, 90 * time.Second},
+		{" 200ms ", time.Second, 200 * time.Millisecond},
+		{10, time.Second, 10 * time.Second},
+		{10, time.Millisecond, 10 * time.Millisecond},
+		{2.5, time.Second, 2500 * time.Millisecond},
+		{"250", time.Millisecond, 250 * time.Millisecond},
+		{5 * time.Minute, time.Second, 5 * time.Minute},
+		{textDuration(15 * time.Second), time.Second, 15 * time.Second},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value, tt.unit)
+		require.NoError(t, err, "parsing %v", tt.value)
+		assert.Equal(t, tt.want, got, "parsing %v", tt.value)
+	}
+
+	for _, bad := range []any{"soon", "", true, []string{"1s"}, 1e300} {
+		_, err := ParseDuration(bad, time.Second)
+		assert.Error(t, err, "parsing %v", bad)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                                   "0s",
+		200 * time.Millisecond:              "200ms",
+		1500 * time.Millisecond:             "1.5s",
+		time.Minute:                         "1m",
+		90 * time.Second:                    "1m30s",
+		time.Hour:                           "1h",
+		time.Hour + 30*time.Minute:          "1h30m",
+		time.Hour + time.Second:             "1h0m1s",
+		-time.Minute:                        "-1m",
+		36*time.Hour + 250*time.Millisecond: "36h0m0.25s",
+	}
+	for d, want := range tests {
+		assert.Equal(t, want, FormatDuration(d))
+		parsed, err := time.ParseDuration(want)
+		require.NoError(t, err)
+		assert.Equal(t, d, parsed)
+	}
+}
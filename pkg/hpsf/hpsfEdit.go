@@ -0,0 +1,145 @@
+package hpsf
+
+import (
+	"fmt"
+	"slices"
+)
+
+// RenameComponent renames a component, along with the connections and layout
+// entries that refer to it. It returns an error if there is no component
+// called oldName, or if newName is empty or has the same safe name as
+// another component (see GetSafeName), since generated configurations would
+// then have two components with the same name.
+func (h *HPSF) RenameComponent(oldName, newName string) error {
+	c := NewGraph(h).Component(oldName)
+	if c == nil {
+		return fmt.Errorf("there is no component called %s", oldName)
+	}
+	if newName == "" {
+		return fmt.Errorf("component %s can't be renamed to an empty name", oldName)
+	}
+	for _, other := range h.Components {
+		if other != c && other.GetSafeName() == safeName(newName) {
+			return fmt.Errorf("component %s can't be renamed to %s because it would collide with component %s",
+				oldName, newName, other.Name)
+		}
+	}
+
+	c.Name = newName
+	for _, conn := range h.Connections {
+		if conn.Source.Component == oldName {
+			conn.Source.Component = newName
+		}
+		if conn.Destination.Component == oldName {
+			conn.Destination.Component = newName
+		}
+	}
+	if h.Layout != nil {
+		for i := range h.Layout.Components {
+			if h.Layout.Components[i].Name == oldName {
+				h.Layout.Components[i].Name = newName
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveComponent removes a component, along with its connections and its
+// layout entry. If reconnect is true, the gap is closed: each component that
+// sent data to it is connected to each component it sent the same type of
+// data to, so removing a processor from the middle of a pipeline leaves the
+// pipeline intact. It returns an error if there is no component called name.
+func (h *HPSF) RemoveComponent(name string, reconnect bool) error {
+	g := NewGraph(h)
+	c := g.Component(name)
+	if c == nil {
+		return fmt.Errorf("there is no component called %s", name)
+	}
+
+	var bridges []*Connection
+	if reconnect {
+		for _, in := range g.Inputs(name) {
+			for _, out := range g.Outputs(name) {
+				if in.Source.Type != out.Source.Type || in.Source.Component == name || out.Destination.Component == name {
+					continue
+				}
+				bridge := &Connection{Source: in.Source, Destination: out.Destination}
+				bridge.Source.loc, bridge.Destination.loc = Location{}, Location{}
+				if !h.hasConnection(bridge) && !slices.ContainsFunc(bridges, bridge.sameEnds) {
+					bridges = append(bridges, bridge)
+				}
+			}
+		}
+	}
+
+	h.Components = slices.DeleteFunc(h.Components, func(other *Component) bool { return other == c })
+	h.Connections = slices.DeleteFunc(h.Connections, func(conn *Connection) bool {
+		return conn.Source.Component == name || conn.Destination.Component == name
+	})
+	h.Connections = append(h.Connections, bridges...)
+	if h.Layout != nil {
+		h.Layout.Components = slices.DeleteFunc(h.Layout.Components, func(lc LayoutComponent) bool {
+			return lc.Name == name
+		})
+	}
+	return nil
+}
+
+// InsertBetween splices a component into a connection, which must be one of
+// the document's connections. The connection is replaced by one from its
+// source to the component's input port, and another from the component's
+// output port to its destination; both carry the connection's type of data.
+//
+// The component is added to the document, and must not already be in it. If
+// its name is taken, it's given a unique one with UniqueComponentName. If
+// both ends of the connection have a position in the layout, the component is
+// placed halfway between them.
+func (h *HPSF) InsertBetween(conn *Connection, c *Component, input, output string) error {
+	i := slices.Index(h.Connections, conn)
+	if i < 0 {
+		return fmt.Errorf("the connection from %s to %s is not part of the document",
+			conn.Source.Component, conn.Destination.Component)
+	}
+	if slices.Contains(h.Components, c) {
+		return fmt.Errorf("component %s is already part of the document", c.Name)
+	}
+	if input == "" || output == "" {
+		return fmt.Errorf("component %s needs an input and an output port to be inserted", c.Name)
+	}
+
+	c.Name = h.UniqueComponentName(c.Name)
+	h.Components = append(h.Components, c)
+
+	ctype := conn.Source.Type
+	in := &Connection{
+		Source:      conn.Source,
+		Destination: ConnectionPort{Component: c.Name, PortName: input, Type: ctype},
+	}
+	out := &Connection{
+		Source:      ConnectionPort{Component: c.Name, PortName: output, Type: ctype},
+		Destination: conn.Destination,
+	}
+	h.Connections = slices.Replace(h.Connections, i, i+1, in, out)
+
+	srcX, srcY, srcOK := h.GetComponentPosition(conn.Source.Component)
+	dstX, dstY, dstOK := h.GetComponentPosition(conn.Destination.Component)
+	if srcOK && dstOK {
+		h.Layout.Components = append(h.Layout.Components, LayoutComponent{
+			Name:     c.Name,
+			Position: &Pos{X: (srcX + dstX) / 2, Y: (srcY + dstY) / 2},
+		})
+	}
+	return nil
+}
+
+// hasConnection reports whether the document already has a connection
+// between the same ports as conn.
+func (h *HPSF) hasConnection(conn *Connection) bool {
+	return slices.ContainsFunc(h.Connections, conn.sameEnds)
+}
+
+// sameEnds reports whether two connections join the same ports.
+func (c *Connection) sameEnds(other *Connection) bool {
+	return c.Source.Component == other.Source.Component && c.Source.PortName == other.Source.PortName &&
+		c.Destination.Component == other.Destination.Component && c.Destination.PortName == other.Destination.PortName
+}
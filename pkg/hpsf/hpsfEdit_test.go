@@ -0,0 +1,140 @@
+package hpsf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const editTestDocument = `
+components:
+  - name: otlp
+    kind: OTelReceiver
+  - name: redact
+    kind: RedactionProcessor
+  - name: honeycomb
+    kind: HoneycombExporter
+connections:
+  - source: {component: otlp, port: Traces, type: OTelTraces}
+    destination: {component: redact, port: Traces, type: OTelTraces}
+  - source: {component: otlp, port: Logs, type: OTelLogs}
+    destination: {component: redact, port: Logs, type: OTelLogs}
+  - source: {component: redact, port: Traces, type: OTelTraces}
+    destination: {component: honeycomb, port: Traces, type: OTelTraces}
+  - source: {component: redact, port: Logs, type: OTelLogs}
+    destination: {component: honeycomb, port: Logs, type: OTelLogs}
+layout:
+  components:
+    - name: otlp
+      position: {x: 0, y: 0}
+    - name: redact
+      position: {x: 100, y: 20}
+    - name: honeycomb
+      position: {x: 200, y: 40}
+`
+
+func editTestHPSF(t *testing.T) *HPSF {
+	h, err := FromYAML(editTestDocument)
+	require.NoError(t, err)
+	return &h
+}
+
+// connectionStrings describes the connections of a document, in order.
+func connectionStrings(h *HPSF) []string {
+	var conns []string
+	for _, c := range h.Connections {
+		conns = append(conns, c.Source.Component+"."+c.Source.PortName+" -> "+c.Destination.Component+"."+c.Destination.PortName)
+	}
+	return conns
+}
+
+func TestHPSF_RenameComponent(t *testing.T) {
+	h := editTestHPSF(t)
+	require.NoError(t, h.RenameComponent("redact", "Redact PII"))
+	assert.Equal(t, "Redact PII", h.Components[1].Name)
+	assert.Equal(t, []string{
+		"otlp.Traces -> Redact PII.Traces",
+		"otlp.Logs -> Redact PII.Logs",
+		"Redact PII.Traces -> honeycomb.Traces",
+		"Redact PII.Logs -> honeycomb.Logs",
+	}, connectionStrings(h))
+	x, y, ok := h.GetComponentPosition("Redact PII")
+	require.True(t, ok)
+	assert.Equal(t, []int{100, 20}, []int{x, y})
+	require.NoError(t, h.Validate())
+
+	// a name can change as long as its safe name doesn't collide with another component's
+	require.NoError(t, h.RenameComponent("Redact PII", "Redact_PII"))
+
+	assert.EqualError(t, h.RenameComponent("missing", "x"), "there is no component called missing")
+	assert.EqualError(t, h.RenameComponent("otlp", ""), "component otlp can't be renamed to an empty name")
+	assert.EqualError(t, h.RenameComponent("otlp", "Redact-PII"), "component otlp can't be renamed to Redact-PII because it would collide with component Redact_PII")
+	assert.EqualError(t, h.RenameComponent("otlp", "Redact PII"), "component otlp can't be renamed to Redact PII because it would collide with component Redact_PII")
+	assert.Equal(t, "otlp", h.Components[0].Name)
+}
+
+func TestHPSF_RemoveComponent(t *testing.T) {
+	h := editTestHPSF(t)
+	require.NoError(t, h.RemoveComponent("redact", false))
+	assert.Len(t, h.Components, 2)
+	assert.Empty(t, h.Connections)
+	_, _, ok := h.GetComponentPosition("redact")
+	assert.False(t, ok)
+	assert.Len(t, h.Layout.Components, 2)
+
+	// reconnecting joins up the inputs and outputs that carry the same type of data
+	h = editTestHPSF(t)
+	require.NoError(t, h.RemoveComponent("redact", true))
+	assert.Equal(t, []string{
+		"otlp.Traces -> honeycomb.Traces",
+		"otlp.Logs -> honeycomb.Logs",
+	}, connectionStrings(h))
+	assert.Equal(t, CTYPE_LOGS, h.Connections[1].Destination.Type)
+	require.NoError(t, h.Validate())
+
+	// ends with nothing on the other side are just removed
+	require.NoError(t, h.RemoveComponent("honeycomb", true))
+	assert.Empty(t, h.Connections)
+
+	assert.EqualError(t, h.RemoveComponent("redact", true), "there is no component called redact")
+}
+
+func TestHPSF_RemoveComponentDoesNotDuplicateConnections(t *testing.T) {
+	h := editTestHPSF(t)
+	h.Connections = append(h.Connections, &Connection{
+		Source:      ConnectionPort{Component: "otlp", PortName: "Traces", Type: CTYPE_TRACES},
+		Destination: ConnectionPort{Component: "honeycomb", PortName: "Traces", Type: CTYPE_TRACES},
+	})
+	require.NoError(t, h.RemoveComponent("redact", true))
+	assert.Equal(t, []string{
+		"otlp.Traces -> honeycomb.Traces",
+		"otlp.Logs -> honeycomb.Logs",
+	}, connectionStrings(h))
+}
+
+func TestHPSF_InsertBetween(t *testing.T) {
+	h := editTestHPSF(t)
+	require.NoError(t, h.RemoveComponent("redact", true))
+
+	filter := &Component{Name: "otlp", Kind: "CustomTraceFilterProcessor"}
+	require.NoError(t, h.InsertBetween(h.Connections[0], filter, "Traces", "Traces"))
+	assert.Equal(t, "otlp 2", filter.Name, "the name is made unique")
+	assert.Same(t, filter, h.Components[2])
+	assert.Equal(t, []string{
+		"otlp.Traces -> otlp 2.Traces",
+		"otlp 2.Traces -> honeycomb.Traces",
+		"otlp.Logs -> honeycomb.Logs",
+	}, connectionStrings(h))
+	assert.Equal(t, CTYPE_TRACES, h.Connections[0].Destination.Type)
+	assert.Equal(t, CTYPE_TRACES, h.Connections[1].Source.Type)
+	x, y, ok := h.GetComponentPosition("otlp 2")
+	require.True(t, ok)
+	assert.Equal(t, []int{100, 20}, []int{x, y})
+	require.NoError(t, h.Validate())
+
+	stale := &Connection{Source: ConnectionPort{Component: "a"}, Destination: ConnectionPort{Component: "b"}}
+	assert.EqualError(t, h.InsertBetween(stale, &Component{Name: "x"}, "In", "Out"), "the connection from a to b is not part of the document")
+	assert.EqualError(t, h.InsertBetween(h.Connections[2], filter, "Traces", "Traces"), "component otlp 2 is already part of the document")
+	assert.EqualError(t, h.InsertBetween(h.Connections[2], &Component{Name: "x"}, "Logs", ""), "component x needs an input and an output port to be inserted")
+}
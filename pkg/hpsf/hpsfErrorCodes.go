@@ -0,0 +1,112 @@
+package hpsf
+
+// An ErrorCode identifies the kind of problem an HPSFError describes. Codes
+// are stable: once published, a code keeps its meaning, so tools can key off
+// them instead of matching the text of the reason, which may change. Codes
+// are never reused; if a check is removed, its code is retired.
+type ErrorCode string
+
+const (
+	// CODE_UNKNOWN is reported for errors that don't carry a code of their own.
+	CODE_UNKNOWN ErrorCode = "HPSF-E000"
+
+	// problems with the structure of an HPSF document
+	CODE_NAME_REQUIRED          ErrorCode = "HPSF-E001"
+	CODE_KIND_REQUIRED          ErrorCode = "HPSF-E002"
+	CODE_INVALID_PORT_DIRECTION ErrorCode = "HPSF-E003"
+	CODE_PROPERTY_NAME_REQUIRED ErrorCode = "HPSF-E004"
+	CODE_INVALID_PROPERTY_TYPE  ErrorCode = "HPSF-E005"
+	CODE_VALUE_REQUIRED         ErrorCode = "HPSF-E006"
+	CODE_INVALID_VALUE          ErrorCode = "HPSF-E007"
+	CODE_DUPLICATE_NAME         ErrorCode = "HPSF-E008"
+	CODE_COMPONENT_NOT_FOUND    ErrorCode = "HPSF-E009"
+	CODE_CYCLE                  ErrorCode = "HPSF-E010"
+	CODE_INVALID_CONTAINER      ErrorCode = "HPSF-E011"
+	CODE_CONNECTION_INCOMPLETE  ErrorCode = "HPSF-E022"
+	CODE_EMPTY_DOCUMENT         ErrorCode = "HPSF-E023"
+
+	// problems found when expanding containers or checking against the component library
+	CODE_PORT_NOT_FOUND              ErrorCode = "HPSF-E012"
+	CODE_PORT_TYPE_MISMATCH          ErrorCode = "HPSF-E013"
+	CODE_RECURSIVE_CONTAINER         ErrorCode = "HPSF-E014"
+	CODE_PROPERTY_NOT_FOUND          ErrorCode = "HPSF-E015"
+	CODE_INVALID_PROPERTY            ErrorCode = "HPSF-E016"
+	CODE_COMPONENT_VALIDATION_FAILED ErrorCode = "HPSF-E017"
+	CODE_UNKNOWN_KIND                ErrorCode = "HPSF-E018"
+	CODE_PORT_CONNECTIONS            ErrorCode = "HPSF-E019"
+	CODE_SAMPLING_PATHS              ErrorCode = "HPSF-E020"
+	CODE_INVALID_TEMPLATE_PARAMETER  ErrorCode = "HPSF-E021"
+
+	// warnings about components that do nothing useful
+	CODE_DISCONNECTED_RECEIVER ErrorCode = "HPSF-W001"
+	CODE_DEAD_END_PROCESSOR    ErrorCode = "HPSF-W002"
+	CODE_UNFED_EXPORTER        ErrorCode = "HPSF-W003"
+	CODE_UNWIRED_STARTSAMPLING ErrorCode = "HPSF-W004"
+
+	// warnings about changes made to the document before it was used
+	CODE_COMPONENT_UPGRADED ErrorCode = "HPSF-W005"
+
+	// warnings about values that are risky to use
+	CODE_LITERAL_SECRET ErrorCode = "HPSF-W006"
+)
+
+type errorCodeInfo struct {
+	name        string
+	description string
+}
+
+var errorCodes = map[ErrorCode]errorCodeInfo{
+	CODE_UNKNOWN: {"unknown", "An error without a more specific code"},
+
+	CODE_NAME_REQUIRED:          {"name-required", "A component or container has no name"},
+	CODE_KIND_REQUIRED:          {"kind-required", "A component has no kind"},
+	CODE_INVALID_PORT_DIRECTION: {"invalid-port-direction", "A port's direction is not Input or Output"},
+	CODE_PROPERTY_NAME_REQUIRED: {"property-name-required", "A property has no name"},
+	CODE_INVALID_PROPERTY_TYPE:  {"invalid-property-type", "A property declares a type that doesn't exist"},
+	CODE_VALUE_REQUIRED:         {"value-required", "A property has no value"},
+	CODE_INVALID_VALUE:          {"invalid-value", "A property's value doesn't match its type"},
+	CODE_DUPLICATE_NAME:         {"duplicate-name", "More than one component has the same name"},
+	CODE_COMPONENT_NOT_FOUND:    {"component-not-found", "A connection or port refers to a component that doesn't exist"},
+	CODE_CYCLE:                  {"cycle", "Connections form a loop, so data would flow forever"},
+	CODE_INVALID_CONTAINER:      {"invalid-container", "A container definition is incomplete or its name is already used"},
+	CODE_CONNECTION_INCOMPLETE:  {"connection-incomplete", "One end of a connection has no component, port, or type"},
+	CODE_EMPTY_DOCUMENT:         {"empty-document", "The document has no components or containers"},
+
+	CODE_PORT_NOT_FOUND:              {"port-not-found", "A connection refers to a port the component doesn't have"},
+	CODE_PORT_TYPE_MISMATCH:          {"port-type-mismatch", "A connection joins ports that carry different types of data"},
+	CODE_RECURSIVE_CONTAINER:         {"recursive-container", "A container contains itself"},
+	CODE_PROPERTY_NOT_FOUND:          {"property-not-found", "A property is set that the component doesn't have"},
+	CODE_INVALID_PROPERTY:            {"invalid-property", "A property value fails the component's validation"},
+	CODE_COMPONENT_VALIDATION_FAILED: {"component-validation-failed", "A component's properties fail a rule that involves more than one of them"},
+	CODE_UNKNOWN_KIND:                {"unknown-kind", "No component in the library matches the kind and version"},
+	CODE_PORT_CONNECTIONS:            {"port-connections", "A port has more or fewer connections than its component allows"},
+	CODE_SAMPLING_PATHS:              {"sampling-paths", "The sampling components are not arranged as StartSampling requires"},
+	CODE_INVALID_TEMPLATE_PARAMETER:  {"invalid-template-parameter", "A template parameter doesn't match a component property"},
+
+	CODE_DISCONNECTED_RECEIVER: {"disconnected-receiver", "A receiver has no outgoing connections"},
+	CODE_DEAD_END_PROCESSOR:    {"dead-end-processor", "A processor is not connected to an exporter"},
+	CODE_UNFED_EXPORTER:        {"unfed-exporter", "An exporter has no incoming connections"},
+	CODE_UNWIRED_STARTSAMPLING: {"unwired-startsampling", "None of the outputs of a StartSampling component are connected"},
+
+	CODE_COMPONENT_UPGRADED: {"component-upgraded", "A component was upgraded from an older major version of its template"},
+
+	CODE_LITERAL_SECRET: {"literal-secret", "A secret is written into the document instead of referring to an environment variable"},
+}
+
+// Name returns the short, human-friendly name of the code, like "port-not-found".
+func (c ErrorCode) Name() string {
+	return errorCodes[c].name
+}
+
+// Description returns a one-sentence description of the problem the code identifies.
+func (c ErrorCode) Description() string {
+	return errorCodes[c].description
+}
+
+// String returns the code and its name, like "HPSF-E012 port-not-found".
+func (c ErrorCode) String() string {
+	if c.Name() == "" {
+		return string(c)
+	}
+	return string(c) + " " + c.Name()
+}
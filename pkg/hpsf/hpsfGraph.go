@@ -0,0 +1,152 @@
+package hpsf
+
+// A Graph is an index of the components and connections of an HPSF document,
+// so that components can be found by name, and the connections at either end
+// of a component (optionally on one port, or of one signal type) can be found
+// without scanning all the connections in the document. Validation and
+// translation look these up over and over, which gets slow for documents
+// with hundreds of components.
+//
+// A Graph is built once with NewGraph and never changes; it doesn't follow
+// later changes to the document. The slices it returns are shared, so they
+// must not be modified.
+//
+// Components are identified by name, the way connections refer to them.
+// The signal type of a connection is the type declared at its source.
+type Graph struct {
+	components  []*Component
+	connections []*Connection
+	byName      map[string]*Component
+	inputs      map[string][]*Connection
+	outputs     map[string][]*Connection
+	portInputs  map[graphPort][]*Connection
+	portOutputs map[graphPort][]*Connection
+	typeInputs  map[graphSignal][]*Connection
+	typeOutputs map[graphSignal][]*Connection
+}
+
+type graphPort struct {
+	component string
+	port      string
+}
+
+type graphSignal struct {
+	component string
+	ctype     ConnectionType
+}
+
+// NewGraph indexes the components and connections of the document. Connections
+// are kept in document order. If more than one component has the same name,
+// the first one is used.
+func NewGraph(h *HPSF) *Graph {
+	n := len(h.Components)
+	g := &Graph{
+		components:  h.Components,
+		connections: h.Connections,
+		byName:      make(map[string]*Component, n),
+		inputs:      make(map[string][]*Connection, n),
+		outputs:     make(map[string][]*Connection, n),
+		portInputs:  make(map[graphPort][]*Connection, n),
+		portOutputs: make(map[graphPort][]*Connection, n),
+		typeInputs:  make(map[graphSignal][]*Connection, n),
+		typeOutputs: make(map[graphSignal][]*Connection, n),
+	}
+	for _, c := range h.Components {
+		if _, ok := g.byName[c.Name]; !ok {
+			g.byName[c.Name] = c
+		}
+	}
+	for _, conn := range h.Connections {
+		src, dst := conn.Source, conn.Destination
+		g.outputs[src.Component] = append(g.outputs[src.Component], conn)
+		g.inputs[dst.Component] = append(g.inputs[dst.Component], conn)
+		srcPort, dstPort := graphPort{src.Component, src.PortName}, graphPort{dst.Component, dst.PortName}
+		g.portOutputs[srcPort] = append(g.portOutputs[srcPort], conn)
+		g.portInputs[dstPort] = append(g.portInputs[dstPort], conn)
+		srcSignal, dstSignal := graphSignal{src.Component, src.Type}, graphSignal{dst.Component, src.Type}
+		g.typeOutputs[srcSignal] = append(g.typeOutputs[srcSignal], conn)
+		g.typeInputs[dstSignal] = append(g.typeInputs[dstSignal], conn)
+	}
+	return g
+}
+
+// Components returns the components of the document, in document order.
+func (g *Graph) Components() []*Component {
+	return g.components
+}
+
+// Connections returns the connections of the document, in document order.
+func (g *Graph) Connections() []*Connection {
+	return g.connections
+}
+
+// Component returns the component with the given name, or nil if there isn't one.
+func (g *Graph) Component(name string) *Component {
+	return g.byName[name]
+}
+
+// Inputs returns the connections whose destination is the named component.
+func (g *Graph) Inputs(name string) []*Connection {
+	return g.inputs[name]
+}
+
+// Outputs returns the connections whose source is the named component.
+func (g *Graph) Outputs(name string) []*Connection {
+	return g.outputs[name]
+}
+
+// PortInputs returns the connections whose destination is the given port of the named component.
+func (g *Graph) PortInputs(name, port string) []*Connection {
+	return g.portInputs[graphPort{name, port}]
+}
+
+// PortOutputs returns the connections whose source is the given port of the named component.
+func (g *Graph) PortOutputs(name, port string) []*Connection {
+	return g.portOutputs[graphPort{name, port}]
+}
+
+// InputsOfType returns the connections of the given signal type whose destination is the named component.
+func (g *Graph) InputsOfType(name string, ctype ConnectionType) []*Connection {
+	return g.typeInputs[graphSignal{name, ctype}]
+}
+
+// OutputsOfType returns the connections of the given signal type whose source is the named component.
+func (g *Graph) OutputsOfType(name string, ctype ConnectionType) []*Connection {
+	return g.typeOutputs[graphSignal{name, ctype}]
+}
+
+// Downstream returns the components that data from the named component can
+// reach by following connections, in depth-first order. The component itself
+// isn't included, and connections to components that don't exist are ignored.
+func (g *Graph) Downstream(name string) []*Component {
+	return g.reachable(name, g.outputs, func(conn *Connection) string { return conn.Destination.Component })
+}
+
+// Upstream returns the components that can send data to the named component,
+// directly or through other components, in depth-first order. The component
+// itself isn't included, and connections from components that don't exist
+// are ignored.
+func (g *Graph) Upstream(name string) []*Component {
+	return g.reachable(name, g.inputs, func(conn *Connection) string { return conn.Source.Component })
+}
+
+func (g *Graph) reachable(name string, edges map[string][]*Connection, next func(*Connection) string) []*Component {
+	var found []*Component
+	visited := map[string]bool{name: true}
+	var dfs func(string)
+	dfs = func(name string) {
+		for _, conn := range edges[name] {
+			n := next(conn)
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			if c := g.byName[n]; c != nil {
+				found = append(found, c)
+				dfs(n)
+			}
+		}
+	}
+	dfs(name)
+	return found
+}
@@ -0,0 +1,150 @@
+package hpsf
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	h, err := FromYAML(`
+components:
+  - name: otlp
+    kind: OTelReceiver
+  - name: filter
+    kind: FilterProcessor
+  - name: honeycomb
+    kind: HoneycombExporter
+  - name: debug
+    kind: DebugExporter
+connections:
+  - source: {component: otlp, port: Traces, type: OTelTraces}
+    destination: {component: filter, port: Traces, type: OTelTraces}
+  - source: {component: otlp, port: Logs, type: OTelLogs}
+    destination: {component: honeycomb, port: Logs, type: OTelLogs}
+  - source: {component: filter, port: Traces, type: OTelTraces}
+    destination: {component: honeycomb, port: Traces, type: OTelTraces}
+  - source: {component: filter, port: Traces, type: OTelTraces}
+    destination: {component: missing, port: Traces, type: OTelTraces}
+`)
+	require.NoError(t, err)
+	g := NewGraph(&h)
+
+	assert.Len(t, g.Components(), 4)
+	assert.Len(t, g.Connections(), 4)
+	assert.Equal(t, "filter", g.Component("filter").Name)
+	assert.Nil(t, g.Component("missing"))
+
+	assert.Equal(t, h.Connections[:2], g.Outputs("otlp"))
+	assert.Equal(t, []*Connection{h.Connections[1], h.Connections[2]}, g.Inputs("honeycomb"))
+	assert.Empty(t, g.Inputs("debug"))
+	assert.Equal(t, []*Connection{h.Connections[1]}, g.PortOutputs("otlp", "Logs"))
+	assert.Equal(t, []*Connection{h.Connections[2]}, g.PortInputs("honeycomb", "Traces"))
+	assert.Empty(t, g.PortInputs("honeycomb", "Metrics"))
+	assert.Equal(t, []*Connection{h.Connections[0]}, g.OutputsOfType("otlp", CTYPE_TRACES))
+	assert.Equal(t, []*Connection{h.Connections[1]}, g.InputsOfType("honeycomb", CTYPE_LOGS))
+	assert.Empty(t, g.OutputsOfType("otlp", CTYPE_METRICS))
+
+	names := func(comps []*Component) []string {
+		var names []string
+		for _, c := range comps {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"filter", "honeycomb"}, names(g.Downstream("otlp")))
+	assert.Equal(t, []string{"honeycomb"}, names(g.Downstream("filter")))
+	assert.Empty(t, g.Downstream("debug"))
+	assert.Equal(t, []string{"otlp", "filter"}, names(g.Upstream("honeycomb")))
+
+	// the index doesn't follow changes to the document
+	h.Connections = h.Connections[:1]
+	assert.Len(t, g.Connections(), 4)
+}
+
+func TestGraph_Cycle(t *testing.T) {
+	h := &HPSF{
+		Components: []*Component{{Name: "a"}, {Name: "b"}},
+		Connections: []*Connection{
+			{Source: ConnectionPort{Component: "a"}, Destination: ConnectionPort{Component: "b"}},
+			{Source: ConnectionPort{Component: "b"}, Destination: ConnectionPort{Component: "a"}},
+		},
+	}
+	g := NewGraph(h)
+	require.Len(t, g.Downstream("a"), 1)
+	assert.Equal(t, "b", g.Downstream("a")[0].Name)
+	require.Len(t, g.Upstream("a"), 1)
+	assert.Equal(t, "b", g.Upstream("a")[0].Name)
+}
+
+// pipelineDocument makes a document with n receivers, each feeding its own
+// processor, which sends to its own exporter and to one shared exporter.
+func pipelineDocument(n int) *HPSF {
+	h := &HPSF{}
+	connect := func(src, dst string) {
+		h.Connections = append(h.Connections, &Connection{
+			Source:      ConnectionPort{Component: src, PortName: "Traces", Type: CTYPE_TRACES},
+			Destination: ConnectionPort{Component: dst, PortName: "Traces", Type: CTYPE_TRACES},
+		})
+	}
+	h.Components = append(h.Components, &Component{Name: "Shared Exporter", Kind: "Exporter"})
+	for i := range n {
+		recv, proc, exp := fmt.Sprintf("Receiver %d", i), fmt.Sprintf("Processor %d", i), fmt.Sprintf("Exporter %d", i)
+		h.Components = append(h.Components,
+			&Component{Name: recv, Kind: "Receiver"},
+			&Component{Name: proc, Kind: "Processor"},
+			&Component{Name: exp, Kind: "Exporter"},
+		)
+		connect(recv, proc)
+		connect(proc, exp)
+		connect(proc, "Shared Exporter")
+	}
+	return h
+}
+
+// benchmarkSizes are the numbers of pipelines in the benchmark documents;
+// each pipeline has three components.
+var benchmarkSizes = []int{10, 100, 1000}
+
+func benchmarkDocuments(b *testing.B, fn func(b *testing.B, h *HPSF)) {
+	for _, n := range benchmarkSizes {
+		h := pipelineDocument(n)
+		b.Run(fmt.Sprintf("components=%d", len(h.Components)), func(b *testing.B) {
+			fn(b, h)
+		})
+	}
+}
+
+func BenchmarkNewGraph(b *testing.B) {
+	benchmarkDocuments(b, func(b *testing.B, h *HPSF) {
+		for b.Loop() {
+			NewGraph(h)
+		}
+	})
+}
+
+func BenchmarkHPSF_FindAllPaths(b *testing.B) {
+	benchmarkDocuments(b, func(b *testing.B, h *HPSF) {
+		for b.Loop() {
+			h.FindAllPaths(nil)
+		}
+	})
+}
+
+func BenchmarkHPSF_VisitComponents(b *testing.B) {
+	benchmarkDocuments(b, func(b *testing.B, h *HPSF) {
+		for b.Loop() {
+			_ = h.VisitComponents(func(*Component) error { return nil })
+		}
+	})
+}
+
+func BenchmarkHPSF_Validate(b *testing.B) {
+	benchmarkDocuments(b, func(b *testing.B, h *HPSF) {
+		for b.Loop() {
+			_ = h.Validate()
+		}
+	})
+}
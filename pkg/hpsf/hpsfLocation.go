@@ -0,0 +1,162 @@
+package hpsf
+
+import (
+	"fmt"
+	"strings"
+
+	y "gopkg.in/yaml.v3"
+)
+
+// A Location identifies where in a source document an element of an HPSF
+// document was defined. Line and Column are 1-based; a zero Line means that
+// the location is unknown (for example, when the document was built in code
+// rather than parsed from YAML).
+type Location struct {
+	File   string `yaml:"file,omitempty" json:"file,omitempty"`
+	Line   int    `yaml:"line,omitempty" json:"line,omitempty"`
+	Column int    `yaml:"column,omitempty" json:"column,omitempty"`
+}
+
+// IsKnown returns true if the location refers to a real position in a source document.
+func (l Location) IsKnown() bool {
+	return l.Line > 0
+}
+
+// String returns the location in the conventional file:line:column format
+// understood by editors and CI annotation tools.
+func (l Location) String() string {
+	if !l.IsKnown() {
+		return ""
+	}
+	if l.File == "" {
+		return fmt.Sprintf("%d:%d", l.Line, l.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+func locationOf(node *y.Node) Location {
+	return Location{Line: node.Line, Column: node.Column}
+}
+
+// The UnmarshalYAML methods below decode the element as usual and then
+// record the position of its node so that validation errors can point at
+// the offending line. The local "plain" types have the same fields but no
+// methods, which keeps Decode from recursing back into UnmarshalYAML.
+
+func (h *HPSF) UnmarshalYAML(value *y.Node) error {
+	type plain HPSF
+	if err := value.Decode((*plain)(h)); err != nil {
+		return err
+	}
+	h.loc = locationOf(value)
+	return nil
+}
+
+func (c *Component) UnmarshalYAML(value *y.Node) error {
+	type plain Component
+	if err := value.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	c.loc = locationOf(value)
+	return nil
+}
+
+func (p *Property) UnmarshalYAML(value *y.Node) error {
+	type plain Property
+	if err := value.Decode((*plain)(p)); err != nil {
+		return err
+	}
+	p.loc = locationOf(value)
+	return nil
+}
+
+func (cp *ConnectionPort) UnmarshalYAML(value *y.Node) error {
+	type plain ConnectionPort
+	if err := value.Decode((*plain)(cp)); err != nil {
+		return err
+	}
+	cp.loc = locationOf(value)
+	return nil
+}
+
+func (c *Connection) UnmarshalYAML(value *y.Node) error {
+	type plain Connection
+	if err := value.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	c.loc = locationOf(value)
+	return nil
+}
+
+// Location returns the position in the source document where the component was defined.
+func (c *Component) Location() Location {
+	return c.loc
+}
+
+// Location returns the position in the source document where the property was defined.
+func (p *Property) Location() Location {
+	return p.loc
+}
+
+// Location returns the position in the source document where the connection port was defined.
+func (cp *ConnectionPort) Location() Location {
+	return cp.loc
+}
+
+// Location returns the position in the source document where the connection was defined.
+func (c *Connection) Location() Location {
+	return c.loc
+}
+
+// Location returns the position of the root of the source document.
+func (h *HPSF) Location() Location {
+	return h.loc
+}
+
+// setSourceFile records the file name in the location of every element in the document
+// that has a known position.
+func (h *HPSF) setSourceFile(file string) {
+	setFile := func(l *Location) {
+		if l.IsKnown() {
+			l.File = file
+		}
+	}
+	setFile(&h.loc)
+	for _, c := range h.Components {
+		setFile(&c.loc)
+		for i := range c.Properties {
+			setFile(&c.Properties[i].loc)
+		}
+	}
+	setConnFiles := func(conns []*Connection) {
+		for _, conn := range conns {
+			setFile(&conn.loc)
+			setFile(&conn.Source.loc)
+			setFile(&conn.Destination.loc)
+		}
+	}
+	setConnFiles(h.Connections)
+	for i := range h.Containers {
+		for j := range h.Containers[i].Components {
+			c := &h.Containers[i].Components[j]
+			setFile(&c.loc)
+			for k := range c.Properties {
+				setFile(&c.Properties[k].loc)
+			}
+		}
+		setConnFiles(h.Containers[i].Connections)
+	}
+}
+
+// FromNamedYAML parses an HPSF document just like FromYAML, and also records
+// the given file name in the location of every component, property, and
+// connection so that errors can be reported as file:line:column.
+func FromNamedYAML(file string, in string) (HPSF, error) {
+	var h HPSF
+	dec := y.NewDecoder(strings.NewReader(in))
+	if err := dec.Decode(&h); err != nil {
+		return h, err
+	}
+	h.setSourceFile(file)
+	return h, nil
+}
@@ -0,0 +1,85 @@
+package hpsf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const locationTestYAML = `components:
+  - name: otlp_in
+    kind: OTelReceiver
+    properties:
+      - name: GRPCPort
+      - name: HTTPPort
+        value: 1234
+  - name: otlp_out
+    kind: OTelGRPCExporter
+connections:
+  - source:
+      component: otlp_in2
+      port: Traces
+      type: OTelTraces
+    destination:
+      component: otlp_out
+      port: Traces
+      type: OTelTraces
+`
+
+func TestFromNamedYAML_Locations(t *testing.T) {
+	h, err := FromNamedYAML("workflow.yaml", locationTestYAML)
+	require.NoError(t, err)
+
+	assert.Equal(t, Location{File: "workflow.yaml", Line: 2, Column: 5}, h.Components[0].Location())
+	assert.Equal(t, Location{File: "workflow.yaml", Line: 5, Column: 9}, h.Components[0].Properties[0].Location())
+	assert.Equal(t, Location{File: "workflow.yaml", Line: 6, Column: 9}, h.Components[0].Properties[1].Location())
+	assert.Equal(t, Location{File: "workflow.yaml", Line: 8, Column: 5}, h.Components[1].Location())
+	assert.Equal(t, Location{File: "workflow.yaml", Line: 11, Column: 5}, h.Connections[0].Location())
+	assert.Equal(t, Location{File: "workflow.yaml", Line: 12, Column: 7}, h.Connections[0].Source.Location())
+	assert.Equal(t, Location{File: "workflow.yaml", Line: 16, Column: 7}, h.Connections[0].Destination.Location())
+
+	// documents parsed without a name still know their lines
+	h, err = FromYAML(locationTestYAML)
+	require.NoError(t, err)
+	assert.Equal(t, Location{Line: 2, Column: 5}, h.Components[0].Location())
+	assert.Equal(t, "2:5", h.Components[0].Location().String())
+
+	// and documents built in code have no location at all
+	c := &Component{Name: "a", Kind: "b"}
+	assert.False(t, c.Location().IsKnown())
+	assert.Equal(t, "", c.Location().String())
+}
+
+func TestHPSF_ValidateReportsLocations(t *testing.T) {
+	h, err := FromNamedYAML("workflow.yaml", locationTestYAML)
+	require.NoError(t, err)
+
+	err = h.Validate()
+	result, ok := err.(validator.Result)
+	require.True(t, ok)
+	require.Equal(t, 2, result.Len())
+
+	var herr *HPSFError
+	require.True(t, errors.As(result.Details[0], &herr))
+	assert.Equal(t, "GRPCPort", herr.Property)
+	assert.Equal(t, Location{File: "workflow.yaml", Line: 5, Column: 9}, herr.Location)
+	assert.Contains(t, herr.Error(), "Location: workflow.yaml:5:9")
+
+	require.True(t, errors.As(result.Details[1], &herr))
+	assert.Equal(t, "otlp_in2", herr.Component)
+	assert.Equal(t, Location{File: "workflow.yaml", Line: 12, Column: 7}, herr.Location)
+}
+
+func TestHPSFError_WithLocation(t *testing.T) {
+	e := NewError("oops").WithLocation(Location{File: "f.yaml", Line: 3, Column: 4})
+	assert.Equal(t, "E: oops Location: f.yaml:3:4", e.Error())
+
+	// an unknown location doesn't overwrite a known one
+	e.WithLocation(Location{})
+	assert.Equal(t, 3, e.Location.Line)
+
+	assert.Equal(t, "E: oops", NewError("oops").WithLocation(Location{}).Error())
+}
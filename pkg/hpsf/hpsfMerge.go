@@ -0,0 +1,458 @@
+// contains the logic for merging concurrent edits to an HPSF document.
+package hpsf
+
+import (
+	"fmt"
+	"reflect"
+
+	y "gopkg.in/yaml.v3"
+)
+
+// A Conflict is a change that Merge3 could not resolve because both sides
+// changed the same thing in different ways. Component and Property identify
+// what was changed, if applicable; Base, Ours, and Theirs hold the competing
+// values, with nil meaning the value was absent on that side. Type is the
+// type declared for the property, if any, and is used by String to redact
+// secrets.
+type Conflict struct {
+	Component string
+	Property  string
+	Type      PropType
+	Reason    string
+	Base      any
+	Ours      any
+	Theirs    any
+}
+
+func (c Conflict) String() string {
+	var target string
+	switch {
+	case c.Component != "" && c.Property != "":
+		target = c.Component + "." + c.Property + ": "
+	case c.Component != "":
+		target = c.Component + ": "
+	}
+	return fmt.Sprintf("%s%s (base: %s, ours: %s, theirs: %s)", target, c.Reason,
+		conflictValue(c.Type, c.Base), conflictValue(c.Type, c.Ours), conflictValue(c.Type, c.Theirs))
+}
+
+func conflictValue(t PropType, v any) string {
+	if v == nil {
+		return "<unset>"
+	}
+	return fmt.Sprintf("%v", t.Redact(v))
+}
+
+// merge3 is the usual three-way merge of a single value: if only one side
+// changed it, that side wins. If both sides changed it differently, it
+// returns ours and false.
+func merge3[T any](base, ours, theirs T) (T, bool) {
+	switch {
+	case reflect.DeepEqual(ours, theirs), reflect.DeepEqual(base, theirs):
+		return ours, true
+	case reflect.DeepEqual(base, ours):
+		return theirs, true
+	default:
+		return ours, false
+	}
+}
+
+// mergeEntry tracks one component across the three documents. Any of the
+// three may be nil if the component doesn't exist on that side.
+type mergeEntry struct {
+	id                 string
+	base, ours, theirs *Component
+	merged             *Component
+}
+
+// merger holds the state needed while merging three documents.
+type merger struct {
+	base, ours, theirs *HPSF
+	entries            []*mergeEntry
+	// these map component names on each side to the id of their entry
+	baseIDs, oursIDs, theirsIDs map[string]string
+	conflicts                   []Conflict
+}
+
+// Merge3 merges two documents that were both derived from base. Changes made
+// on only one side are applied, as are identical changes made on both sides.
+// Components are identified by name, following renames the same way Diff
+// does; property values are merged individually, so edits to different
+// properties of the same component don't conflict. Connections are merged as
+// a set, and layout positions are merged per component.
+//
+// When both sides changed the same thing differently, the merged document
+// uses the value from ours and the change is reported as a Conflict. The
+// merged document is always returned, but it should not be used without
+// review if there are any conflicts.
+func Merge3(base, ours, theirs *HPSF) (*HPSF, []Conflict) {
+	m := &merger{
+		base:      base,
+		ours:      ours,
+		theirs:    theirs,
+		baseIDs:   make(map[string]string),
+		oursIDs:   make(map[string]string),
+		theirsIDs: make(map[string]string),
+	}
+	m.matchEntries()
+
+	merged := &HPSF{}
+	m.mergeDocument(merged)
+	for _, e := range m.orderedEntries() {
+		m.mergeEntry(e)
+		if e.merged != nil {
+			merged.Components = append(merged.Components, e.merged)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range merged.Components {
+		if seen[c.GetSafeName()] {
+			m.conflict(Conflict{Component: c.Name, Reason: "more than one component has this name after merging"})
+		}
+		seen[c.GetSafeName()] = true
+	}
+
+	merged.Connections = m.mergeConnections()
+	merged.Layout = m.mergeLayout()
+	return merged, m.conflicts
+}
+
+func (m *merger) conflict(c Conflict) {
+	m.conflicts = append(m.conflicts, c)
+}
+
+// matchEntries pairs up the components of the three documents.
+func (m *merger) matchEntries() {
+	byID := make(map[string]*mergeEntry)
+	add := func(e *mergeEntry) {
+		m.entries = append(m.entries, e)
+		byID[e.id] = e
+	}
+
+	base, ours, theirs := NewGraph(m.base), NewGraph(m.ours), NewGraph(m.theirs)
+	oursMatch := matchComponents(base, ours)
+	theirsMatch := matchComponents(base, theirs)
+	for _, bc := range m.base.Components {
+		e := &mergeEntry{id: "base:" + bc.Name, base: bc}
+		m.baseIDs[bc.Name] = e.id
+		if n, ok := oursMatch[bc.Name]; ok {
+			e.ours = ours.Component(n)
+			m.oursIDs[n] = e.id
+		}
+		if n, ok := theirsMatch[bc.Name]; ok {
+			e.theirs = theirs.Component(n)
+			m.theirsIDs[n] = e.id
+		}
+		add(e)
+	}
+	// components added on either side are the same component if they have the same name
+	for _, oc := range m.ours.Components {
+		if _, ok := m.oursIDs[oc.Name]; !ok {
+			e := &mergeEntry{id: "new:" + oc.Name, ours: oc}
+			m.oursIDs[oc.Name] = e.id
+			add(e)
+		}
+	}
+	for _, tc := range m.theirs.Components {
+		if _, ok := m.theirsIDs[tc.Name]; ok {
+			continue
+		}
+		id := "new:" + tc.Name
+		m.theirsIDs[tc.Name] = id
+		if e, ok := byID[id]; ok {
+			e.theirs = tc
+			continue
+		}
+		add(&mergeEntry{id: id, theirs: tc})
+	}
+}
+
+// orderedEntries returns the entries in the order in which ours has its
+// components, followed by the ones that only survive on the other side.
+func (m *merger) orderedEntries() []*mergeEntry {
+	byID := make(map[string]*mergeEntry)
+	for _, e := range m.entries {
+		byID[e.id] = e
+	}
+	done := make(map[string]bool)
+	var result []*mergeEntry
+	for _, oc := range m.ours.Components {
+		id := m.oursIDs[oc.Name]
+		if !done[id] {
+			result = append(result, byID[id])
+			done[id] = true
+		}
+	}
+	for _, e := range m.entries {
+		if !done[e.id] {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (m *merger) mergeDocument(merged *HPSF) {
+	fields := []struct {
+		name               string
+		base, ours, theirs string
+		target             *string
+	}{
+		{"kind", m.base.Kind, m.ours.Kind, m.theirs.Kind, &merged.Kind},
+		{"version", m.base.Version, m.ours.Version, m.theirs.Version, &merged.Version},
+		{"format_version", m.base.FormatVersion, m.ours.FormatVersion, m.theirs.FormatVersion, &merged.FormatVersion},
+		{"name", m.base.Name, m.ours.Name, m.theirs.Name, &merged.Name},
+		{"summary", m.base.Summary, m.ours.Summary, m.theirs.Summary, &merged.Summary},
+		{"description", m.base.Description, m.ours.Description, m.theirs.Description, &merged.Description},
+		{"library_version", m.base.LibraryVersion, m.ours.LibraryVersion, m.theirs.LibraryVersion, &merged.LibraryVersion},
+	}
+	for _, f := range fields {
+		v, ok := merge3(f.base, f.ours, f.theirs)
+		if !ok {
+			m.conflict(Conflict{Reason: "document " + f.name + " changed on both sides", Base: f.base, Ours: f.ours, Theirs: f.theirs})
+		}
+		*f.target = v
+	}
+
+	// containers are merged as a whole since they're rarely edited; they're
+	// compared as YAML so that source locations don't count as changes
+	asYAML := func(c []Container) string {
+		data, _ := y.Marshal(c)
+		return string(data)
+	}
+	containers := map[string][]Container{
+		asYAML(m.base.Containers):   m.base.Containers,
+		asYAML(m.theirs.Containers): m.theirs.Containers,
+		asYAML(m.ours.Containers):   m.ours.Containers,
+	}
+	v, ok := merge3(asYAML(m.base.Containers), asYAML(m.ours.Containers), asYAML(m.theirs.Containers))
+	if !ok {
+		m.conflict(Conflict{Reason: "containers changed on both sides"})
+	}
+	merged.Containers = containers[v]
+}
+
+func (m *merger) mergeEntry(e *mergeEntry) {
+	switch {
+	case e.ours == nil && e.theirs == nil:
+		// deleted on both sides
+	case e.base == nil && e.theirs == nil:
+		e.merged = e.ours.clone()
+	case e.base == nil && e.ours == nil:
+		e.merged = e.theirs.clone()
+	case e.ours == nil:
+		if !componentsEqual(e.base, e.theirs) {
+			m.conflict(Conflict{Component: e.theirs.Name, Reason: "deleted in ours but changed in theirs"})
+			e.merged = e.theirs.clone()
+		}
+	case e.theirs == nil:
+		if !componentsEqual(e.base, e.ours) {
+			m.conflict(Conflict{Component: e.ours.Name, Reason: "deleted in theirs but changed in ours"})
+			e.merged = e.ours.clone()
+		}
+	default:
+		base := e.base
+		if base == nil {
+			// added on both sides; anything they agree on is fine
+			base = &Component{}
+		}
+		e.merged = m.mergeComponent(base, e.ours, e.theirs)
+	}
+}
+
+func (m *merger) mergeComponent(base, ours, theirs *Component) *Component {
+	merged := &Component{}
+	scalar := func(what string, b, o, t string) string {
+		v, ok := merge3(b, o, t)
+		if !ok {
+			m.conflict(Conflict{Component: ours.Name, Reason: what + " changed on both sides", Base: b, Ours: o, Theirs: t})
+		}
+		return v
+	}
+	merged.Name = scalar("name", base.Name, ours.Name, theirs.Name)
+	merged.Kind = scalar("kind", base.Kind, ours.Kind, theirs.Kind)
+	merged.Version = scalar("version", base.Version, ours.Version, theirs.Version)
+	merged.Style = scalar("style", base.Style, ours.Style, theirs.Style)
+	ports, ok := merge3(base.Ports, ours.Ports, theirs.Ports)
+	if !ok {
+		m.conflict(Conflict{Component: ours.Name, Reason: "ports changed on both sides"})
+	}
+	merged.Ports = ports
+
+	// properties keep the order they have in ours, followed by any that theirs added
+	var names []string
+	seen := make(map[string]bool)
+	for _, props := range [][]Property{ours.Properties, theirs.Properties} {
+		for _, p := range props {
+			if !seen[p.Name] {
+				names = append(names, p.Name)
+				seen[p.Name] = true
+			}
+		}
+	}
+	for _, name := range names {
+		bp, op, tp := mergeProperty(base, name), mergeProperty(ours, name), mergeProperty(theirs, name)
+		v, ok := merge3(bp, op, tp)
+		if !ok {
+			m.conflict(Conflict{Component: ours.Name, Property: name, Reason: "property changed on both sides",
+				Type: declaredType(op, tp, bp), Base: propertyValue(bp), Ours: propertyValue(op), Theirs: propertyValue(tp)})
+		}
+		if v != nil {
+			merged.Properties = append(merged.Properties, Property{Name: name, Value: cloneValue(v.Value), Type: v.Type})
+		}
+	}
+	return merged
+}
+
+// mergeProperty returns the parts of a property that matter to a merge, or
+// nil if the component doesn't have it.
+func mergeProperty(c *Component, name string) *Property {
+	p := c.GetProperty(name)
+	if p == nil {
+		return nil
+	}
+	return &Property{Name: p.Name, Value: p.Value, Type: p.Type}
+}
+
+func propertyValue(p *Property) any {
+	if p == nil {
+		return nil
+	}
+	return p.Value
+}
+
+// componentsEqual returns true if the components have the same name, kind,
+// and settings, regardless of the order of their properties.
+func componentsEqual(a, b *Component) bool {
+	return a.Name == b.Name && sameSettings(a, b)
+}
+
+// sameSettings returns true if the components have the same kind and
+// settings, regardless of their names and the order of their properties.
+func sameSettings(a, b *Component) bool {
+	if a.Kind != b.Kind || a.Version != b.Version || a.Style != b.Style ||
+		!reflect.DeepEqual(a.Ports, b.Ports) || len(a.Properties) != len(b.Properties) {
+		return false
+	}
+	for _, p := range a.Properties {
+		if !reflect.DeepEqual(mergeProperty(a, p.Name), mergeProperty(b, p.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeConnections merges the connections of the three documents as sets,
+// using component identities so that renames don't look like rewiring.
+func (m *merger) mergeConnections() []*Connection {
+	type sides struct {
+		base, ours, theirs *Connection
+	}
+	var keys []string
+	byKey := make(map[string]*sides)
+	collect := func(conns []*Connection, ids map[string]string, set func(*sides, *Connection)) {
+		for _, conn := range conns {
+			id := func(n string) string {
+				if id, ok := ids[n]; ok {
+					return id
+				}
+				// a dangling connection; it can only match itself
+				return "missing:" + n
+			}
+			key := connectionKey(id(conn.Source.Component), conn.Source.PortName,
+				id(conn.Destination.Component), conn.Destination.PortName, conn.Source.Type)
+			if _, ok := byKey[key]; !ok {
+				keys = append(keys, key)
+				byKey[key] = &sides{}
+			}
+			set(byKey[key], conn)
+		}
+	}
+	collect(m.ours.Connections, m.oursIDs, func(s *sides, c *Connection) { s.ours = c })
+	collect(m.theirs.Connections, m.theirsIDs, func(s *sides, c *Connection) { s.theirs = c })
+	collect(m.base.Connections, m.baseIDs, func(s *sides, c *Connection) { s.base = c })
+
+	// the merged component for each name on each side
+	byID := make(map[string]*mergeEntry)
+	for _, e := range m.entries {
+		byID[e.id] = e
+	}
+	lookup := func(ids map[string]string) map[string]*Component {
+		result := make(map[string]*Component)
+		for name, id := range ids {
+			if e := byID[id]; e.merged != nil {
+				result[name] = e.merged
+			}
+		}
+		return result
+	}
+	oursMerged, theirsMerged := lookup(m.oursIDs), lookup(m.theirsIDs)
+
+	var result []*Connection
+	for _, key := range keys {
+		s := byKey[key]
+		keep, _ := merge3(s.base != nil, s.ours != nil, s.theirs != nil)
+		if !keep {
+			continue
+		}
+		conn, names := s.ours, oursMerged
+		if conn == nil {
+			conn, names = s.theirs, theirsMerged
+		}
+		src, dst := names[conn.Source.Component], names[conn.Destination.Component]
+		if src == nil || dst == nil {
+			m.conflict(Conflict{
+				Component: conn.Source.Component,
+				Reason: fmt.Sprintf("connection %s.%s -> %s.%s refers to a component that was deleted",
+					conn.Source.Component, conn.Source.PortName, conn.Destination.Component, conn.Destination.PortName),
+			})
+			continue
+		}
+		c := &Connection{Source: conn.Source, Destination: conn.Destination}
+		c.Source.Component = src.Name
+		c.Destination.Component = dst.Name
+		result = append(result, c)
+	}
+	return result
+}
+
+// mergeLayout merges the layout of each component that survived the merge.
+func (m *merger) mergeLayout() *Layout {
+	if m.base.Layout == nil && m.ours.Layout == nil && m.theirs.Layout == nil {
+		return nil
+	}
+	// the name is handled by the component merge, so only the placement matters here
+	placement := func(h *HPSF, c *Component) *LayoutComponent {
+		if c == nil {
+			return nil
+		}
+		lc := h.layoutComponent(c.Name)
+		if lc == nil {
+			return nil
+		}
+		return &LayoutComponent{Position: lc.Position, Size: lc.Size}
+	}
+	layout := &Layout{}
+	for _, e := range m.orderedEntries() {
+		if e.merged == nil {
+			continue
+		}
+		v, ok := merge3(placement(m.base, e.base), placement(m.ours, e.ours), placement(m.theirs, e.theirs))
+		if !ok {
+			m.conflict(Conflict{Component: e.merged.Name, Reason: "moved on both sides"})
+		}
+		if v != nil {
+			lc := LayoutComponent{Name: e.merged.Name}
+			if v.Position != nil {
+				p := *v.Position
+				lc.Position = &p
+			}
+			if v.Size != nil {
+				s := *v.Size
+				lc.Size = &s
+			}
+			layout.Components = append(layout.Components, lc)
+		}
+	}
+	return layout
+}
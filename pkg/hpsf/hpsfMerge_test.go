@@ -0,0 +1,152 @@
+package hpsf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge3_NonOverlappingEdits(t *testing.T) {
+	base := parseDiffYAML(t, diffBaseYAML)
+	ours := parseDiffYAML(t, diffBaseYAML)
+	theirs := parseDiffYAML(t, diffBaseYAML)
+
+	// ours changes a property, adds a component, and moves the receiver
+	ours.Components[0].Properties[0].Value = 5000
+	ours.Components = append(ours.Components, &Component{Name: "Debug", Kind: "DebugExporter"})
+	ours.Connections = append(ours.Connections, &Connection{
+		Source:      ConnectionPort{Component: "Receiver", PortName: "Logs", Type: CTYPE_LOGS},
+		Destination: ConnectionPort{Component: "Debug", PortName: "Logs", Type: CTYPE_LOGS},
+	})
+	ours.Layout.Components[0].Position.Y = 40
+
+	// theirs changes a different property of the same component, renames the
+	// sampler, and rewires the exporter
+	theirs.Components[0].Properties = append(theirs.Components[0].Properties, Property{Name: "HTTPPort", Value: 4318})
+	theirs.Components[1].Name = "Sampler 1"
+	theirs.Connections[0].Destination.Component = "Sampler 1"
+	theirs.Connections[1].Source.Component = "Sampler 1"
+	theirs.Layout.Components[1].Name = "Sampler 1"
+	theirs.Components[2].Kind = "OTelGRPCExporter"
+	theirs.Components[2].Name = "OTel"
+	theirs.Connections[1].Destination.Component = "OTel"
+	theirs.Layout.Components[2].Name = "OTel"
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	require.Empty(t, conflicts)
+
+	names := []string{}
+	for _, c := range merged.Components {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"Receiver", "Sampler 1", "Debug", "OTel"}, names)
+	assert.Equal(t, 5000, merged.Components[0].GetProperty("GRPCPort").Value)
+	assert.Equal(t, 4318, merged.Components[0].GetProperty("HTTPPort").Value)
+
+	conns := []string{}
+	for _, c := range merged.Connections {
+		conns = append(conns, c.Source.Component+" -> "+c.Destination.Component)
+	}
+	assert.ElementsMatch(t, []string{"Receiver -> Sampler 1", "Receiver -> Debug", "Sampler 1 -> OTel"}, conns)
+
+	require.NotNil(t, merged.Layout)
+	require.Len(t, merged.Layout.Components, 3)
+	assert.Equal(t, "Receiver", merged.Layout.Components[0].Name)
+	assert.Equal(t, 40, merged.Layout.Components[0].Position.Y)
+	assert.Equal(t, "Sampler 1", merged.Layout.Components[1].Name)
+	assert.Equal(t, "OTel", merged.Layout.Components[2].Name)
+
+	require.NoError(t, merged.Validate())
+	// the inputs are untouched
+	assert.Equal(t, "Sampler", base.Components[1].Name)
+	assert.Equal(t, 40, ours.Layout.Components[0].Position.Y)
+}
+
+func TestMerge3_Conflicts(t *testing.T) {
+	base := parseDiffYAML(t, diffBaseYAML)
+	ours := parseDiffYAML(t, diffBaseYAML)
+	theirs := parseDiffYAML(t, diffBaseYAML)
+
+	ours.Components[0].Properties[0].Value = 5000
+	theirs.Components[0].Properties[0].Value = 6000
+
+	ours.Components[1].Name = "Ours"
+	ours.Connections[0].Destination.Component = "Ours"
+	ours.Connections[1].Source.Component = "Ours"
+	theirs.Components[1].Name = "Theirs"
+	theirs.Connections[0].Destination.Component = "Theirs"
+	theirs.Connections[1].Source.Component = "Theirs"
+
+	theirs.Layout.Components[0].Position.X = 10
+	ours.Layout.Components[0].Position.X = 20
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	require.Len(t, conflicts, 3)
+	assert.Equal(t, Conflict{Component: "Receiver", Property: "GRPCPort", Reason: "property changed on both sides",
+		Base: 4317, Ours: 5000, Theirs: 6000}, conflicts[0])
+	assert.Equal(t, Conflict{Component: "Ours", Reason: "name changed on both sides",
+		Base: "Sampler", Ours: "Ours", Theirs: "Theirs"}, conflicts[1])
+	assert.Equal(t, "Receiver: moved on both sides (base: <unset>, ours: <unset>, theirs: <unset>)", conflicts[2].String())
+
+	// conflicts resolve to ours
+	assert.Equal(t, 5000, merged.Components[0].GetProperty("GRPCPort").Value)
+	assert.Equal(t, "Ours", merged.Components[1].Name)
+	assert.Equal(t, "Ours", merged.Connections[0].Destination.Component)
+	assert.Equal(t, 20, merged.Layout.Components[0].Position.X)
+}
+
+func TestMerge3_ConflictsRedactSecrets(t *testing.T) {
+	base := parseDiffYAML(t, diffBaseYAML)
+	ours := parseDiffYAML(t, diffBaseYAML)
+	theirs := parseDiffYAML(t, diffBaseYAML)
+	ours.Components[2].SetProperty(Property{Name: "APIKey", Value: "abc123", Type: PTYPE_SECRET})
+	theirs.Components[2].SetProperty(Property{Name: "APIKey", Value: "${HONEYCOMB_API_KEY}"})
+
+	_, conflicts := Merge3(base, ours, theirs)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, PTYPE_SECRET, conflicts[0].Type)
+	assert.Equal(t, "Exporter.APIKey: property changed on both sides (base: <unset>, ours: <redacted>, theirs: ${HONEYCOMB_API_KEY})",
+		conflicts[0].String())
+}
+
+func TestMerge3_DeleteModify(t *testing.T) {
+	base := parseDiffYAML(t, diffBaseYAML)
+	ours := parseDiffYAML(t, diffBaseYAML)
+	theirs := parseDiffYAML(t, diffBaseYAML)
+
+	// ours deletes the exporter, which theirs has changed
+	ours.Components = ours.Components[:2]
+	ours.Connections = ours.Connections[:1]
+	theirs.Components[2].Properties = []Property{{Name: "APIKey", Value: "abc"}}
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "Exporter: deleted in ours but changed in theirs (base: <unset>, ours: <unset>, theirs: <unset>)",
+		conflicts[0].String())
+
+	// a changed component is kept, but the connection that ours removed stays removed
+	require.Len(t, merged.Components, 3)
+	assert.Equal(t, "abc", merged.Components[2].GetProperty("APIKey").Value)
+	assert.Len(t, merged.Connections, 1)
+}
+
+func TestMerge3_DeletedComponentConnection(t *testing.T) {
+	base := parseDiffYAML(t, diffBaseYAML)
+	ours := parseDiffYAML(t, diffBaseYAML)
+	theirs := parseDiffYAML(t, diffBaseYAML)
+
+	// ours deletes the exporter; theirs leaves it alone but connects something new to it
+	ours.Components = ours.Components[:2]
+	ours.Connections = ours.Connections[:1]
+	theirs.Connections = append(theirs.Connections, &Connection{
+		Source:      ConnectionPort{Component: "Receiver", PortName: "Logs", Type: CTYPE_LOGS},
+		Destination: ConnectionPort{Component: "Exporter", PortName: "Logs", Type: CTYPE_LOGS},
+	})
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	require.Len(t, conflicts, 1)
+	assert.Contains(t, conflicts[0].Reason, "Receiver.Logs -> Exporter.Logs refers to a component that was deleted")
+	assert.Len(t, merged.Components, 2)
+	assert.Len(t, merged.Connections, 1)
+}
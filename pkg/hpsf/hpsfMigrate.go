@@ -0,0 +1,126 @@
+// contains the machinery for upgrading documents between format versions.
+package hpsf
+
+import (
+	"fmt"
+	"slices"
+)
+
+// FormatVersion is the version of the HPSF format that this package reads
+// and writes. A document records the format it's written in in its
+// format_version field; documents without one are assumed to be in this
+// format. (The document's version field is the version of the workflow or
+// template itself, and has nothing to do with the format.)
+const FormatVersion = "v1"
+
+// A Migration upgrades a document from one format version to the next. Apply
+// is called on a copy of the document, so it can edit it freely; it doesn't
+// need to set the version, which is done after it returns.
+type Migration struct {
+	From        string
+	To          string
+	Description string
+	Apply       func(h *HPSF) error
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%s -> %s: %s", m.From, m.To, m.Description)
+}
+
+// Migrations is a registry of migrations, each of which upgrades a document
+// by one step. There may be at most one migration from any version, so there
+// is only one path from an old version to a newer one.
+type Migrations struct {
+	steps  map[string]Migration
+	latest string
+}
+
+// NewMigrations returns a registry with no migrations, whose latest version
+// is the given one.
+func NewMigrations(latest string) *Migrations {
+	return &Migrations{
+		steps:  make(map[string]Migration),
+		latest: latest,
+	}
+}
+
+// DefaultMigrations holds the migrations between the versions of the HPSF
+// format, and is used by Migrate. Every change to the format that would break
+// existing documents should come with a migration registered here, and an
+// update to FormatVersion.
+var DefaultMigrations = NewMigrations(FormatVersion)
+
+// Register adds a migration to the registry. It returns an error if there is
+// already a migration from the same version.
+func (ms *Migrations) Register(m Migration) error {
+	if m.From == "" || m.To == "" || m.From == m.To {
+		return fmt.Errorf("migration must be between two different versions, not %q and %q", m.From, m.To)
+	}
+	if m.Apply == nil {
+		return fmt.Errorf("migration from %s to %s has nothing to apply", m.From, m.To)
+	}
+	if existing, ok := ms.steps[m.From]; ok {
+		return fmt.Errorf("there is already a migration from %s (to %s)", m.From, existing.To)
+	}
+	ms.steps[m.From] = m
+	return nil
+}
+
+// Latest returns the newest version known to the registry.
+func (ms *Migrations) Latest() string {
+	return ms.latest
+}
+
+// Plan returns the migrations that would upgrade a document from one version
+// to another, in the order they would be applied. An empty from is taken to
+// mean FormatVersion. It returns an error if there is no way to get from one
+// to the other; in particular, documents can't be downgraded.
+func (ms *Migrations) Plan(from, to string) ([]Migration, error) {
+	if from == "" {
+		from = FormatVersion
+	}
+	plan := []Migration{}
+	seen := []string{from}
+	for v := from; v != to; {
+		m, ok := ms.steps[v]
+		if !ok {
+			return nil, fmt.Errorf("no migration path from version %s to %s", from, to)
+		}
+		if slices.Contains(seen, m.To) {
+			// registered migrations that go around in circles are a bug
+			return nil, fmt.Errorf("migrations from version %s loop back to %s", from, m.To)
+		}
+		seen = append(seen, m.To)
+		plan = append(plan, m)
+		v = m.To
+	}
+	return plan, nil
+}
+
+// Migrate returns a copy of the document upgraded to the target version, and
+// the migrations that were applied to it. An empty target means the latest
+// version. The document itself is not modified, so the result can be compared
+// with it (with Diff, for example) to see what the migration would change.
+func (ms *Migrations) Migrate(h *HPSF, target string) (*HPSF, []Migration, error) {
+	if target == "" {
+		target = ms.latest
+	}
+	plan, err := ms.Plan(h.FormatVersion, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	migrated := h.Clone()
+	for _, m := range plan {
+		if err := m.Apply(migrated); err != nil {
+			return nil, nil, fmt.Errorf("error migrating from version %s to %s: %w", m.From, m.To, err)
+		}
+		migrated.FormatVersion = m.To
+	}
+	return migrated, plan, nil
+}
+
+// Migrate upgrades a document to the target version of the HPSF format using
+// DefaultMigrations; see Migrations.Migrate.
+func Migrate(h *HPSF, target string) (*HPSF, []Migration, error) {
+	return DefaultMigrations.Migrate(h, target)
+}
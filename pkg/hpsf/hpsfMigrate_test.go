@@ -0,0 +1,138 @@
+package hpsf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMigrations returns a registry that upgrades v1 -> v2 -> v3, where v2
+// renames a component kind and v3 renames a property.
+func testMigrations(t *testing.T) *Migrations {
+	ms := NewMigrations("v3")
+	require.NoError(t, ms.Register(Migration{
+		From:        "v2",
+		To:          "v3",
+		Description: "rename the Port property to ListenPort",
+		Apply: func(h *HPSF) error {
+			for _, c := range h.Components {
+				for i := range c.Properties {
+					if c.Properties[i].Name == "Port" {
+						c.Properties[i].Name = "ListenPort"
+					}
+				}
+			}
+			return nil
+		},
+	}))
+	require.NoError(t, ms.Register(Migration{
+		From:        "v1",
+		To:          "v2",
+		Description: "OldReceiver is now OTelReceiver",
+		Apply: func(h *HPSF) error {
+			for _, c := range h.Components {
+				if c.Kind == "OldReceiver" {
+					c.Kind = "OTelReceiver"
+				}
+			}
+			return nil
+		},
+	}))
+	return ms
+}
+
+const migrateTestYAML = `components:
+  - name: in
+    kind: OldReceiver
+    properties:
+      - name: Port
+        value: 4317
+`
+
+func TestMigrations_Migrate(t *testing.T) {
+	ms := testMigrations(t)
+	h, err := FromYAML(migrateTestYAML)
+	require.NoError(t, err)
+
+	migrated, steps, err := ms.Migrate(&h, "")
+	require.NoError(t, err)
+	require.Len(t, steps, 2)
+	assert.Equal(t, "v1 -> v2: OldReceiver is now OTelReceiver", steps[0].String())
+	assert.Equal(t, "v3", migrated.FormatVersion)
+	assert.Equal(t, "OTelReceiver", migrated.Components[0].Kind)
+	assert.Equal(t, "ListenPort", migrated.Components[0].Properties[0].Name)
+
+	// the original is untouched, so the two can be compared
+	assert.Equal(t, "OldReceiver", h.Components[0].Kind)
+	assert.Empty(t, h.FormatVersion)
+	d := Diff(&h, migrated)
+	assert.Equal(t, []FieldChange{{Field: "format_version", Old: "", New: "v3"}}, d.FieldsChanged)
+	assert.Len(t, d.ComponentsRemoved, 1)
+	assert.Len(t, d.ComponentsAdded, 1)
+
+	// part of the way
+	migrated, steps, err = ms.Migrate(&h, "v2")
+	require.NoError(t, err)
+	assert.Len(t, steps, 1)
+	assert.Equal(t, "v2", migrated.FormatVersion)
+	assert.Equal(t, "Port", migrated.Components[0].Properties[0].Name)
+
+	// already there
+	h.FormatVersion = "v3"
+	migrated, steps, err = ms.Migrate(&h, "v3")
+	require.NoError(t, err)
+	assert.Empty(t, steps)
+	assert.Equal(t, "OldReceiver", migrated.Components[0].Kind)
+}
+
+func TestMigrations_Errors(t *testing.T) {
+	ms := testMigrations(t)
+	h, err := FromYAML(migrateTestYAML)
+	require.NoError(t, err)
+
+	h.FormatVersion = "v3"
+	_, _, err = ms.Migrate(&h, "v1")
+	assert.EqualError(t, err, "no migration path from version v3 to v1")
+
+	h.FormatVersion = "v0.1.0"
+	_, _, err = ms.Migrate(&h, "")
+	assert.EqualError(t, err, "no migration path from version v0.1.0 to v3")
+
+	assert.EqualError(t, ms.Register(Migration{From: "v1", To: "v4", Apply: func(*HPSF) error { return nil }}),
+		"there is already a migration from v1 (to v2)")
+	assert.Error(t, ms.Register(Migration{From: "v4", To: "v4", Apply: func(*HPSF) error { return nil }}))
+	assert.Error(t, ms.Register(Migration{From: "v4", To: "v5"}))
+
+	failing := NewMigrations("v2")
+	require.NoError(t, failing.Register(Migration{From: "v1", To: "v2", Apply: func(*HPSF) error {
+		return errors.New("boom")
+	}}))
+	h.FormatVersion = ""
+	_, _, err = failing.Migrate(&h, "")
+	assert.EqualError(t, err, "error migrating from version v1 to v2: boom")
+
+	loop := NewMigrations("v3")
+	require.NoError(t, loop.Register(Migration{From: "v1", To: "v2", Apply: func(*HPSF) error { return nil }}))
+	require.NoError(t, loop.Register(Migration{From: "v2", To: "v1", Apply: func(*HPSF) error { return nil }}))
+	_, err = loop.Plan("v1", "v3")
+	assert.EqualError(t, err, "migrations from version v1 loop back to v1")
+}
+
+func TestMigrate_CurrentFormat(t *testing.T) {
+	h, err := FromYAML(migrateTestYAML)
+	require.NoError(t, err)
+	migrated, steps, err := Migrate(&h, "")
+	require.NoError(t, err)
+	assert.Empty(t, steps)
+	assert.Equal(t, h.Components, migrated.Components)
+	assert.Equal(t, FormatVersion, DefaultMigrations.Latest())
+
+	// the version of the workflow itself isn't the format version
+	h.Version = "v0.1.0"
+	migrated, steps, err = Migrate(&h, "")
+	require.NoError(t, err)
+	assert.Empty(t, steps)
+	assert.Equal(t, "v0.1.0", migrated.Version)
+}
@@ -0,0 +1,284 @@
+package hpsf
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/honeycombio/hpsf/pkg/validator"
+)
+
+// A Report is a machine-readable form of the error returned by validation. It
+// can be rendered as JSON for tools of our own, or as SARIF for code scanning
+// and CI annotation tools.
+type Report struct {
+	Message  string          `json:"message,omitempty"`
+	Valid    bool            `json:"valid"`
+	Errors   int             `json:"errors"`
+	Warnings int             `json:"warnings"`
+	Problems []ReportProblem `json:"problems"`
+}
+
+// A ReportProblem describes one problem in a Report. Causes holds the chain of
+// errors that led to it, outermost first. Causes that aren't HPSFErrors only
+// have a Message.
+type ReportProblem struct {
+	Code      ErrorCode       `json:"code,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Severity  string          `json:"severity,omitempty"`
+	Message   string          `json:"message"`
+	Component string          `json:"component,omitempty"`
+	Property  string          `json:"property,omitempty"`
+	Location  *Location       `json:"location,omitempty"`
+	Causes    []ReportProblem `json:"causes,omitempty"`
+}
+
+// NewReport builds a Report from an error returned by validation, which is
+// usually a validator.Result. The details of nested Results are flattened
+// into a single list of problems, in order. A nil error produces a valid,
+// empty report.
+func NewReport(err error) *Report {
+	r := &Report{Problems: []ReportProblem{}}
+	if res, ok := err.(validator.Result); ok {
+		r.Message = res.Msg
+	}
+	for _, e := range flattenResult(err) {
+		p := reportProblem(e)
+		if p.Code == "" {
+			p.Code = CODE_UNKNOWN
+			p.Name = CODE_UNKNOWN.Name()
+			p.Severity = severityName(SEV_ERROR)
+		}
+		if p.Severity == severityName(SEV_WARN) {
+			r.Warnings++
+		} else {
+			r.Errors++
+		}
+		r.Problems = append(r.Problems, p)
+	}
+	r.Valid = r.Errors == 0
+	return r
+}
+
+// JSON returns the report as indented JSON.
+func (r *Report) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// flattenResult returns the details of a Result, recursively; any other
+// error is returned as the only detail.
+func flattenResult(err error) []error {
+	if err == nil {
+		return nil
+	}
+	res, ok := err.(validator.Result)
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, d := range res.Details {
+		errs = append(errs, flattenResult(d)...)
+	}
+	return errs
+}
+
+func reportProblem(err error) ReportProblem {
+	herr := wrappedHPSFError(err)
+	if herr == nil {
+		// plain errors have no code, but may wrap errors that do
+		p := ReportProblem{Message: err.Error()}
+		p.Causes = reportCauses(errors.Unwrap(err))
+		return p
+	}
+	// an HPSFError wrapped with fmt.Errorf keeps the context it was given
+	message := herr.Reason
+	if context, ok := strings.CutSuffix(err.Error(), herr.Error()); ok {
+		message = context + message
+	}
+	p := ReportProblem{
+		Code:      herr.GetCode(),
+		Name:      herr.GetCode().Name(),
+		Severity:  severityName(herr.Severity),
+		Message:   message,
+		Component: herr.Component,
+		Property:  herr.Property,
+		Causes:    reportCauses(herr.Cause),
+	}
+	if herr.Location.IsKnown() {
+		loc := herr.Location
+		p.Location = &loc
+	}
+	return p
+}
+
+// wrappedHPSFError returns the HPSFError that err is or wraps, like errors.As,
+// or nil if there isn't one. It doesn't look inside validation results, whose
+// errors are reported as causes instead.
+func wrappedHPSFError(err error) *HPSFError {
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) {
+		case *HPSFError:
+			return e
+		case validator.Result:
+			return nil
+		}
+	}
+	return nil
+}
+
+func reportCauses(err error) []ReportProblem {
+	var causes []ReportProblem
+	for _, e := range flattenResult(err) {
+		causes = append(causes, reportProblem(e))
+	}
+	return causes
+}
+
+func severityName(s ErrorSeverity) string {
+	if s == SEV_WARN {
+		return "warning"
+	}
+	return "error"
+}
+
+// fullMessage returns the message of a problem followed by those of its causes.
+func (p ReportProblem) fullMessage() string {
+	msgs := []string{p.Message}
+	for _, c := range p.Causes {
+		msgs = append(msgs, c.fullMessage())
+	}
+	return strings.Join(msgs, ": ")
+}
+
+// The sarif types are the subset of SARIF 2.1.0 that we produce.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// SARIF returns the report in SARIF 2.1.0 format. There is one rule for each
+// error code in the report. Problems are located in their file if it's known,
+// and by component name otherwise.
+func (r *Report) SARIF() ([]byte, error) {
+	codes := []ErrorCode{}
+	for _, p := range r.Problems {
+		if !slices.Contains(codes, p.Code) {
+			codes = append(codes, p.Code)
+		}
+	}
+	slices.Sort(codes)
+	rules := make([]sarifRule, 0, len(codes))
+	for _, c := range codes {
+		rules = append(rules, sarifRule{ID: string(c), Name: c.Name(), ShortDescription: sarifMessage{Text: c.Description()}})
+	}
+
+	results := make([]sarifResult, 0, len(r.Problems))
+	for _, p := range r.Problems {
+		res := sarifResult{
+			RuleID:    string(p.Code),
+			RuleIndex: slices.Index(codes, p.Code),
+			Level:     p.Severity,
+			Message:   sarifMessage{Text: p.fullMessage()},
+		}
+		var loc sarifLocation
+		if p.Location != nil && p.Location.File != "" {
+			loc.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: p.Location.File},
+				Region:           sarifRegion{StartLine: p.Location.Line, StartColumn: p.Location.Column},
+			}
+		}
+		if p.Component != "" {
+			loc.LogicalLocations = []sarifLogicalLocation{{Name: p.Component, Kind: "object"}}
+			res.Properties = map[string]any{"component": p.Component}
+			if p.Property != "" {
+				res.Properties["property"] = p.Property
+			}
+		}
+		if loc.PhysicalLocation != nil || loc.LogicalLocations != nil {
+			res.Locations = []sarifLocation{loc}
+		}
+		results = append(results, res)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "hpsf",
+				InformationURI: "https://github.com/honeycombio/hpsf",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,194 @@
+package hpsf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodes(t *testing.T) {
+	names := make(map[string]ErrorCode)
+	for code, info := range errorCodes {
+		assert.Regexp(t, `^HPSF-[EW]\d{3}$`, string(code))
+		assert.Regexp(t, `^[a-z]+(-[a-z]+)*$`, info.name, code)
+		assert.NotEmpty(t, info.description, code)
+		if other, ok := names[info.name]; ok {
+			t.Errorf("codes %s and %s have the same name %s", code, other, info.name)
+		}
+		names[info.name] = code
+	}
+	assert.Equal(t, "HPSF-E012 port-not-found", CODE_PORT_NOT_FOUND.String())
+	assert.Equal(t, "HPSF-X999", ErrorCode("HPSF-X999").String())
+	assert.Equal(t, CODE_UNKNOWN, NewError("no code").GetCode())
+}
+
+// reportTestResult returns a validation result with the kinds of errors that
+// validation produces: nested results, errors with causes, warnings, and a
+// plain error wrapping a result.
+func reportTestResult() error {
+	inner := validator.NewResult("component validation errors")
+	inner.Add(NewError("Kind must be set").WithCode(CODE_KIND_REQUIRED).WithComponent("a"))
+
+	nested := validator.NewResult("nested")
+	nested.Add(NewError("failed to validate property").WithCode(CODE_INVALID_PROPERTY).
+		WithComponent("b").WithProperty("Port").
+		WithLocation(Location{File: "f.yaml", Line: 3, Column: 5}).
+		WithCause(NewError("validation failed for property: noblanks").WithCode(CODE_INVALID_PROPERTY).
+			WithCause(errors.New("blank value"))))
+	nested.Add(NewWarning("exporter has no incoming connections").WithCode(CODE_UNFED_EXPORTER).WithComponent("c"))
+
+	result := validator.NewResult("HPSF validation errors")
+	result.Add(fmt.Errorf("failed to validate component a: %w", inner))
+	result.Details = append(result.Details, nested)
+	return result
+}
+
+func TestNewReport(t *testing.T) {
+	r := NewReport(reportTestResult())
+	assert.Equal(t, "HPSF validation errors", r.Message)
+	assert.False(t, r.Valid)
+	assert.Equal(t, 2, r.Errors)
+	assert.Equal(t, 1, r.Warnings)
+	require.Len(t, r.Problems, 3)
+
+	// a plain error has no code of its own, but keeps the errors it wraps
+	assert.Equal(t, ReportProblem{
+		Code:     CODE_UNKNOWN,
+		Name:     "unknown",
+		Severity: "error",
+		Message:  "failed to validate component a: component validation errors",
+		Causes: []ReportProblem{{
+			Code: CODE_KIND_REQUIRED, Name: "kind-required", Severity: "error", Message: "Kind must be set", Component: "a",
+		}},
+	}, r.Problems[0])
+
+	assert.Equal(t, ReportProblem{
+		Code:      CODE_INVALID_PROPERTY,
+		Name:      "invalid-property",
+		Severity:  "error",
+		Message:   "failed to validate property",
+		Component: "b",
+		Property:  "Port",
+		Location:  &Location{File: "f.yaml", Line: 3, Column: 5},
+		Causes: []ReportProblem{{
+			Code: CODE_INVALID_PROPERTY, Name: "invalid-property", Severity: "error",
+			Message: "validation failed for property: noblanks",
+			Causes:  []ReportProblem{{Message: "blank value"}},
+		}},
+	}, r.Problems[1])
+
+	assert.Equal(t, "warning", r.Problems[2].Severity)
+	assert.Equal(t, CODE_UNFED_EXPORTER, r.Problems[2].Code)
+
+	data, err := r.JSON()
+	require.NoError(t, err)
+	var decoded Report
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *r, decoded)
+}
+
+func TestNewReport_WrappedError(t *testing.T) {
+	herr := NewError("duplicate component name").WithCode(CODE_DUPLICATE_NAME).WithComponent("a").
+		WithLocation(Location{File: "f.yaml", Line: 4, Column: 5})
+	r := NewReport(fmt.Errorf("loading f.yaml: %w", herr))
+	require.Len(t, r.Problems, 1)
+	assert.Equal(t, ReportProblem{
+		Code:      CODE_DUPLICATE_NAME,
+		Name:      "duplicate-name",
+		Severity:  "error",
+		Message:   "loading f.yaml: duplicate component name",
+		Component: "a",
+		Location:  &Location{File: "f.yaml", Line: 4, Column: 5},
+	}, r.Problems[0])
+}
+
+func TestNewReport_Valid(t *testing.T) {
+	r := NewReport(nil)
+	assert.True(t, r.Valid)
+	data, err := r.JSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"valid": true, "errors": 0, "warnings": 0, "problems": []}`, string(data))
+
+	warnings := validator.NewResult("warnings")
+	warnings.Add(NewWarning("receiver has no outgoing connections").WithCode(CODE_DISCONNECTED_RECEIVER))
+	assert.True(t, NewReport(warnings).Valid)
+}
+
+func TestReport_SARIF(t *testing.T) {
+	data, err := NewReport(reportTestResult()).SARIF()
+	require.NoError(t, err)
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID   string `json:"id"`
+						Name string `json:"name"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []map[string]any `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "hpsf", run.Tool.Driver.Name)
+
+	ids := []string{}
+	for _, rule := range run.Tool.Driver.Rules {
+		ids = append(ids, rule.ID+" "+rule.Name)
+	}
+	assert.Equal(t, []string{"HPSF-E000 unknown", "HPSF-E016 invalid-property", "HPSF-W003 unfed-exporter"}, ids)
+
+	require.Len(t, run.Results, 3)
+	assert.JSONEq(t, `{
+		"ruleId": "HPSF-E016",
+		"ruleIndex": 1,
+		"level": "error",
+		"message": {"text": "failed to validate property: validation failed for property: noblanks: blank value"},
+		"locations": [{
+			"physicalLocation": {
+				"artifactLocation": {"uri": "f.yaml"},
+				"region": {"startLine": 3, "startColumn": 5}
+			},
+			"logicalLocations": [{"name": "b", "kind": "object"}]
+		}],
+		"properties": {"component": "b", "property": "Port"}
+	}`, mustJSON(t, run.Results[1]))
+	assert.Equal(t, "warning", run.Results[2]["level"])
+	assert.NotContains(t, run.Results[0], "locations")
+}
+
+func mustJSON(t *testing.T, v any) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestValidateErrorsHaveCodes(t *testing.T) {
+	h, err := FromYAML(`components:
+  - name: a
+    kind: Thing
+  - name: a
+  - kind: Thing
+connections:
+  - source: {component: a, port: Out, type: OTelTraces}
+    destination: {component: missing, port: In, type: OTelTraces}
+`)
+	require.NoError(t, err)
+	codes := []ErrorCode{}
+	for _, p := range NewReport(h.Validate()).Problems {
+		codes = append(codes, p.Code)
+	}
+	assert.Equal(t, []ErrorCode{CODE_KIND_REQUIRED, CODE_NAME_REQUIRED, CODE_COMPONENT_NOT_FOUND, CODE_DUPLICATE_NAME}, codes)
+}
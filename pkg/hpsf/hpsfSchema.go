@@ -0,0 +1,181 @@
+// contains the generator for the JSON Schema that describes HPSF documents.
+package hpsf
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// schemaNode is the subset of JSON Schema that we need to describe HPSF.
+// The fields are in the order we'd like them to appear in the output.
+type schemaNode struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Properties           map[string]*schemaNode `json:"properties,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *schemaNode            `json:"items,omitempty"`
+	Defs                 map[string]*schemaNode `json:"$defs,omitempty"`
+}
+
+// schemaEnums lists the allowed values of the string types that have them.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeFor[ConnectionType](): {
+		string(CTYPE_LOGS), string(CTYPE_METRICS), string(CTYPE_TRACES), string(CTYPE_EVENTS),
+		string(CTYPE_HONEY), string(CTYPE_SAMPLE), string(CTYPE_NUMBER), string(CTYPE_STRING), string(CTYPE_BOOL),
+	},
+	reflect.TypeFor[Direction](): {string(DIR_INPUT), string(DIR_OUTPUT)},
+	reflect.TypeFor[PropType](): {
+		string(PTYPE_INT), string(PTYPE_FLOAT), string(PTYPE_STRING), string(PTYPE_BOOL),
+		string(PTYPE_ARRSTR), string(PTYPE_ARRINT), string(PTYPE_ARRFLOAT), string(PTYPE_MAPSTR),
+		string(PTYPE_STRMAP), string(PTYPE_COND), string(PTYPE_DUR),
+		string(PTYPE_CHECK), string(PTYPE_CODE), string(PTYPE_RULE), string(PTYPE_SECRET),
+	},
+}
+
+// schemaDescriptions documents the types and fields in the schema. Keys are
+// either a type name or "Type.key".
+var schemaDescriptions = map[string]string{
+	"HPSF":                 "A workflow: a set of components and the connections between them",
+	"HPSF.kind":            "Only set for templates, where it identifies the template",
+	"HPSF.version":         "The version of the workflow or template",
+	"HPSF.format_version":  "The version of the HPSF format the document is written in; defaults to the latest",
+	"HPSF.name":            "A short name for the workflow",
+	"HPSF.summary":         "A one-line summary of what the workflow does",
+	"HPSF.description":     "A longer description of the workflow",
+	"HPSF.library_version": "The version of the component library the workflow was written for",
+	"HPSF.components":      "The components in the workflow; names must be unique",
+	"HPSF.connections":     "The connections between component ports",
+	"HPSF.containers":      "Reusable groups of components that can be used as if they were a single component",
+	"HPSF.layout":          "Where the components are drawn in the UI; has no effect on behavior",
+
+	"Component":            "An instance of a component from the component library",
+	"Component.name":       "The name of this instance; must be unique within the workflow",
+	"Component.kind":       "The kind of component from the library, or the name of a container",
+	"Component.version":    "The version of the component kind to use; defaults to the latest",
+	"Component.ports":      "Ports in addition to the ones the component kind declares",
+	"Component.properties": "Values for the component's properties; unset properties use their defaults",
+	"Component.style":      "Used to control UI rendering",
+
+	"Port":           "A port on a component",
+	"Port.name":      "The name of the port",
+	"Port.direction": "Whether data flows into or out of the component through this port",
+	"Port.type":      "The type of data that flows through the port",
+
+	"Property":       "A property value set on a component",
+	"Property.name":  "The name of the property, as declared by the component kind",
+	"Property.value": "The value; its shape depends on the property's type",
+	"Property.type":  "The type of the value, if it can't be determined from the component kind",
+
+	"Connection":               "A connection from an output port of one component to an input port of another",
+	"Connection.source":        "The output port the data comes from",
+	"Connection.destination":   "The input port the data goes to",
+	"ConnectionPort":           "One end of a connection",
+	"ConnectionPort.component": "The name of the component",
+	"ConnectionPort.port":      "The name of the port on the component",
+	"ConnectionPort.type":      "The type of data that flows through the connection",
+
+	"Container":             "A named group of components that can be used as a component kind",
+	"Container.name":        "The name of the container, used as the kind of components that are instances of it",
+	"Container.components":  "The components inside the container",
+	"Container.connections": "The connections between the components inside the container",
+	"Container.ports":       "The ports of the container, each of which exposes a port of a component inside it",
+	"Container.props":       "The properties of the container, each of which sets a property of a component inside it",
+	"PublicPort":            "A port of a container that exposes a port of one of its components",
+	"PublicPort.name":       "The name of the port on the container",
+	"PublicPort.component":  "The name of the component inside the container",
+	"PublicPort.port":       "The name of the port on that component",
+	"PublicProp":            "A property of a container that sets a property of one of its components",
+	"PublicProp.name":       "The name of the property on the container",
+	"PublicProp.component":  "The name of the component inside the container",
+	"PublicProp.property":   "The name of the property on that component",
+
+	"Layout":                   "The positions of components in the UI",
+	"Layout.components":        "The position of each component",
+	"LayoutComponent":          "The position and size of one component in the UI",
+	"LayoutComponent.name":     "The name of the component",
+	"LayoutComponent.position": "Where the component is drawn",
+	"LayoutComponent.size":     "How big the component is drawn",
+	"Pos":                      "A position in the UI",
+	"Siz":                      "A size in the UI",
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) for HPSF documents. It's
+// generated from the Go types, so it always matches what FromYAML and
+// FromJSON accept. A copy is kept in hpsf-schema.json at the root of the
+// repository for use by editors and other languages.
+func JSONSchema() ([]byte, error) {
+	defs := make(map[string]*schemaNode)
+	root := &schemaNode{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		ID:          "https://honeycomb.io/schemas/hpsf.json",
+		Title:       "HPSF Workflow",
+		Description: "JSON Schema for HPSF (Honeycomb Pipeline Specification Format) workflows and templates",
+		Ref:         schemaType(reflect.TypeFor[HPSF](), defs).Ref,
+		Defs:        defs,
+	}
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// schemaType returns the schema for values of type t, adding a definition to
+// defs for every struct type it encounters.
+func schemaType(t reflect.Type, defs map[string]*schemaNode) *schemaNode {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		ref := &schemaNode{Ref: "#/$defs/" + t.Name()}
+		if _, ok := defs[t.Name()]; ok {
+			return ref
+		}
+		f := false
+		def := &schemaNode{
+			Description:          schemaDescriptions[t.Name()],
+			Type:                 "object",
+			Properties:           make(map[string]*schemaNode),
+			AdditionalProperties: &f,
+		}
+		// add the definition before filling it in so that recursive types terminate
+		defs[t.Name()] = def
+		for i := range t.NumField() {
+			field, key, ok := yamlField(t, i)
+			if !ok {
+				continue
+			}
+			prop := schemaType(field.Type, defs)
+			prop.Description = schemaDescriptions[t.Name()+"."+key]
+			def.Properties[key] = prop
+			if !strings.Contains(field.Tag.Get("yaml"), ",omitempty") {
+				def.Required = append(def.Required, key)
+			}
+		}
+		return ref
+	case reflect.Slice:
+		return &schemaNode{Type: "array", Items: schemaType(t.Elem(), defs)}
+	case reflect.Map:
+		return &schemaNode{Type: "object"}
+	case reflect.String:
+		return &schemaNode{Type: "string", Enum: schemaEnums[t]}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schemaNode{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &schemaNode{Type: "number"}
+	case reflect.Bool:
+		return &schemaNode{Type: "boolean"}
+	default:
+		// interfaces (like property values) can hold anything
+		return &schemaNode{}
+	}
+}
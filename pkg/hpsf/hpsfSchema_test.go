@@ -0,0 +1,131 @@
+package hpsf
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var schemaPath = filepath.Join("..", "..", "hpsf-schema.json")
+
+// TestJSONSchemaIsCurrent makes sure the checked-in schema matches the Go types.
+// Run with OVERWRITE_TESTDATA=1 to regenerate it.
+func TestJSONSchemaIsCurrent(t *testing.T) {
+	generated, err := JSONSchema()
+	require.NoError(t, err)
+
+	if os.Getenv("OVERWRITE_TESTDATA") == "1" {
+		require.NoError(t, os.WriteFile(schemaPath, generated, 0644))
+	}
+
+	existing, err := os.ReadFile(schemaPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(existing), string(generated),
+		"hpsf-schema.json is out of date; regenerate it with OVERWRITE_TESTDATA=1 go test ./pkg/hpsf")
+}
+
+func compileHPSFSchema(t *testing.T) *jsonschema.Schema {
+	data, err := JSONSchema()
+	require.NoError(t, err)
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	require.NoError(t, compiler.AddResource("hpsf-schema.json", bytes.NewReader(data)))
+	schema, err := compiler.Compile("hpsf-schema.json")
+	require.NoError(t, err)
+	return schema
+}
+
+// yamlToJSONValue converts YAML to the generic form the schema validator expects.
+func yamlToJSONValue(t *testing.T, data []byte) any {
+	var m any
+	require.NoError(t, yaml.Unmarshal(data, &m))
+	jsonData, err := json.Marshal(m)
+	require.NoError(t, err)
+	var v any
+	require.NoError(t, json.Unmarshal(jsonData, &v))
+	return v
+}
+
+func TestJSONSchemaValidatesDocuments(t *testing.T) {
+	schema := compileHPSFSchema(t)
+
+	var files []string
+	for _, pattern := range []string{
+		filepath.Join("..", "..", "examples", "*.yaml"),
+		filepath.Join("..", "..", "tests", "smoke", "*.yaml"),
+		filepath.Join("..", "data", "templates", "*.yaml"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		require.NoError(t, err)
+		require.NotEmpty(t, matches, "no files match %s", pattern)
+		files = append(files, matches...)
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			require.NoError(t, schema.Validate(yamlToJSONValue(t, data)))
+
+			// the JSON form of the parsed document validates too
+			h, err := FromYAML(string(data))
+			require.NoError(t, err)
+			js, err := h.AsJSON()
+			require.NoError(t, err)
+			var v any
+			require.NoError(t, json.Unmarshal([]byte(js), &v))
+			require.NoError(t, schema.Validate(v))
+		})
+	}
+}
+
+func TestJSONSchemaRejectsInvalidDocuments(t *testing.T) {
+	schema := compileHPSFSchema(t)
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"unknown key", "components:\n  - name: a\n    kind: b\n    propertys: []\n"},
+		{"missing kind", "components:\n  - name: a\n"},
+		{"bad connection type", `connections:
+  - source: {component: a, port: p, type: Traces}
+    destination: {component: b, port: p, type: Traces}
+`},
+		{"bad position", "layout:\n  components:\n    - name: a\n      position: {x: left, y: 1}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, schema.Validate(yamlToJSONValue(t, []byte(tt.input))))
+		})
+	}
+}
+
+func TestHPSF_JSONRoundTrip(t *testing.T) {
+	h, err := FromYAML(containerTestYAML)
+	require.NoError(t, err)
+
+	js, err := h.AsJSON()
+	require.NoError(t, err)
+	assert.Contains(t, js, `"components": [`)
+	assert.Contains(t, js, `"value": "abc123"`)
+
+	h2, err := FromJSON(js)
+	require.NoError(t, err)
+	y1, err := h.AsYAML()
+	require.NoError(t, err)
+	y2, err := h2.AsYAML()
+	require.NoError(t, err)
+	assert.Equal(t, y1, y2)
+	// locations refer to the JSON text
+	assert.True(t, h2.Components[0].Location().IsKnown())
+
+	_, err = FromJSON("components:\n  - name: a\n")
+	assert.ErrorContains(t, err, "error parsing hpsf JSON")
+}
@@ -0,0 +1,50 @@
+package hpsf
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RedactedValue is shown in place of the value of a secret.
+const RedactedValue = "<redacted>"
+
+var secretReference = regexp.MustCompile(`^\$\{[^{}]+\}$`)
+
+// IsSecretReference reports whether a value refers to an environment
+// variable, like ${HTP_EXPORTER_APIKEY}, rather than being a secret itself.
+func IsSecretReference(v any) bool {
+	s, ok := v.(string)
+	return ok && secretReference.MatchString(s)
+}
+
+// IsLiteralSecret reports whether a value of this type is a secret written
+// into the document, rather than a reference to one.
+func (p PropType) IsLiteralSecret(v any) bool {
+	if p != PTYPE_SECRET || v == nil || v == "" {
+		return false
+	}
+	return !IsSecretReference(v)
+}
+
+// Redact returns the value to show for a property of this type. Literal
+// secrets are replaced by RedactedValue; everything else, including
+// references to secrets, is returned unchanged.
+func (p PropType) Redact(v any) any {
+	if p.IsLiteralSecret(v) {
+		return RedactedValue
+	}
+	return v
+}
+
+// SecretEnvVar returns the name of the environment variable that is used in
+// place of a literal secret when it is rendered into a collector config,
+// like HTP_MY_EXPORTER_APIKEY for the APIKey property of "My Exporter".
+func SecretEnvVar(component, property string) string {
+	name := strings.ToUpper("HTP_" + component + "_" + property)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
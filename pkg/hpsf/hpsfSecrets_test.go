@@ -0,0 +1,69 @@
+package hpsf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropType_Redact(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     PropType
+		value   any
+		literal bool
+		want    any
+	}{
+		{"literal secret", PTYPE_SECRET, "abc123", true, RedactedValue},
+		{"numeric secret", PTYPE_SECRET, 12345, true, RedactedValue},
+		{"reference", PTYPE_SECRET, "${HTP_EXPORTER_APIKEY}", false, "${HTP_EXPORTER_APIKEY}"},
+		{"reference with text", PTYPE_SECRET, "key-${SUFFIX}", true, RedactedValue},
+		{"empty", PTYPE_SECRET, "", false, ""},
+		{"missing", PTYPE_SECRET, nil, false, nil},
+		{"not a secret", PTYPE_STRING, "abc123", false, "abc123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.literal, tt.typ.IsLiteralSecret(tt.value))
+			assert.Equal(t, tt.want, tt.typ.Redact(tt.value))
+		})
+	}
+}
+
+func TestSecretEnvVar(t *testing.T) {
+	assert.Equal(t, "HTP_HONEYCOMB_APIKEY", SecretEnvVar("honeycomb", "APIKey"))
+	assert.Equal(t, "HTP_MY_EXPORTER_1_APIKEY", SecretEnvVar("My Exporter 1", "APIKey"))
+	assert.Equal(t, "HTP_BOX_SEND_APIKEY", SecretEnvVar("Box/send", "APIKey"))
+}
+
+func TestPropType_SecretErrorsHideValue(t *testing.T) {
+	require.NoError(t, PTYPE_SECRET.Validate())
+
+	var v any
+	require.NoError(t, PTYPE_SECRET.ValueCoerce("abc123", &v))
+	assert.Equal(t, "abc123", v)
+	require.NoError(t, PTYPE_SECRET.ValueCoerce(12345, &v))
+	assert.Equal(t, "12345", v)
+
+	err := PTYPE_SECRET.ValueCoerce([]any{"abc123"}, &v)
+	assert.EqualError(t, err, "expected string for secret, got []interface {}")
+	err = PTYPE_SECRET.ValueConforms(12345)
+	assert.EqualError(t, err, "expected string for secret, got int")
+
+	// the value doesn't show up when a document declares a property as secret
+	h, err := FromYAML(`components:
+  - name: c
+    kind: Thing
+    properties:
+      - name: Key
+        type: secret
+        value: [abc123]
+`)
+	require.NoError(t, err)
+	err = h.Validate()
+	require.Error(t, err)
+	problem := NewReport(err).Problems[0]
+	require.Len(t, problem.Causes, 1)
+	assert.NotContains(t, problem.Causes[0].Message, "abc123")
+}
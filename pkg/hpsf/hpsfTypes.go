@@ -2,6 +2,7 @@ package hpsf
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -9,6 +10,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dgryski/go-metro"
 	"github.com/honeycombio/hpsf/pkg/validator"
@@ -65,17 +67,21 @@ func (c ConnectionType) AsCollectorSignalType() string {
 type PropType string
 
 const (
-	PTYPE_INT    PropType = "int"
-	PTYPE_FLOAT  PropType = "float"
-	PTYPE_STRING PropType = "string"
-	PTYPE_BOOL   PropType = "bool"
-	PTYPE_ARRSTR PropType = "stringarray" // []string
-	PTYPE_MAPSTR PropType = "map"         // map[string]any
-	PTYPE_COND   PropType = "conditions"  // for refinery conditions
-	PTYPE_DUR    PropType = "duration"    // has affordances for duration strings
-	PTYPE_CHECK  PropType = "checklist"   // for checklist properties
-	PTYPE_CODE   PropType = "code"        // for code properties
-	PTYPE_RULE   PropType = "rule"        // for refinery sampling rules (experimental!)
+	PTYPE_INT      PropType = "int"
+	PTYPE_FLOAT    PropType = "float"
+	PTYPE_STRING   PropType = "string"
+	PTYPE_BOOL     PropType = "bool"
+	PTYPE_ARRSTR   PropType = "stringarray" // []string
+	PTYPE_ARRINT   PropType = "intarray"    // []int
+	PTYPE_ARRFLOAT PropType = "floatarray"  // []float64
+	PTYPE_MAPSTR   PropType = "map"         // map[string]any
+	PTYPE_STRMAP   PropType = "stringmap"   // map[string]string
+	PTYPE_COND     PropType = "conditions"  // for refinery conditions
+	PTYPE_DUR      PropType = "duration"    // has affordances for duration strings
+	PTYPE_CHECK    PropType = "checklist"   // for checklist properties
+	PTYPE_CODE     PropType = "code"        // for code properties
+	PTYPE_RULE     PropType = "rule"        // a refinery sampling rule, as a map[string]any
+	PTYPE_SECRET   PropType = "secret"      // a string that must not be exposed, like an API key
 )
 
 func (p PropType) Validate() error {
@@ -85,12 +91,16 @@ func (p PropType) Validate() error {
 	case PTYPE_STRING:
 	case PTYPE_BOOL:
 	case PTYPE_ARRSTR:
+	case PTYPE_ARRINT:
+	case PTYPE_ARRFLOAT:
 	case PTYPE_MAPSTR:
+	case PTYPE_STRMAP:
 	case PTYPE_COND:
 	case PTYPE_DUR:
 	case PTYPE_CHECK:
 	case PTYPE_RULE:
 	case PTYPE_CODE:
+	case PTYPE_SECRET:
 	default:
 		return errors.New("invalid PropType '" + string(p) + "'")
 	}
@@ -192,6 +202,18 @@ func (p PropType) ValueCoerce(a any, target *any) error {
 		default:
 			return errors.New("expected string array, got " + fmt.Sprint(a))
 		}
+	case PTYPE_ARRINT:
+		ints, err := coerceElements[int](a, PTYPE_INT)
+		if err != nil {
+			return err
+		}
+		*target = ints
+	case PTYPE_ARRFLOAT:
+		floats, err := coerceElements[float64](a, PTYPE_FLOAT)
+		if err != nil {
+			return err
+		}
+		*target = floats
 	case PTYPE_MAPSTR:
 		switch v := a.(type) {
 		case map[string]any:
@@ -199,6 +221,23 @@ func (p PropType) ValueCoerce(a any, target *any) error {
 		default:
 			return errors.New("expected dictionary, got " + fmt.Sprint(a))
 		}
+	case PTYPE_STRMAP:
+		switch v := a.(type) {
+		case map[string]string:
+			*target = v
+		case map[string]any:
+			m := make(map[string]string, len(v))
+			for k, val := range v {
+				var s any
+				if err := PTYPE_STRING.ValueCoerce(val, &s); err != nil {
+					return fmt.Errorf("expected string for key %s, got %v", k, val)
+				}
+				m[k] = s.(string)
+			}
+			*target = m
+		default:
+			return errors.New("expected dictionary of strings, got " + fmt.Sprint(a))
+		}
 	case PTYPE_COND:
 		switch v := a.(type) {
 		case map[string]any:
@@ -206,6 +245,22 @@ func (p PropType) ValueCoerce(a any, target *any) error {
 		default:
 			return errors.New("expected dictionary, got " + fmt.Sprint(a))
 		}
+	case PTYPE_DUR:
+		// numbers are seconds here; a template property can choose another
+		// unit with its subtype
+		d, err := ParseDuration(a, time.Second)
+		if err != nil {
+			return errors.New("expected duration, got " + fmt.Sprint(a))
+		}
+		*target = FormatDuration(d)
+	case PTYPE_RULE:
+		// the contents are checked against the structure of a rule by the template property
+		switch v := a.(type) {
+		case map[string]any:
+			*target = v
+		default:
+			return errors.New("expected dictionary for rule, got " + fmt.Sprint(a))
+		}
 	case PTYPE_CHECK:
 		switch v := a.(type) {
 		case []string:
@@ -238,6 +293,16 @@ func (p PropType) ValueCoerce(a any, target *any) error {
 		default:
 			return errors.New("expected string or string array for code property, got " + fmt.Sprint(a))
 		}
+	case PTYPE_SECRET:
+		// never include the value of a secret in an error
+		switch v := a.(type) {
+		case int, float64:
+			*target = fmt.Sprint(a)
+		case string:
+			*target = v
+		default:
+			return fmt.Errorf("expected string for secret, got %T", a)
+		}
 	default:
 		return errors.New("invalid PropType '" + string(p) + "'")
 	}
@@ -270,10 +335,34 @@ func (p PropType) ValueConforms(a any) error {
 		if _, ok := a.([]string); !ok {
 			return errors.New("expected []string, got " + fmt.Sprint(a))
 		}
+	case PTYPE_ARRINT:
+		if _, ok := a.([]int); !ok {
+			return errors.New("expected []int, got " + fmt.Sprint(a))
+		}
+	case PTYPE_ARRFLOAT:
+		if _, ok := a.([]float64); !ok {
+			return errors.New("expected []float64, got " + fmt.Sprint(a))
+		}
 	case PTYPE_MAPSTR:
 		if _, ok := a.(map[string]any); !ok {
 			return errors.New("expected map[string]any, got " + fmt.Sprint(a))
 		}
+	case PTYPE_STRMAP:
+		if _, ok := a.(map[string]string); !ok {
+			return errors.New("expected map[string]string, got " + fmt.Sprint(a))
+		}
+	case PTYPE_DUR:
+		s, ok := a.(string)
+		if !ok {
+			return errors.New("expected duration string, got " + fmt.Sprint(a))
+		}
+		if _, err := time.ParseDuration(s); err != nil {
+			return errors.New("expected duration string, got " + s)
+		}
+	case PTYPE_RULE:
+		if _, ok := a.(map[string]any); !ok {
+			return errors.New("expected map[string]any for rule, got " + fmt.Sprint(a))
+		}
 	case PTYPE_CHECK:
 		if _, ok := a.([]string); !ok {
 			return errors.New("expected []string for checklist, got " + fmt.Sprint(a))
@@ -282,12 +371,43 @@ func (p PropType) ValueConforms(a any) error {
 		if _, ok := a.(string); !ok {
 			return errors.New("expected string for code, got " + fmt.Sprint(a))
 		}
+	case PTYPE_SECRET:
+		if _, ok := a.(string); !ok {
+			return fmt.Errorf("expected string for secret, got %T", a)
+		}
 	default:
 		return errors.New("invalid PropType '" + string(p) + "'")
 	}
 	return nil
 }
 
+// coerceElements coerces each element of an array to the element type, for
+// the typed array property types.
+func coerceElements[T any](a any, elemType PropType) ([]T, error) {
+	var elems []any
+	switch v := a.(type) {
+	case []T:
+		return v, nil
+	case []any:
+		elems = v
+	case []string:
+		for _, s := range v {
+			elems = append(elems, s)
+		}
+	default:
+		return nil, fmt.Errorf("expected %s array, got %v", elemType, a)
+	}
+	result := make([]T, len(elems))
+	for i, elem := range elems {
+		var coerced any
+		if err := elemType.ValueCoerce(elem, &coerced); err != nil {
+			return nil, fmt.Errorf("element %d: %w", i+1, err)
+		}
+		result[i] = coerced.(T)
+	}
+	return result, nil
+}
+
 type Direction string
 
 const (
@@ -305,6 +425,7 @@ type Property struct {
 	Name  string   `yaml:"name"`
 	Value any      `yaml:"value"`
 	Type  PropType `yaml:"type,omitempty"`
+	loc   Location
 }
 
 type Component struct {
@@ -314,6 +435,7 @@ type Component struct {
 	Ports      []Port     `yaml:"ports,omitempty"`
 	Properties []Property `yaml:"properties,omitempty"`
 	Style      string     `yaml:"style,omitempty"`
+	loc        Location
 }
 
 type ErrorSeverity string
@@ -325,10 +447,12 @@ const (
 
 type HPSFError struct {
 	Severity  ErrorSeverity `yaml:"severity"`
+	Code      ErrorCode     `yaml:"code,omitempty"`
 	Component string        `yaml:"component,omitempty"`
 	Property  string        `yaml:"property,omitempty"`
 	Reason    string        `yaml:"reason"`
 	Cause     error         `yaml:"cause,omitempty"`
+	Location  Location      `yaml:"location,omitempty"`
 }
 
 func (e *HPSFError) Error() string {
@@ -342,6 +466,9 @@ func (e *HPSFError) Error() string {
 	if e.Cause != nil {
 		err += fmt.Sprintf(" Cause: %s", e.Cause)
 	}
+	if e.Location.IsKnown() {
+		err += fmt.Sprintf(" Location: %s", e.Location)
+	}
 	return err
 }
 
@@ -349,6 +476,21 @@ func (e *HPSFError) Unwrap() error {
 	return e.Cause
 }
 
+// GetCode returns the code identifying the kind of problem, or CODE_UNKNOWN if
+// none was set.
+func (e *HPSFError) GetCode() ErrorCode {
+	if e.Code == "" {
+		return CODE_UNKNOWN
+	}
+	return e.Code
+}
+
+// WithCode sets the code that identifies the kind of problem; see ErrorCode.
+func (e *HPSFError) WithCode(c ErrorCode) *HPSFError {
+	e.Code = c
+	return e
+}
+
 func (e *HPSFError) WithComponent(c string) *HPSFError {
 	e.Component = c
 	return e
@@ -359,6 +501,15 @@ func (e *HPSFError) WithProperty(p string) *HPSFError {
 	return e
 }
 
+// WithLocation records where in the source document the problem was found.
+// An unknown location leaves any location already set on the error unchanged.
+func (e *HPSFError) WithLocation(l Location) *HPSFError {
+	if l.IsKnown() {
+		e.Location = l
+	}
+	return e
+}
+
 // WithCause accepts an error that will be used to populate the Cause field of the HPSFError struct.
 // This allows you to wrap another error inside an HPSFError, which can be useful for debugging.
 func (e *HPSFError) WithCause(c error) *HPSFError {
@@ -396,34 +547,55 @@ func NewWarningf(format string, args ...any) *HPSFError {
 	}
 }
 
+// OnlyWarnings returns true if err is a warning, or a validator.Result whose details
+// are all warnings. These are problems worth reporting that don't stop a
+// configuration from being used. It returns false for nil.
+func OnlyWarnings(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch e := err.(type) {
+	case *HPSFError:
+		return e.Severity == SEV_WARN
+	case validator.Result:
+		for _, d := range e.Details {
+			if !OnlyWarnings(d) {
+				return false
+			}
+		}
+		return !e.IsEmpty()
+	}
+	return false
+}
+
 func (c *Component) Validate() error {
 	result := validator.NewResult("component validation errors")
 	if c.Name == "" {
-		result.Add(NewError("Name must be set"))
+		result.Add(NewError("Name must be set").WithCode(CODE_NAME_REQUIRED).WithLocation(c.loc))
 	}
 	if c.Kind == "" {
-		result.Add(NewError("Kind must be set").WithComponent(c.Name))
+		result.Add(NewError("Kind must be set").WithCode(CODE_KIND_REQUIRED).WithComponent(c.Name).WithLocation(c.loc))
 	}
 	// base components mentioned in typical configurations don't need to set up
 	// ports, because those come from the templatecomponents, but composite
 	// components might have ports, so we do want to check them if they exist
 	for _, p := range c.Ports {
 		if p.Direction != DIR_INPUT && p.Direction != DIR_OUTPUT {
-			result.Add(NewErrorf("Port %s Direction must be 'Input' or 'Output'", p.Name).WithComponent(c.Name))
+			result.Add(NewErrorf("Port %s Direction must be 'Input' or 'Output'", p.Name).WithCode(CODE_INVALID_PORT_DIRECTION).WithComponent(c.Name).WithLocation(c.loc))
 		}
 	}
 	// any properties specified need to have a value
 	for _, p := range c.Properties {
 		if p.Name == "" {
-			result.Add(NewError("Property Name must be set").WithComponent(c.Name))
+			result.Add(NewError("Property Name must be set").WithCode(CODE_PROPERTY_NAME_REQUIRED).WithComponent(c.Name).WithLocation(p.loc))
 		}
 		if p.Type != "" {
 			if err := p.Type.Validate(); err != nil {
-				result.Add(NewError("Type is invalid").WithComponent(c.Name).WithProperty(p.Name).WithCause(err))
+				result.Add(NewError("Type is invalid").WithCode(CODE_INVALID_PROPERTY_TYPE).WithComponent(c.Name).WithProperty(p.Name).WithCause(err).WithLocation(p.loc))
 			}
 		}
 		if p.Value == nil {
-			result.Add(NewError("Value must be set").WithComponent(c.Name).WithProperty(p.Name))
+			result.Add(NewError("Value must be set").WithCode(CODE_VALUE_REQUIRED).WithComponent(c.Name).WithProperty(p.Name).WithLocation(p.loc))
 			// can't check values after this
 			continue
 		}
@@ -431,28 +603,29 @@ func (c *Component) Validate() error {
 		// we can only support specific types for the values we get from the YAML, so we coerce the values
 		// we have to the types we expect
 		switch p.Value.(type) {
-		case string, int, float64, bool, []any, []string, map[string]any:
+		case string, int, float64, bool, []any, []string, []int, []float64, map[string]any, map[string]string:
 			err := p.Type.ValueCoerce(p.Value, &p.Value)
 			if err != nil {
-				result.Add(NewError("Value error").WithComponent(c.Name).WithProperty(p.Name).WithCause(err))
+				result.Add(NewError("Value error").WithCode(CODE_INVALID_VALUE).WithComponent(c.Name).WithProperty(p.Name).WithCause(err).WithLocation(p.loc))
 			}
 		default:
-			result.Add(NewError("Value must be a string, number, bool, array, or dictionary").WithComponent(c.Name).WithProperty(p.Name))
+			result.Add(NewError("Value must be a string, number, bool, array, or dictionary").WithCode(CODE_INVALID_VALUE).WithComponent(c.Name).WithProperty(p.Name).WithLocation(p.loc))
 		}
 
 		// This is a sanity check; belt and suspenders since the above should have done it right.
 		// This was the first implementation, and we should be able to delete it once we're comfortable.
 		err := p.Type.ValueConforms(p.Value)
 		if err != nil {
-			result.Add(NewError("Value does not conform").WithComponent(c.Name).WithProperty(p.Name).WithCause(err))
+			result.Add(NewError("Value does not conform").WithCode(CODE_INVALID_VALUE).WithComponent(c.Name).WithProperty(p.Name).WithCause(err).WithLocation(p.loc))
 		}
 	}
 	return result.ErrOrNil()
 }
 
+var unsafeNameChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
 func safeName(s string) string {
-	re := regexp.MustCompile(`[^a-zA-Z0-9]+`)
-	return re.ReplaceAllString(s, "_")
+	return unsafeNameChars.ReplaceAllString(s, "_")
 }
 
 // GetSafeName returns the safe name of the component (no spaces or special characters)
@@ -483,6 +656,17 @@ func (c *Component) GetProperty(name string) *Property {
 	return nil
 }
 
+// SetProperty replaces the property with the same name, or adds it if it isn't set.
+func (c *Component) SetProperty(p Property) {
+	for i := range c.Properties {
+		if c.Properties[i].Name == p.Name {
+			c.Properties[i] = p
+			return
+		}
+	}
+	c.Properties = append(c.Properties, p)
+}
+
 // GetPropertyNames returns all specified property names as a slice of strings
 func (c *Component) GetPropertyNames() []string {
 	props := make([]string, len(c.Properties))
@@ -496,6 +680,7 @@ type ConnectionPort struct {
 	Component string         `yaml:"component"`
 	PortName  string         `yaml:"port"`
 	Type      ConnectionType `yaml:"type"`
+	loc       Location
 }
 
 func (cp *ConnectionPort) GetSafeName() string {
@@ -505,20 +690,21 @@ func (cp *ConnectionPort) GetSafeName() string {
 func (cp *ConnectionPort) Validate() error {
 	result := validator.NewResult("connection port validation errors")
 	if cp.Component == "" {
-		result.Add(validator.NewResult("ConnectionPort Component must be set"))
+		result.Add(NewError("ConnectionPort Component must be set").WithCode(CODE_CONNECTION_INCOMPLETE).WithLocation(cp.loc))
 	}
 	if cp.PortName == "" {
-		result.Add(validator.NewResult("ConnectionPort PortName must be set"))
+		result.Add(NewError("ConnectionPort PortName must be set").WithCode(CODE_CONNECTION_INCOMPLETE).WithComponent(cp.Component).WithLocation(cp.loc))
 	}
 	if cp.Type == "" {
-		result.Add(validator.NewResult("ConnectionPort Type must be set"))
+		result.Add(NewError("ConnectionPort Type must be set").WithCode(CODE_CONNECTION_INCOMPLETE).WithComponent(cp.Component).WithLocation(cp.loc))
 	}
-	return result
+	return result.ErrOrNil()
 }
 
 type Connection struct {
 	Source      ConnectionPort `yaml:"source"`
 	Destination ConnectionPort `yaml:"destination"`
+	loc         Location
 }
 
 func (c *Connection) Validate() error {
@@ -527,9 +713,11 @@ func (c *Connection) Validate() error {
 	result.Add(e)
 	e = c.Destination.Validate()
 	result.Add(e)
-	return result
+	return result.ErrOrNil()
 }
 
+// A PublicPort exposes the port of a component inside a container as a port
+// on every instance of that container.
 type PublicPort struct {
 	Name      string `yaml:"name"`
 	Component string `yaml:"component"`
@@ -539,17 +727,21 @@ type PublicPort struct {
 func (pp *PublicPort) Validate() error {
 	result := validator.NewResult("port validation errors")
 	if pp.Name == "" {
-		result.Add(validator.NewResult("PublicPort Name must be set"))
+		result.Add(NewError("PublicPort Name must be set").WithCode(CODE_INVALID_CONTAINER))
 	}
 	if pp.Component == "" {
-		result.Add(validator.NewResult("PublicPort Component must be set"))
+		result.Add(NewError("PublicPort Component must be set").WithCode(CODE_INVALID_CONTAINER))
 	}
 	if pp.Port == "" {
-		result.Add(validator.NewResult("PublicPort Port must be set"))
+		result.Add(NewError("PublicPort Port must be set").WithCode(CODE_INVALID_CONTAINER))
 	}
-	return result
+	return result.ErrOrNil()
 }
 
+// A PublicProp exposes the property of a component inside a container as a
+// property on every instance of that container. Several PublicProps may share
+// a name, in which case setting that property on an instance sets all of the
+// internal properties it names.
 type PublicProp struct {
 	Name      string `yaml:"name"`
 	Component string `yaml:"component"`
@@ -559,38 +751,73 @@ type PublicProp struct {
 func (pp *PublicProp) Validate() error {
 	result := validator.NewResult("prop validation errors")
 	if pp.Name == "" {
-		result.Add(validator.NewResult("PublicProp Name must be set"))
+		result.Add(NewError("PublicProp Name must be set").WithCode(CODE_INVALID_CONTAINER))
 	}
 	if pp.Component == "" {
-		result.Add(validator.NewResult("PublicProp Component must be set"))
+		result.Add(NewError("PublicProp Component must be set").WithCode(CODE_INVALID_CONTAINER))
 	}
 	if pp.Property == "" {
-		result.Add(validator.NewResult("PublicProp Property must be set"))
+		result.Add(NewError("PublicProp Property must be set").WithCode(CODE_INVALID_CONTAINER))
 	}
-	return result
+	return result.ErrOrNil()
 }
 
+// A Container is a reusable subgraph of components and connections. Any
+// component in the document whose Kind is the Name of a container is an
+// instance of it; connections to the instance use the names of the
+// container's public ports, and the instance's properties are the container's
+// public props. Instances are expanded into ordinary components by Flatten.
 type Container struct {
-	Name       string       `yaml:"name"`
-	Components []Component  `yaml:"components,omitempty"`
-	Ports      []PublicPort `yaml:"ports,omitempty"`
-	Props      []PublicProp `yaml:"props,omitempty"`
+	Name        string        `yaml:"name"`
+	Components  []Component   `yaml:"components,omitempty"`
+	Connections []*Connection `yaml:"connections,omitempty"`
+	Ports       []PublicPort  `yaml:"ports,omitempty"`
+	Props       []PublicProp  `yaml:"props,omitempty"`
+}
+
+// getComponent returns the component inside the container with the given name, or nil if not found
+func (c *Container) getComponent(name string) *Component {
+	for i := range c.Components {
+		if c.Components[i].Name == name {
+			return &c.Components[i]
+		}
+	}
+	return nil
 }
 
 func (c *Container) Validate() error {
 	result := validator.NewResult("container validation errors")
 	if c.Name == "" {
-		result.Add(validator.NewResult("Container Name must be set"))
+		result.Add(NewError("Container Name must be set").WithCode(CODE_INVALID_CONTAINER))
+	}
+	for _, comp := range c.Components {
+		result.Add(comp.Validate())
+	}
+	for _, conn := range c.Connections {
+		if c.getComponent(conn.Source.Component) == nil {
+			result.Add(NewErrorf("Connection source component not found in container %s", c.Name).
+				WithCode(CODE_COMPONENT_NOT_FOUND).WithComponent(conn.Source.Component).WithLocation(conn.Source.loc))
+		}
+		if c.getComponent(conn.Destination.Component) == nil {
+			result.Add(NewErrorf("Connection destination component not found in container %s", c.Name).
+				WithCode(CODE_COMPONENT_NOT_FOUND).WithComponent(conn.Destination.Component).WithLocation(conn.Destination.loc))
+		}
 	}
 	for _, p := range c.Ports {
-		e := p.Validate()
-		result.Add(e)
+		result.Add(p.Validate())
+		if p.Component != "" && c.getComponent(p.Component) == nil {
+			result.Add(NewErrorf("PublicPort %s refers to a component not found in container %s", p.Name, c.Name).
+				WithCode(CODE_INVALID_CONTAINER).WithComponent(p.Component))
+		}
 	}
 	for _, p := range c.Props {
-		e := p.Validate()
-		result.Add(e)
+		result.Add(p.Validate())
+		if p.Component != "" && c.getComponent(p.Component) == nil {
+			result.Add(NewErrorf("PublicProp %s refers to a component not found in container %s", p.Name, c.Name).
+				WithCode(CODE_INVALID_CONTAINER).WithComponent(p.Component))
+		}
 	}
-	return result
+	return result.ErrOrNil()
 }
 
 type Pos struct {
@@ -615,6 +842,7 @@ type Layout struct {
 type HPSF struct {
 	Kind           string        `yaml:"kind,omitempty"`
 	Version        string        `yaml:"version,omitempty"`
+	FormatVersion  string        `yaml:"format_version,omitempty"`
 	Name           string        `yaml:"name,omitempty"`
 	Summary        string        `yaml:"summary,omitempty"`
 	Description    string        `yaml:"description,omitempty"`
@@ -623,6 +851,7 @@ type HPSF struct {
 	Connections    []*Connection `yaml:"connections,omitempty"`
 	Containers     []Container   `yaml:"containers,omitempty"`
 	Layout         *Layout       `yaml:"layout,omitempty"`
+	loc            Location
 }
 
 // GetStartComponents generates a list of components that are not named as the destination of a connection
@@ -647,17 +876,14 @@ func (h *HPSF) GetStartComponents() []*Component {
 // GetSourceComponentsFor generates a list of components that are sources of connections but not destinations
 // of connections for a given signal type. This is used to find the start components of a pipeline.
 func (h *HPSF) GetSourceComponentsFor(connType ConnectionType) []*Component {
+	return sourceComponentsFor(NewGraph(h), connType)
+}
+
+func sourceComponentsFor(g *Graph, connType ConnectionType) []*Component {
 	sourceComps := make([]*Component, 0)
-	// make a map of all components that are destinations of connections for the given signal type
-	destinations := make(map[string]bool)
-	for _, conn := range h.Connections {
-		if conn.Source.Type == connType {
-			destinations[conn.Destination.Component] = true
-		}
-	}
-	for _, c := range h.Components {
+	for _, c := range g.Components() {
 		// if the component is a source of a connection and not a destination of a connection, add it to the list
-		if h.isSourceComponent(c, connType) && !destinations[c.Name] {
+		if len(g.OutputsOfType(c.Name, connType)) > 0 && len(g.InputsOfType(c.Name, connType)) == 0 {
 			sourceComps = append(sourceComps, c)
 		}
 	}
@@ -668,24 +894,31 @@ func (h *HPSF) GetSourceComponentsFor(connType ConnectionType) []*Component {
 	return sourceComps
 }
 
-func (h *HPSF) getComponent(name string) *Component {
-	// find the component with the given name
+// UniqueComponentName returns name if no component in the document has the
+// same safe name, and otherwise a variant of name with a number at the end
+// that doesn't collide. Names that already end in a number (like "OTel
+// Receiver 1") have that number incremented.
+func (h *HPSF) UniqueComponentName(name string) string {
+	taken := make(map[string]bool)
 	for _, c := range h.Components {
-		if c.Name == name {
-			return c
+		taken[c.GetSafeName()] = true
+	}
+	if !taken[safeName(name)] {
+		return name
+	}
+	base, n := name, 2
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		if num, err := strconv.Atoi(name[i+1:]); err == nil {
+			base, n = name[:i], num+1
 		}
 	}
-	return nil
-}
-
-func (h *HPSF) isSourceComponent(c *Component, connType ConnectionType) bool {
-	// check if the component is a source of a connection of this signal type
-	for _, conn := range h.Connections {
-		if conn.Source.Component == c.Name && conn.Source.Type == connType {
-			return true
+	for {
+		candidate := fmt.Sprintf("%s %d", base, n)
+		if !taken[safeName(candidate)] {
+			return candidate
 		}
+		n++
 	}
-	return false
 }
 
 // PathWithConnections is designed to hold a linear set of components
@@ -732,11 +965,13 @@ func (h *HPSF) FindAllPaths(_ map[string]bool) []PathWithConnections {
 	var paths []PathWithConnections
 	var path []*Component
 	var connections []*Connection
+	g := NewGraph(h)
 
 	var findPaths func(ConnectionType, *Component, []*Connection)
 	findPaths = func(connType ConnectionType, c *Component, conns []*Connection) {
 		path = append(path, c)
-		if !h.isSourceComponent(c, connType) {
+		outputs := g.OutputsOfType(c.Name, connType)
+		if len(outputs) == 0 {
 			// we reached an end component, create a path
 			path := PathWithConnections{
 				ConnType:    connType,
@@ -747,9 +982,9 @@ func (h *HPSF) FindAllPaths(_ map[string]bool) []PathWithConnections {
 		} else {
 			// for each of these sources, we don't want to visit the same component again,
 			visited := make(map[string]bool)
-			for _, conn := range h.Connections {
-				if conn.Source.Component == c.Name && conn.Source.Type == connType && !visited[conn.Destination.Component] {
-					destComp := h.getComponent(conn.Destination.Component)
+			for _, conn := range outputs {
+				if !visited[conn.Destination.Component] {
+					destComp := g.Component(conn.Destination.Component)
 					visited[conn.Destination.Component] = true // mark as visited
 					if destComp != nil {
 						// Add this connection to our path and continue
@@ -764,7 +999,7 @@ func (h *HPSF) FindAllPaths(_ map[string]bool) []PathWithConnections {
 
 	// start the search from each start component
 	for _, connType := range PipelineConnectionTypes {
-		srcComps := h.GetSourceComponentsFor(connType)
+		srcComps := sourceComponentsFor(g, connType)
 		if len(srcComps) == 0 {
 			continue // no source components for this signal type
 		}
@@ -794,6 +1029,7 @@ func (h *HPSF) VisitComponents(fn func(*Component) error) error {
 		return strings.Compare(a.Name, b.Name)
 	})
 	visited := make(map[string]bool)
+	g := NewGraph(h)
 	// we need the visit function to be recursive, so we define it first
 	var visit func(*Component) error
 	visit = func(c *Component) error {
@@ -811,18 +1047,15 @@ func (h *HPSF) VisitComponents(fn func(*Component) error) error {
 			return fmt.Errorf("error visiting component %s: %w", c.Name, err)
 		}
 		// now visit all connections that have this component as a source
-		for _, conn := range h.Connections {
-			if conn.Source.Component == c.Name {
-				// find the destination component
-				for _, destComp := range h.Components {
-					if destComp.Name == conn.Destination.Component {
-						// visit the destination component
-						err := visit(destComp)
-						if err != nil {
-							return fmt.Errorf("error visiting destination component %s from source %s: %w", destComp.Name, c.Name, err)
-						}
-					}
-				}
+		for _, conn := range g.Outputs(c.Name) {
+			destComp := g.Component(conn.Destination.Component)
+			if destComp == nil {
+				continue
+			}
+			// visit the destination component
+			err := visit(destComp)
+			if err != nil {
+				return fmt.Errorf("error visiting destination component %s from source %s: %w", destComp.Name, c.Name, err)
 			}
 		}
 		return nil
@@ -837,25 +1070,118 @@ func (h *HPSF) VisitComponents(fn func(*Component) error) error {
 	return nil
 }
 
-// use reflect to generate a list of valid yaml tags in a pointer to
-// a struct
-func getValidKeys(p any) []string {
+// use reflect to generate a list of valid yaml tags for a struct type, in field order
+func getValidKeys(t reflect.Type) []string {
 	keys := []string{}
-	v := reflect.ValueOf(p).Elem()
-	for i := range v.NumField() {
-		f := v.Type().Field(i)
-		yamltag := f.Tag.Get("yaml")
-		if yamltag != "" {
-			// ignore any options like "omitempty"
-			if strings.Contains(yamltag, ",") {
-				yamltag = strings.Split(yamltag, ",")[0]
-			}
-			keys = append(keys, yamltag)
+	for i := range t.NumField() {
+		if _, key, ok := yamlField(t, i); ok {
+			keys = append(keys, key)
 		}
 	}
 	return keys
 }
 
+// yamlField returns the field at index i of struct type t along with the key it
+// uses in yaml; ok is false if the field doesn't appear in yaml at all.
+func yamlField(t reflect.Type, i int) (reflect.StructField, string, bool) {
+	f := t.Field(i)
+	yamltag := f.Tag.Get("yaml")
+	// ignore any options like "omitempty"
+	yamltag, _, _ = strings.Cut(yamltag, ",")
+	if yamltag == "" || yamltag == "-" {
+		return f, "", false
+	}
+	return f, yamltag, true
+}
+
+// findUnknownKeys walks a yaml node alongside the Go type it will be decoded
+// into, and reports every mapping key that doesn't correspond to a field of
+// the struct at that position. Type mismatches are left for the decoder to
+// report; we only care about keys here. Fields of type any (like property
+// values) can contain arbitrary keys, so we don't descend into them.
+func findUnknownKeys(node *y.Node, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == y.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	var bad []string
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != y.MappingNode {
+			return nil
+		}
+		fields := make(map[string]reflect.StructField)
+		for i := range t.NumField() {
+			if f, key, ok := yamlField(t, i); ok {
+				fields[key] = f
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keypath := key.Value
+			if path != "" {
+				keypath = path + "." + key.Value
+			}
+			f, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("%s (line %d", keypath, key.Line)
+				if suggestion := suggestKey(key.Value, getValidKeys(t)); suggestion != "" {
+					msg += fmt.Sprintf(", did you mean '%s'?", suggestion)
+				}
+				bad = append(bad, msg+")")
+				continue
+			}
+			bad = append(bad, findUnknownKeys(value, f.Type, keypath)...)
+		}
+	case reflect.Slice:
+		if node.Kind != y.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			bad = append(bad, findUnknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return bad
+}
+
+// suggestKey returns the valid key closest to the unknown one, or the empty
+// string if nothing is close enough to be a plausible typo.
+func suggestKey(key string, valid []string) string {
+	best := ""
+	bestDist := max(2, len(key)/3) + 1
+	for _, v := range valid {
+		if d := editDistance(strings.ToLower(key), v); d < bestDist {
+			best, bestDist = v, d
+		}
+	}
+	return best
+}
+
+// editDistance computes the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
 // unmarshalYAML is a brain-dead validator that just tries to unmarshal the input into a map
 // to validate the input is parseable YAML
 func unmarshalYAML(input []byte) (map[string]any, error) {
@@ -880,15 +1206,12 @@ func EnsureHPSFYAML(input string) error {
 		return errors.New("HPSF yaml is empty")
 	}
 
-	// check to see if it has only expected top-level keys
-	// (it would be interesting to do this recursively someday, but it's a lot)
-	keys := getValidKeys(&HPSF{})
-	badkeys := make([]string, 0)
-	for k := range m {
-		if !slices.Contains(keys, k) {
-			badkeys = append(badkeys, k)
-		}
+	// check to see if it has only expected keys, all the way down
+	var doc y.Node
+	if err := y.Unmarshal([]byte(input), &doc); err != nil {
+		return err
 	}
+	badkeys := findUnknownKeys(doc.Content[0], reflect.TypeOf(HPSF{}), "")
 	if len(badkeys) > 0 {
 		return errors.New("HPSF yaml contains unexpected keys: " + strings.Join(badkeys, ", "))
 	}
@@ -900,6 +1223,85 @@ func EnsureHPSFYAML(input string) error {
 	return h.Validate()
 }
 
+// Clone returns a deep copy of the document, so that the copy can be edited
+// without affecting the original.
+func (h *HPSF) Clone() *HPSF {
+	c := *h
+	c.Components = slices.Clone(h.Components)
+	for i, comp := range c.Components {
+		c.Components[i] = comp.clone()
+	}
+	c.Connections = cloneConnections(h.Connections)
+	c.Containers = slices.Clone(h.Containers)
+	for i, cont := range c.Containers {
+		cont.Components = slices.Clone(cont.Components)
+		for j := range cont.Components {
+			cont.Components[j] = *cont.Components[j].clone()
+		}
+		cont.Connections = cloneConnections(cont.Connections)
+		cont.Ports = slices.Clone(cont.Ports)
+		cont.Props = slices.Clone(cont.Props)
+		c.Containers[i] = cont
+	}
+	if h.Layout != nil {
+		l := Layout{Components: slices.Clone(h.Layout.Components)}
+		for i, lc := range l.Components {
+			if lc.Position != nil {
+				p := *lc.Position
+				lc.Position = &p
+			}
+			if lc.Size != nil {
+				s := *lc.Size
+				lc.Size = &s
+			}
+			l.Components[i] = lc
+		}
+		c.Layout = &l
+	}
+	return &c
+}
+
+func (c *Component) clone() *Component {
+	cc := *c
+	cc.Ports = slices.Clone(c.Ports)
+	cc.Properties = slices.Clone(c.Properties)
+	for i := range cc.Properties {
+		cc.Properties[i].Value = cloneValue(cc.Properties[i].Value)
+	}
+	return &cc
+}
+
+func cloneConnections(conns []*Connection) []*Connection {
+	result := slices.Clone(conns)
+	for i, conn := range result {
+		c := *conn
+		result[i] = &c
+	}
+	return result
+}
+
+// cloneValue copies the slices and maps that can appear in property values
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case []any:
+		result := make([]any, len(v))
+		for i, a := range v {
+			result[i] = cloneValue(a)
+		}
+		return result
+	case []string:
+		return slices.Clone(v)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, a := range v {
+			result[k] = cloneValue(a)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
 func (h *HPSF) AsYAML() (string, error) {
 	// this is a mechanism to marshal the template to YAML
 	data, err := y.Marshal(h)
@@ -915,3 +1317,35 @@ func FromYAML(in string) (HPSF, error) {
 	err := dec.Decode(&h)
 	return h, err
 }
+
+// AsJSON returns the document as JSON, using the same field names as the YAML
+// form. The structure is described by the schema returned by JSONSchema.
+func (h *HPSF) AsJSON() (string, error) {
+	// going by way of YAML means the JSON uses the yaml tags and omitempty
+	// rules, so there's only one definition of the format to maintain
+	data, err := y.Marshal(h)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling hpsf to JSON: %w", err)
+	}
+	var v any
+	if err := y.Unmarshal(data, &v); err != nil {
+		return "", fmt.Errorf("error marshaling hpsf to JSON: %w", err)
+	}
+	data, err = json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling hpsf to JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// FromJSON parses an HPSF document from JSON. Since JSON is a subset of YAML,
+// the result is exactly what FromYAML would produce for the same input,
+// including source locations.
+func FromJSON(in string) (HPSF, error) {
+	// make sure it really is JSON, and get a JSON-flavored error if it isn't
+	var v any
+	if err := json.Unmarshal([]byte(in), &v); err != nil {
+		return HPSF{}, fmt.Errorf("error parsing hpsf JSON: %w", err)
+	}
+	return FromYAML(in)
+}
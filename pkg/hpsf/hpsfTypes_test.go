@@ -38,6 +38,123 @@ func TestEnsureHPSF(t *testing.T) {
 	}
 }
 
+func TestEnsureHPSF_NestedKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			input: `components:
+  - name: a
+    kind: OTelReceiver
+    properties:
+      - name: GRPCPort
+        value:
+          anything: goes
+layout:
+  components:
+    - name: a
+      position:
+        x: 1
+        y: 2
+`,
+		},
+		{
+			name: "misspelled component key",
+			input: `components:
+  - name: a
+    kind: OTelReceiver
+    propertys:
+      - name: GRPCPort
+        value: 1
+`,
+			wantErr: []string{"components[0].propertys (line 4, did you mean 'properties'?)"},
+		},
+		{
+			name: "misspelled connection keys",
+			input: `components:
+  - name: a
+    kind: OTelReceiver
+connections:
+  - source:
+      component: a
+      port: Traces
+      type: OTelTraces
+    destinaton:
+      component: a
+      prot: Traces
+      type: OTelTraces
+`,
+			wantErr: []string{"connections[0].destinaton (line 9, did you mean 'destination'?)"},
+		},
+		{
+			name: "deeply nested keys",
+			input: `components:
+  - name: a
+    kind: OTelReceiver
+    properties:
+      - nmae: GRPCPort
+        value: 1
+connections:
+  - source:
+      component: a
+      prot: Traces
+      type: OTelTraces
+containers:
+  - name: c
+    props:
+      - name: p
+        component: a
+        proprety: GRPCPort
+layout:
+  components:
+    - name: a
+      sise:
+        w: 1
+        h: 2
+`,
+			wantErr: []string{
+				"components[0].properties[0].nmae (line 5, did you mean 'name'?)",
+				"connections[0].source.prot (line 10, did you mean 'port'?)",
+				"containers[0].props[0].proprety (line 17, did you mean 'property'?)",
+				"layout.components[0].sise (line 21, did you mean 'size'?)",
+			},
+		},
+		{
+			name: "no suggestion when nothing is close",
+			input: `components:
+  - name: a
+    kind: OTelReceiver
+    xyzzy: 1
+`,
+			wantErr: []string{"components[0].xyzzy (line 4)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := EnsureHPSFYAML(tt.input)
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "unexpected keys")
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("name", "name"))
+	assert.Equal(t, 1, editDistance("destinaton", "destination"))
+	assert.Equal(t, 2, editDistance("propertys", "properties"))
+	assert.Equal(t, 4, editDistance("", "kind"))
+}
+
 func TestHPSF_Validate(t *testing.T) {
 	inputData := []byte(`components:
   - name: otlp_in
@@ -123,6 +240,23 @@ connections:
 	assert.Contains(t, result.Details[1].Error(), "otlp_in2")
 }
 
+func TestOnlyWarnings(t *testing.T) {
+	warnings := validator.NewResult("warnings")
+	warnings.Add(NewWarning("a"))
+	warnings.Add(NewWarningf("b %d", 2))
+	mixed := validator.NewResult("mixed")
+	mixed.Add(warnings)
+	mixed.Add(NewError("c"))
+
+	assert.False(t, OnlyWarnings(nil))
+	assert.True(t, OnlyWarnings(NewWarning("a")))
+	assert.False(t, OnlyWarnings(NewError("a")))
+	assert.False(t, OnlyWarnings(fmt.Errorf("a")))
+	assert.True(t, OnlyWarnings(warnings))
+	assert.False(t, OnlyWarnings(mixed))
+	assert.False(t, OnlyWarnings(validator.NewResult("empty")))
+}
+
 func TestComponent_GetSafeName(t *testing.T) {
 	tests := []struct {
 		name string
@@ -145,6 +279,52 @@ func TestComponent_GetSafeName(t *testing.T) {
 	}
 }
 
+func TestHPSF_UniqueComponentName(t *testing.T) {
+	h := &HPSF{Components: []*Component{
+		{Name: "OTel Receiver 1"},
+		{Name: "OTel Receiver 2"},
+		{Name: "Debug Exporter"},
+	}}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Honeycomb Exporter 1", "Honeycomb Exporter 1"},
+		{"OTel Receiver 1", "OTel Receiver 3"},
+		{"OTel_Receiver_2", "OTel_Receiver_2 2"},
+		{"Debug Exporter", "Debug Exporter 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, h.UniqueComponentName(tt.name))
+		})
+	}
+}
+
+func TestHPSF_Clone(t *testing.T) {
+	h, err := FromYAML(containerTestYAML)
+	require.NoError(t, err)
+	h.Components[1].Properties = append(h.Components[1].Properties, Property{Name: "List", Value: []any{"a"}})
+	h.Layout = &Layout{Components: []LayoutComponent{{Name: "Receiver", Position: &Pos{X: 1, Y: 2}}}}
+
+	c := h.Clone()
+	require.Equal(t, &h, c)
+
+	c.Components[0].Name = "changed"
+	c.Components[1].Properties[0].Value = "changed"
+	c.Components[1].Properties[1].Value.([]any)[0] = "changed"
+	c.Connections[0].Source.Component = "changed"
+	c.Containers[0].Components[1].Properties[0].Value = "changed"
+	c.Layout.Components[0].Position.X = 100
+
+	assert.Equal(t, "Receiver", h.Components[0].Name)
+	assert.Equal(t, "abc123", h.Components[1].Properties[0].Value)
+	assert.Equal(t, []any{"a"}, h.Components[1].Properties[1].Value)
+	assert.Equal(t, "Receiver", h.Connections[0].Source.Component)
+	assert.Equal(t, "default", h.Containers[0].Components[1].Properties[0].Value)
+	assert.Equal(t, 1, h.Layout.Components[0].Position.X)
+}
+
 func TestPropType_ValueCoerce(t *testing.T) {
 	var result any
 	tests := []struct {
@@ -203,6 +383,24 @@ func TestPropType_ValueCoerce(t *testing.T) {
 		{PTYPE_CHECK, []any{123, "item2"}, []string{"123", "item2"}, false},
 		{PTYPE_CHECK, "not a slice", nil, true},
 		{PTYPE_CHECK, []string{}, []string{}, false},
+		{PTYPE_DUR, "30s", "30s", false},
+		{PTYPE_DUR, "60s", "1m", false},
+		{PTYPE_DUR, 90, "1m30s", false},
+		{PTYPE_DUR, "0.5", "500ms", false},
+		{PTYPE_DUR, "soon", nil, true},
+		{PTYPE_DUR, true, nil, true},
+		{PTYPE_ARRINT, []int{1, 2}, []int{1, 2}, false},
+		{PTYPE_ARRINT, []any{1, "2", 3.0}, []int{1, 2, 3}, false},
+		{PTYPE_ARRINT, []string{"4", "5"}, []int{4, 5}, false},
+		{PTYPE_ARRINT, []any{1, "two"}, nil, true},
+		{PTYPE_ARRINT, "1,2", nil, true},
+		{PTYPE_ARRFLOAT, []float64{0.5}, []float64{0.5}, false},
+		{PTYPE_ARRFLOAT, []any{1, "2.5"}, []float64{1, 2.5}, false},
+		{PTYPE_ARRFLOAT, []any{true}, nil, true},
+		{PTYPE_STRMAP, map[string]string{"a": "b"}, map[string]string{"a": "b"}, false},
+		{PTYPE_STRMAP, map[string]any{"a": "b", "n": 1}, map[string]string{"a": "b", "n": "1"}, false},
+		{PTYPE_STRMAP, map[string]any{"a": []any{"b"}}, nil, true},
+		{PTYPE_STRMAP, []string{"a"}, nil, true},
 	}
 	for _, tt := range tests {
 		name := fmt.Sprintf("%s_%#v", tt.p, tt.v)
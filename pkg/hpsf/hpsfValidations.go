@@ -2,12 +2,14 @@
 package hpsf
 
 import (
-	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/honeycombio/hpsf/pkg/validator"
 )
 
-// validateNames checks that all component names are unique.
+// validateNames checks that all component names and all container names are unique.
 func (h *HPSF) validateNames() error {
 	result := validator.NewResult("hpsf name validation errors")
 
@@ -15,12 +17,23 @@ func (h *HPSF) validateNames() error {
 	nameSet := make(map[string]struct{})
 	for _, c := range h.Components {
 		if _, exists := nameSet[c.GetSafeName()]; exists {
-			result.Add(NewError("duplicate component name").WithComponent(c.Name))
+			result.Add(NewError("duplicate component name").WithCode(CODE_DUPLICATE_NAME).WithComponent(c.Name).WithLocation(c.loc))
 		} else {
 			nameSet[c.GetSafeName()] = struct{}{}
 		}
 	}
 
+	// instances find their container by name, so only the first of two
+	// containers with the same name could ever be used
+	containerSet := make(map[string]struct{})
+	for _, c := range h.Containers {
+		if _, exists := containerSet[c.Name]; exists {
+			result.Add(NewErrorf("duplicate container name %s", c.Name).WithCode(CODE_INVALID_CONTAINER))
+		} else {
+			containerSet[c.Name] = struct{}{}
+		}
+	}
+
 	return result.ErrOrNil()
 }
 
@@ -29,18 +42,168 @@ func (h *HPSF) validateNames() error {
 // after instantiating the real components.
 func (h *HPSF) validateConnectionSources() error {
 	result := validator.NewResult("hpsf connection source validation errors")
+	g := NewGraph(h)
 	for _, c := range h.Connections {
-		src := h.getComponent(c.Source.Component)
+		src := g.Component(c.Source.Component)
 		if src == nil {
-			result.Add(NewError("Connection source component not found").WithComponent(c.Source.Component))
+			result.Add(NewError("Connection source component not found").WithCode(CODE_COMPONENT_NOT_FOUND).WithComponent(c.Source.Component).WithLocation(c.Source.loc))
 		}
 
-		dst := h.getComponent(c.Destination.Component)
+		dst := g.Component(c.Destination.Component)
 		if dst == nil {
-			result.Add(NewError("Connection destination component not found").WithComponent(c.Destination.Component))
+			result.Add(NewError("Connection destination component not found").WithCode(CODE_COMPONENT_NOT_FOUND).WithComponent(c.Destination.Component).WithLocation(c.Destination.loc))
+		}
+	}
+
+	return result.ErrOrNil()
+}
+
+// maxCycles limits the number of loops FindCycles reports; a densely
+// connected group of components can have more loops than anyone could read.
+const maxCycles = 100
+
+// FindCycles returns the loops in the connection graph: every elementary
+// cycle, which passes through each component at most once, up to maxCycles of
+// them. Each loop holds its connections in order, starting at whichever of its
+// components comes first in the document, and the loops are ordered by that
+// component and then by the order of the connections. Connections to
+// components that don't exist are ignored.
+func (h *HPSF) FindCycles() [][]*Connection {
+	// outgoing connections for each component, in document order
+	out := make(map[string][]*Connection)
+	g := NewGraph(h)
+	for _, conn := range h.Connections {
+		if g.Component(conn.Source.Component) == nil || g.Component(conn.Destination.Component) == nil {
+			continue
+		}
+		out[conn.Source.Component] = append(out[conn.Source.Component], conn)
+	}
+
+	// Tarjan's algorithm finds the groups of components that can all reach
+	// each other (the strongly connected components); every loop stays within
+	// one of them
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	group := make(map[string]int)
+	groups := 0
+	var strongConnect func(name string)
+	strongConnect = func(name string) {
+		index[name] = len(index)
+		lowlink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+		for _, conn := range out[name] {
+			next := conn.Destination.Component
+			if _, visited := index[next]; !visited {
+				strongConnect(next)
+				lowlink[name] = min(lowlink[name], lowlink[next])
+			} else if onStack[next] {
+				lowlink[name] = min(lowlink[name], index[next])
+			}
+		}
+		if lowlink[name] == index[name] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				group[top] = groups
+				if top == name {
+					break
+				}
+			}
+			groups++
 		}
 	}
+	for _, c := range h.Components {
+		if _, visited := index[c.Name]; !visited {
+			strongConnect(c.Name)
+		}
+	}
+
+	// Johnson's algorithm: the loops that start at each component only pass
+	// through the components of its group that come after it
+	position := make(map[string]int)
+	for i, c := range h.Components {
+		position[c.Name] = i
+	}
+	var cycles [][]*Connection
+	for _, c := range h.Components {
+		if len(cycles) >= maxCycles {
+			break
+		}
+		start := c.Name
+		allowed := func(name string) bool {
+			return group[name] == group[start] && position[name] >= position[start]
+		}
+		blocked := make(map[string]bool)
+		blockedBy := make(map[string][]string)
+		var unblock func(name string)
+		unblock = func(name string) {
+			blocked[name] = false
+			waiting := blockedBy[name]
+			blockedBy[name] = nil
+			for _, w := range waiting {
+				if blocked[w] {
+					unblock(w)
+				}
+			}
+		}
+		var path []*Connection
+		var circuit func(name string) bool
+		circuit = func(name string) bool {
+			found := false
+			blocked[name] = true
+			for _, conn := range out[name] {
+				if len(cycles) >= maxCycles {
+					return true
+				}
+				next := conn.Destination.Component
+				if !allowed(next) {
+					continue
+				}
+				if next == start {
+					cycles = append(cycles, append(slices.Clone(path), conn))
+					found = true
+				} else if !blocked[next] {
+					path = append(path, conn)
+					if circuit(next) {
+						found = true
+					}
+					path = path[:len(path)-1]
+				}
+			}
+			if found {
+				unblock(name)
+			} else {
+				for _, conn := range out[name] {
+					next := conn.Destination.Component
+					if allowed(next) && !slices.Contains(blockedBy[next], name) {
+						blockedBy[next] = append(blockedBy[next], name)
+					}
+				}
+			}
+			return found
+		}
+		circuit(start)
+	}
+	return cycles
+}
 
+// validateCycles reports every loop in the connection graph (see FindCycles),
+// since data can't flow around a loop.
+func (h *HPSF) validateCycles() error {
+	result := validator.NewResult("hpsf cycle validation errors")
+	for _, cycle := range h.FindCycles() {
+		steps := make([]string, len(cycle))
+		for i, conn := range cycle {
+			steps[i] = fmt.Sprintf("%s.%s -> %s.%s", conn.Source.Component, conn.Source.PortName,
+				conn.Destination.Component, conn.Destination.PortName)
+		}
+		result.Add(NewErrorf("cycle detected: %s", strings.Join(steps, ", ")).
+			WithCode(CODE_CYCLE).WithComponent(cycle[0].Source.Component).WithLocation(cycle[0].loc))
+	}
 	return result.ErrOrNil()
 }
 
@@ -54,7 +217,7 @@ func (h *HPSF) Validate() error {
 
 	// if the HPSF is empty, it's invalid
 	if len(h.Components) == 0 && len(h.Containers) == 0 {
-		result.Add(errors.New("empty HPSF is not valid"))
+		result.Add(NewError("empty HPSF is not valid").WithCode(CODE_EMPTY_DOCUMENT).WithLocation(h.loc))
 	}
 
 	for _, c := range h.Components {
@@ -73,5 +236,11 @@ func (h *HPSF) Validate() error {
 	result.Add(h.validateConnectionSources())
 	result.Add(h.validateNames())
 
+	// loops are found in terms of the real components, so expand containers
+	// first; if that fails, the problem is reported by the translator
+	if flat, err := h.Flatten(); err == nil {
+		result.Add(flat.validateCycles())
+	}
+
 	return result.ErrOrNil()
 }
@@ -0,0 +1,208 @@
+package hpsf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cycleTestYAML builds a document with the given components (all of the same
+// kind) and connections, each written as "A.Out->B.In".
+func cycleTestYAML(components []string, connections ...string) string {
+	var sb strings.Builder
+	sb.WriteString("components:\n")
+	for _, c := range components {
+		fmt.Fprintf(&sb, "  - name: %s\n    kind: Thing\n", c)
+	}
+	sb.WriteString("connections:\n")
+	for _, conn := range connections {
+		src, dst, _ := strings.Cut(conn, "->")
+		srcComp, srcPort, _ := strings.Cut(src, ".")
+		dstComp, dstPort, _ := strings.Cut(dst, ".")
+		fmt.Fprintf(&sb, "  - source: {component: %s, port: %s, type: OTelTraces}\n", srcComp, srcPort)
+		fmt.Fprintf(&sb, "    destination: {component: %s, port: %s, type: OTelTraces}\n", dstComp, dstPort)
+	}
+	return sb.String()
+}
+
+func cycleStrings(cycles [][]*Connection) []string {
+	result := []string{}
+	for _, cycle := range cycles {
+		steps := []string{}
+		for _, conn := range cycle {
+			steps = append(steps, conn.Source.Component+"."+conn.Source.PortName+"->"+
+				conn.Destination.Component+"."+conn.Destination.PortName)
+		}
+		result = append(result, strings.Join(steps, " "))
+	}
+	return result
+}
+
+func TestHPSF_FindCycles(t *testing.T) {
+	tests := []struct {
+		name        string
+		components  []string
+		connections []string
+		want        []string
+	}{
+		{
+			name:        "no cycles",
+			components:  []string{"R", "A", "B"},
+			connections: []string{"R.Out->A.In", "A.Out->B.In", "R.Out->B.In"},
+			want:        []string{},
+		},
+		{
+			name:        "loop hanging off a receiver",
+			components:  []string{"R", "A", "B", "C"},
+			connections: []string{"R.Out->A.In", "A.Out->B.In", "B.Out->C.In", "C.Back->A.In"},
+			want:        []string{"A.Out->B.In B.Out->C.In C.Back->A.In"},
+		},
+		{
+			name:        "self loop",
+			components:  []string{"R", "A"},
+			connections: []string{"R.Out->A.In", "A.Out->A.In"},
+			want:        []string{"A.Out->A.In"},
+		},
+		{
+			name:       "separate loops are reported separately",
+			components: []string{"R", "A", "B", "C", "D"},
+			connections: []string{
+				"R.Out->A.In", "A.Out->B.In", "B.Out->A.In",
+				"R.Out->C.In", "C.Out->D.In", "D.Out->C.In",
+			},
+			want: []string{"A.Out->B.In B.Out->A.In", "C.Out->D.In D.Out->C.In"},
+		},
+		{
+			name:        "loops that share connections are all reported",
+			components:  []string{"A", "B", "C"},
+			connections: []string{"A.Out->B.In", "B.Out->C.In", "C.Out->A.In", "B.Out->A.In"},
+			want:        []string{"A.Out->B.In B.Out->C.In C.Out->A.In", "A.Out->B.In B.Out->A.In"},
+		},
+		{
+			name:       "two loops through the same component",
+			components: []string{"R", "B", "A", "C"},
+			connections: []string{
+				"R.Out->A.In", "A.Out->B.In", "B.Out->A.In", "A.Out->C.In", "C.Out->A.In",
+			},
+			want: []string{"B.Out->A.In A.Out->B.In", "A.Out->C.In C.Out->A.In"},
+		},
+		{
+			name:        "parallel connections make separate loops",
+			components:  []string{"A", "B"},
+			connections: []string{"A.Out->B.In", "A.Other->B.In", "B.Out->A.In"},
+			want:        []string{"A.Out->B.In B.Out->A.In", "A.Other->B.In B.Out->A.In"},
+		},
+		{
+			name:        "missing components are ignored",
+			components:  []string{"A"},
+			connections: []string{"A.Out->X.In", "X.Out->A.In"},
+			want:        []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := FromYAML(cycleTestYAML(tt.components, tt.connections...))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cycleStrings(h.FindCycles()))
+		})
+	}
+}
+
+func TestHPSF_FindCyclesIsLimited(t *testing.T) {
+	// every pair of components is connected both ways, which makes thousands of loops
+	names := []string{"A", "B", "C", "D", "E", "F", "G"}
+	var conns []string
+	for _, a := range names {
+		for _, b := range names {
+			if a != b {
+				conns = append(conns, a+".Out->"+b+".In")
+			}
+		}
+	}
+	h, err := FromYAML(cycleTestYAML(names, conns...))
+	require.NoError(t, err)
+	assert.Len(t, h.FindCycles(), maxCycles)
+}
+
+func TestHPSF_ValidateReportsCycles(t *testing.T) {
+	h, err := FromNamedYAML("loop.yaml", cycleTestYAML([]string{"R", "A", "B"},
+		"R.Out->A.In", "A.Out->B.In", "B.Out->A.In"))
+	require.NoError(t, err)
+
+	err = h.Validate()
+	require.Error(t, err)
+	result, ok := err.(validator.Result)
+	require.True(t, ok)
+	require.Equal(t, 1, result.Len())
+	assert.Equal(t, "E: cycle detected: A.Out -> B.In, B.Out -> A.In Component: A Location: loop.yaml:11:5",
+		result.Details[0].Error())
+}
+
+func TestHPSF_ValidateReportsCyclesThroughContainers(t *testing.T) {
+	// the loop only exists once the container is expanded
+	h, err := FromYAML(`components:
+  - name: A
+    kind: Thing
+  - name: Box
+    kind: Passthrough
+connections:
+  - source: {component: A, port: Out, type: OTelTraces}
+    destination: {component: Box, port: In, type: OTelTraces}
+  - source: {component: Box, port: Out, type: OTelTraces}
+    destination: {component: A, port: In, type: OTelTraces}
+containers:
+  - name: Passthrough
+    components:
+      - name: P
+        kind: Thing
+    ports:
+      - name: In
+        component: P
+        port: In
+      - name: Out
+        component: P
+        port: Out
+`)
+	require.NoError(t, err)
+	err = h.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.(validator.Result).Unwrap().Error(), "cycle detected: A.Out -> Box/P.In, Box/P.Out -> A.In")
+}
+
+func TestHPSF_ValidateReportsIncompleteConnections(t *testing.T) {
+	h, err := FromNamedYAML("untyped.yaml", `components:
+  - name: A
+    kind: Thing
+  - name: B
+    kind: Thing
+connections:
+  - source: {component: A, port: Out}
+    destination: {component: B, port: In, type: OTelTraces}
+`)
+	require.NoError(t, err)
+
+	err = h.Validate()
+	require.Error(t, err)
+	report := NewReport(err)
+	require.Len(t, report.Problems, 1)
+	assert.Equal(t, CODE_CONNECTION_INCOMPLETE, report.Problems[0].Code)
+	assert.Equal(t, "ConnectionPort Type must be set", report.Problems[0].Message)
+	assert.Equal(t, "A", report.Problems[0].Component)
+	assert.Equal(t, &Location{File: "untyped.yaml", Line: 7, Column: 13}, report.Problems[0].Location)
+}
+
+func TestHPSF_ValidateReportsEmptyDocument(t *testing.T) {
+	h, err := FromNamedYAML("empty.yaml", "kind: hpsf\n")
+	require.NoError(t, err)
+
+	err = h.Validate()
+	require.Error(t, err)
+	report := NewReport(err)
+	require.Len(t, report.Problems, 1)
+	assert.Equal(t, CODE_EMPTY_DOCUMENT, report.Problems[0].Code)
+	assert.Equal(t, &Location{File: "empty.yaml", Line: 1, Column: 1}, report.Problems[0].Location)
+}
@@ -33,6 +33,7 @@ func NewLayouter() (*Layouter, error) {
 // Returns an error if a component template is not found.
 func (l *Layouter) buildLayoutGraph(h *hpsf.HPSF, nodeSize hpsf.NodeSize) (*Graph, error) {
 	g := &Graph{}
+	hg := hpsf.NewGraph(h)
 
 	// Create nodes for each component
 	nodeMap := make(map[string]*Node)
@@ -43,7 +44,7 @@ func (l *Layouter) buildLayoutGraph(h *hpsf.HPSF, nodeSize hpsf.NodeSize) (*Grap
 	}
 	componentPortMaps := make(map[string]*portMaps)
 
-	for _, comp := range h.Components {
+	for _, comp := range hg.Components() {
 		// Look up the template for this component (keyed by Kind only)
 		template, ok := l.templates[comp.Kind]
 		if !ok {
@@ -103,15 +104,14 @@ func (l *Layouter) buildLayoutGraph(h *hpsf.HPSF, nodeSize hpsf.NodeSize) (*Grap
 	}
 
 	// Create edges for each connection using the port name -> index maps
-	for _, conn := range h.Connections {
-		sourceNode := nodeMap[conn.Source.Component]
-		destNode := nodeMap[conn.Destination.Component]
-
-		if sourceNode == nil || destNode == nil {
+	for _, conn := range hg.Connections() {
+		if hg.Component(conn.Source.Component) == nil || hg.Component(conn.Destination.Component) == nil {
 			return nil, fmt.Errorf("connection references unknown component: %s -> %s",
 				conn.Source.Component, conn.Destination.Component)
 		}
 
+		sourceNode := nodeMap[conn.Source.Component]
+		destNode := nodeMap[conn.Destination.Component]
 		sourcePorts := componentPortMaps[conn.Source.Component]
 		destPorts := componentPortMaps[conn.Destination.Component]
 
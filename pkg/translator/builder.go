@@ -0,0 +1,236 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+)
+
+// A Builder constructs an HPSF document in Go code, checking each step
+// against the translator's installed components, so that a misspelled kind,
+// port, or property is reported where it's written rather than when the
+// document is translated. For example:
+//
+//	b := t.NewBuilder("My Workflow")
+//	receiver := b.Add("OTelReceiver")
+//	exporter := b.Add("HoneycombExporter").Set("Dataset", "my-dataset")
+//	receiver.Connect("Traces", exporter).Connect("Logs", exporter)
+//	h, err := b.Build()
+//
+// The first error stops the builder: later calls do nothing, and Build and
+// Err return that error. The builder only checks the steps themselves; use
+// ValidateConfig to check the finished document as a whole.
+type Builder struct {
+	t   *Translator
+	h   *hpsf.HPSF
+	err error
+}
+
+// A BuilderComponent is a component that was added to a Builder's document.
+// Its methods return the component, so calls can be chained.
+type BuilderComponent struct {
+	b  *Builder
+	c  *hpsf.Component
+	tc config.TemplateComponent
+}
+
+// NewBuilder starts building an empty document with the given name, in the
+// current version of the format. The document has no version of its own
+// until one is set with Version.
+func (t *Translator) NewBuilder(name string) *Builder {
+	return &Builder{
+		t: t,
+		h: &hpsf.HPSF{Kind: "HPSF", FormatVersion: hpsf.FormatVersion, Name: name},
+	}
+}
+
+// Version sets the version of the document being built.
+func (b *Builder) Version(version string) *Builder {
+	b.h.Version = version
+	return b
+}
+
+// Document returns the document being built, so that fields like Summary and
+// Description can be filled in.
+func (b *Builder) Document() *hpsf.HPSF {
+	return b.h
+}
+
+// Err returns the first error the builder ran into, or nil.
+func (b *Builder) Err() error {
+	return b.err
+}
+
+// Build returns the finished document, or the first error the builder ran into.
+func (b *Builder) Build() (*hpsf.HPSF, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.h, nil
+}
+
+// Add adds a component of the given kind, named after its template (see
+// UniqueComponentName). It's an error if the kind isn't installed.
+func (b *Builder) Add(kind string) *BuilderComponent {
+	bc := &BuilderComponent{b: b}
+	if b.err != nil {
+		return bc
+	}
+	tc, ok := b.t.components[kind]
+	if !ok {
+		b.err = fmt.Errorf("unknown component kind: %s", kind)
+		return bc
+	}
+	name := tc.Name
+	if name == "" {
+		name = kind
+	}
+	bc.c = &hpsf.Component{Name: b.h.UniqueComponentName(name), Kind: kind}
+	bc.tc = tc
+	b.h.Components = append(b.h.Components, bc.c)
+	return bc
+}
+
+// Name returns the name of the component, or "" if it couldn't be added.
+func (bc *BuilderComponent) Name() string {
+	if bc.c == nil {
+		return ""
+	}
+	return bc.c.Name
+}
+
+// Component returns the component in the document, or nil if it couldn't be added.
+func (bc *BuilderComponent) Component() *hpsf.Component {
+	return bc.c
+}
+
+// Named renames the component (see hpsf.HPSF.RenameComponent). It's an error
+// if another component already has the same safe name.
+func (bc *BuilderComponent) Named(name string) *BuilderComponent {
+	if bc.b.err != nil {
+		return bc
+	}
+	bc.b.err = bc.b.h.RenameComponent(bc.c.Name, name)
+	return bc
+}
+
+// Set sets a property of the component. It's an error if the component's
+// template doesn't have the property, or if the value isn't valid for it.
+// The value is stored as given.
+func (bc *BuilderComponent) Set(property string, value any) *BuilderComponent {
+	if bc.b.err != nil {
+		return bc
+	}
+	bc.b.err = bc.TrySet(property, value)
+	return bc
+}
+
+// TrySet sets a property of the component like Set, but if the property can't
+// be set, it returns the error and leaves the property unset instead of
+// stopping the builder. It's meant for values that come from outside the
+// program, which the caller can report and skip. It does nothing if the
+// builder has already stopped.
+func (bc *BuilderComponent) TrySet(property string, value any) error {
+	if bc.b.err != nil {
+		return nil
+	}
+	tp, ok := bc.tc.Props()[property]
+	if !ok {
+		return fmt.Errorf("%s (%s) doesn't have a property called %s", bc.c.Name, bc.c.Kind, property)
+	}
+	if err := tp.Validate(hpsf.Property{Name: property, Value: value, Type: tp.Type}); err != nil {
+		return fmt.Errorf("%s (%s) can't set property %s to %v: %w", bc.c.Name, bc.c.Kind, property, value, err)
+	}
+	bc.c.SetProperty(hpsf.Property{Name: property, Value: value})
+	return nil
+}
+
+// Connect connects an output port of the component to the input port of dest
+// that carries the same type of data. If dest has more than one such port,
+// the one with the same name as the output is used; ConnectPorts can name
+// the input port instead. It's an error if the component has no output port
+// with that name, or dest has no matching input port.
+func (bc *BuilderComponent) Connect(output string, dest *BuilderComponent) *BuilderComponent {
+	if bc.b.err != nil || dest.b.err != nil {
+		return bc
+	}
+	src, err := bc.port(output, "output")
+	if err != nil {
+		bc.b.err = err
+		return bc
+	}
+	input, err := dest.inputFor(src)
+	if err != nil {
+		bc.b.err = err
+		return bc
+	}
+	return bc.ConnectPorts(output, dest, input)
+}
+
+// ConnectPorts connects an output port of the component to an input port of
+// dest. It's an error if either port doesn't exist, or if they carry
+// different types of data.
+func (bc *BuilderComponent) ConnectPorts(output string, dest *BuilderComponent, input string) *BuilderComponent {
+	if bc.b.err != nil || dest.b.err != nil {
+		return bc
+	}
+	if dest.b != bc.b {
+		bc.b.err = fmt.Errorf("%s can't be connected to %s because it was added to a different builder", bc.c.Name, dest.c.Name)
+		return bc
+	}
+	src, err := bc.port(output, "output")
+	if err != nil {
+		bc.b.err = err
+		return bc
+	}
+	dst, err := dest.port(input, "input")
+	if err != nil {
+		bc.b.err = err
+		return bc
+	}
+	if src.Type != dst.Type {
+		bc.b.err = fmt.Errorf("%s port %s sends %s data but %s port %s expects %s data",
+			bc.c.Name, src.Name, src.Type, dest.c.Name, dst.Name, dst.Type)
+		return bc
+	}
+	bc.b.h.Connections = append(bc.b.h.Connections, &hpsf.Connection{
+		Source:      hpsf.ConnectionPort{Component: bc.c.Name, PortName: src.Name, Type: src.Type},
+		Destination: hpsf.ConnectionPort{Component: dest.c.Name, PortName: dst.Name, Type: dst.Type},
+	})
+	return bc
+}
+
+// port finds a port of the component's template in the given direction.
+func (bc *BuilderComponent) port(name, direction string) (*config.TemplatePort, error) {
+	for i := range bc.tc.Ports {
+		if bc.tc.Ports[i].Name == name && bc.tc.Ports[i].Direction == direction {
+			return &bc.tc.Ports[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s (%s) doesn't have an %s port called %s", bc.c.Name, bc.c.Kind, direction, name)
+}
+
+// inputFor chooses the input port of the component that an output port
+// should be connected to.
+func (bc *BuilderComponent) inputFor(src *config.TemplatePort) (string, error) {
+	var candidates []string
+	for _, p := range bc.tc.Ports {
+		if p.Direction != "input" || p.Type != src.Type {
+			continue
+		}
+		if p.Name == src.Name {
+			return p.Name, nil
+		}
+		candidates = append(candidates, p.Name)
+	}
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("%s (%s) doesn't have an input for %s data", bc.c.Name, bc.c.Kind, src.Type)
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", fmt.Errorf("%s (%s) has more than one input for %s data; use ConnectPorts to choose one",
+			bc.c.Name, bc.c.Kind, src.Type)
+	}
+}
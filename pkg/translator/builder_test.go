@@ -0,0 +1,205 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connectionStrings(h *hpsf.HPSF) []string {
+	var conns []string
+	for _, c := range h.Connections {
+		conns = append(conns, c.Source.Component+"."+c.Source.PortName+" -> "+
+			c.Destination.Component+"."+c.Destination.PortName+" ("+string(c.Source.Type)+")")
+	}
+	return conns
+}
+
+func TestBuilder(t *testing.T) {
+	trans := embeddedTranslator(t)
+
+	b := trans.NewBuilder("Sampled traces")
+	receiver := b.Add("OTelReceiver")
+	start := b.Add("SamplingSequencer").Named("Start Sampling")
+	exporter := b.Add("HoneycombExporter")
+	receiver.Connect("Traces", start).Connect("Metrics", exporter)
+
+	errors := b.Add("FieldExistsCondition").Named("Errors").
+		Set("Field", "error").
+		Set("Operator", "exists")
+	start.Connect("Rule 1", errors)
+	errors.Connect("And", b.Add("DeterministicSampler").Named("Keep Errors").Set("SampleRate", 1))
+	start.Connect("Rule 2", b.Add("DeterministicSampler").Named("Everything Else").Set("SampleRate", 100))
+	b.Add("DeterministicSampler") // a third one, named after its template
+
+	h, err := b.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "Sampled traces", h.Name)
+	assert.Equal(t, hpsf.FormatVersion, h.FormatVersion)
+	assert.Equal(t, "", h.Version)
+	assert.Equal(t, []string{
+		"Receive OTel", "Start Sampling", "Send to Honeycomb",
+		"Errors", "Keep Errors", "Everything Else", "Sample at a Fixed Rate",
+	}, componentNames(h))
+	assert.Equal(t, []string{
+		"Receive OTel.Traces -> Start Sampling.Traces (OTelTraces)",
+		"Receive OTel.Metrics -> Send to Honeycomb.Metrics (OTelMetrics)",
+		"Start Sampling.Rule 1 -> Errors.Match (SampleData)",
+		"Errors.And -> Keep Errors.Sample (SampleData)",
+		"Start Sampling.Rule 2 -> Everything Else.Sample (SampleData)",
+	}, connectionStrings(h))
+	assert.Equal(t, 100, componentNamed(h, "Everything Else").GetProperty("SampleRate").Value)
+}
+
+func componentNames(h *hpsf.HPSF) []string {
+	var names []string
+	for _, c := range h.Components {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestBuilder_ValidDocument(t *testing.T) {
+	trans := embeddedTranslator(t)
+
+	b := trans.NewBuilder("Sampled traces").Version("v3")
+	receiver := b.Add("OTelReceiver")
+	start := b.Add("SamplingSequencer")
+	exporter := b.Add("HoneycombExporter")
+	sampler := b.Add("DeterministicSampler").Set("SampleRate", 10)
+	receiver.Connect("Traces", start).Connect("Logs", start).Connect("Metrics", exporter)
+	start.Connect("Rule 1", sampler)
+	sampler.Connect("Events", exporter)
+
+	h, err := b.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "v3", h.Version)
+	require.NoError(t, trans.ValidateConfig(h))
+}
+
+func TestBuilder_Errors(t *testing.T) {
+	trans := embeddedTranslator(t)
+
+	tests := []struct {
+		name  string
+		build func(b *Builder)
+		err   string
+	}{
+		{"unknown kind", func(b *Builder) {
+			b.Add("OTelReciever")
+		}, "unknown component kind: OTelReciever"},
+		{"unknown output port", func(b *Builder) {
+			b.Add("OTelReceiver").Connect("Trace", b.Add("HoneycombExporter"))
+		}, "Receive OTel (OTelReceiver) doesn't have an output port called Trace"},
+		{"input used as output", func(b *Builder) {
+			b.Add("HoneycombExporter").Connect("Traces", b.Add("OTelReceiver"))
+		}, "Send to Honeycomb (HoneycombExporter) doesn't have an output port called Traces"},
+		{"no input for the data", func(b *Builder) {
+			b.Add("OTelReceiver").Connect("Traces", b.Add("DeterministicSampler"))
+		}, "Sample at a Fixed Rate (DeterministicSampler) doesn't have an input for OTelTraces data"},
+		{"unknown input port", func(b *Builder) {
+			b.Add("OTelReceiver").ConnectPorts("Traces", b.Add("HoneycombExporter"), "Trace")
+		}, "Send to Honeycomb (HoneycombExporter) doesn't have an input port called Trace"},
+		{"mismatched ports", func(b *Builder) {
+			b.Add("OTelReceiver").ConnectPorts("Traces", b.Add("HoneycombExporter"), "Logs")
+		}, "Receive OTel port Traces sends OTelTraces data but Send to Honeycomb port Logs expects OTelLogs data"},
+		{"different builders", func(b *Builder) {
+			b.Add("OTelReceiver").Connect("Traces", trans.NewBuilder("other").Add("HoneycombExporter"))
+		}, "Receive OTel can't be connected to Send to Honeycomb because it was added to a different builder"},
+		{"unknown property", func(b *Builder) {
+			b.Add("DeterministicSampler").Set("Samplerate", 10)
+		}, "Sample at a Fixed Rate (DeterministicSampler) doesn't have a property called Samplerate"},
+		{"invalid value", func(b *Builder) {
+			b.Add("DeterministicSampler").Set("SampleRate", -1)
+		}, "Sample at a Fixed Rate (DeterministicSampler) can't set property SampleRate to -1"},
+		{"name collision", func(b *Builder) {
+			b.Add("OTelReceiver").Named("otlp-in")
+			b.Add("OTelReceiver").Named("otlp in")
+		}, "would collide with component"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := trans.NewBuilder("test")
+			tt.build(b)
+			require.Error(t, b.Err())
+			assert.Contains(t, b.Err().Error(), tt.err)
+			h, err := b.Build()
+			assert.Nil(t, h)
+			assert.Equal(t, b.Err(), err)
+		})
+	}
+}
+
+func TestBuilder_StopsAtFirstError(t *testing.T) {
+	trans := embeddedTranslator(t)
+
+	b := trans.NewBuilder("test")
+	receiver := b.Add("OTelReceiver")
+	exporter := b.Add("HoneycombExporter")
+	receiver.Connect("Trace", exporter)
+	firstErr := b.Err()
+	require.Error(t, firstErr)
+
+	// later calls don't change the document or the error, even on components
+	// that couldn't be added
+	receiver.Connect("Traces", exporter)
+	b.Add("NoSuchKind").Set("x", 1).Named("y").Connect("z", exporter)
+	assert.Equal(t, firstErr, b.Err())
+	assert.Len(t, b.Document().Components, 2)
+	assert.Empty(t, b.Document().Connections)
+}
+
+func TestBuilder_TrySet(t *testing.T) {
+	trans := embeddedTranslator(t)
+
+	b := trans.NewBuilder("test")
+	sampler := b.Add("DeterministicSampler")
+	err := sampler.TrySet("SampleRate", 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't set property SampleRate to 0")
+	assert.Contains(t, sampler.TrySet("Samplerate", 10).Error(), "doesn't have a property called Samplerate")
+
+	// the value is left out, and the builder carries on
+	require.NoError(t, b.Err())
+	assert.Nil(t, sampler.Component().GetProperty("SampleRate"))
+	require.NoError(t, sampler.TrySet("SampleRate", 10))
+	assert.Equal(t, 10, sampler.Component().GetProperty("SampleRate").Value)
+
+	// once the builder has stopped, it does nothing
+	sampler.Set("Samplerate", 10)
+	require.Error(t, b.Err())
+	assert.NoError(t, sampler.TrySet("SampleRate", 20))
+	assert.Equal(t, 10, sampler.Component().GetProperty("SampleRate").Value)
+}
+
+func TestBuilder_AmbiguousInput(t *testing.T) {
+	trans := NewEmptyTranslator()
+	trans.InstallComponents(map[string]config.TemplateComponent{
+		"Source": {Kind: "Source", Name: "Source", Ports: []config.TemplatePort{
+			{Name: "Out", Direction: "output", Type: hpsf.CTYPE_TRACES},
+			{Name: "Left", Direction: "output", Type: hpsf.CTYPE_TRACES},
+		}},
+		"Merge": {Kind: "Merge", Name: "Merge", Ports: []config.TemplatePort{
+			{Name: "Left", Direction: "input", Type: hpsf.CTYPE_TRACES},
+			{Name: "Right", Direction: "input", Type: hpsf.CTYPE_TRACES},
+		}},
+	})
+
+	b := trans.NewBuilder("test")
+	src := b.Add("Source")
+	merge := b.Add("Merge")
+	src.Connect("Left", merge) // same name wins
+	src.ConnectPorts("Out", merge, "Right")
+	require.NoError(t, b.Err())
+	assert.Equal(t, []string{
+		"Source.Left -> Merge.Left (OTelTraces)",
+		"Source.Out -> Merge.Right (OTelTraces)",
+	}, connectionStrings(b.Document()))
+
+	src.Connect("Out", merge)
+	require.Error(t, b.Err())
+	assert.Equal(t, "Merge (Merge) has more than one input for OTelTraces data; use ConnectPorts to choose one", b.Err().Error())
+}
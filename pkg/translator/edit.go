@@ -0,0 +1,39 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+)
+
+// InsertBetween splices a component into a connection of the document, like
+// hpsf.HPSF.InsertBetween, using the ports of the component's template that
+// carry the connection's type of data. For example, a RedactionProcessor
+// inserted into a traces connection is connected through its Traces ports.
+// It returns an error if the component's kind isn't installed, or if its
+// template doesn't have an input and an output port for that type of data.
+func (t *Translator) InsertBetween(h *hpsf.HPSF, conn *hpsf.Connection, c *hpsf.Component) error {
+	tc, ok := t.components[c.Kind]
+	if !ok || !componentVersionSupported(tc.Version, c.Version) {
+		return fmt.Errorf("unknown component kind: %s@%s", c.Kind, c.Version)
+	}
+	ctype := conn.Source.Type
+	input := matchingPort(tc, "input", ctype)
+	output := matchingPort(tc, "output", ctype)
+	if input == "" || output == "" {
+		return fmt.Errorf("%s doesn't have an input and an output for %s data", c.Kind, ctype)
+	}
+	return h.InsertBetween(conn, c, input, output)
+}
+
+// matchingPort returns the name of the template's first port in the given
+// direction that carries the given type of data, or "" if there isn't one.
+func matchingPort(tc config.TemplateComponent, direction string, ctype hpsf.ConnectionType) string {
+	for _, port := range tc.Ports {
+		if port.Direction == direction && port.Type == ctype {
+			return port.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package translator
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/data"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslator_InsertBetween(t *testing.T) {
+	trans := NewEmptyTranslator()
+	comps, err := data.LoadEmbeddedComponents()
+	require.NoError(t, err)
+	trans.InstallComponents(comps)
+
+	h, err := hpsf.FromYAML(`
+components:
+  - name: otlp
+    kind: OTelReceiver
+  - name: honeycomb
+    kind: HoneycombExporter
+  - name: debug
+    kind: DebugExporter
+connections:
+  - source: {component: otlp, port: Traces, type: OTelTraces}
+    destination: {component: honeycomb, port: Traces, type: OTelTraces}
+  - source: {component: otlp, port: Logs, type: OTelLogs}
+    destination: {component: honeycomb, port: Logs, type: OTelLogs}
+  - source: {component: otlp, port: Metrics, type: OTelMetrics}
+    destination: {component: debug, port: Metrics, type: OTelMetrics}
+`)
+	require.NoError(t, err)
+
+	// put a redaction processor in front of every exporter
+	for _, conn := range slices.Clone(h.Connections) {
+		if tc := trans.GetComponents()[componentNamed(&h, conn.Destination.Component).Kind]; tc.Style == "exporter" {
+			require.NoError(t, trans.InsertBetween(&h, conn, &hpsf.Component{Name: "Redact", Kind: "RedactionProcessor"}))
+		}
+	}
+	require.Len(t, h.Components, 6)
+	assert.Equal(t, []string{"Redact", "Redact 2", "Redact 3"},
+		[]string{h.Components[3].Name, h.Components[4].Name, h.Components[5].Name})
+	var conns []string
+	for _, c := range h.Connections {
+		conns = append(conns, c.Source.Component+"."+c.Source.PortName+" -> "+c.Destination.Component+"."+c.Destination.PortName)
+	}
+	assert.Equal(t, []string{
+		"otlp.Traces -> Redact.Traces",
+		"Redact.Traces -> honeycomb.Traces",
+		"otlp.Logs -> Redact 2.Logs",
+		"Redact 2.Logs -> honeycomb.Logs",
+		"otlp.Metrics -> Redact 3.Metrics",
+		"Redact 3.Metrics -> debug.Metrics",
+	}, conns)
+	require.NoError(t, trans.ValidateConfig(&h))
+
+	err = trans.InsertBetween(&h, h.Connections[0], &hpsf.Component{Name: "Debug", Kind: "DebugExporter"})
+	assert.EqualError(t, err, "DebugExporter doesn't have an input and an output for OTelTraces data")
+	err = trans.InsertBetween(&h, h.Connections[0], &hpsf.Component{Name: "Nope", Kind: "NoSuchProcessor"})
+	assert.EqualError(t, err, "unknown component kind: NoSuchProcessor@")
+}
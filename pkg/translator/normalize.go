@@ -0,0 +1,188 @@
+package translator
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+)
+
+// A Fix describes one change made by Normalize. Component and Property are
+// empty when the change isn't about a particular component or property.
+type Fix struct {
+	Component   string
+	Property    string
+	Description string
+}
+
+func (f Fix) String() string {
+	switch {
+	case f.Property != "":
+		return fmt.Sprintf("%s.%s: %s", f.Component, f.Property, f.Description)
+	case f.Component != "":
+		return fmt.Sprintf("%s: %s", f.Component, f.Description)
+	default:
+		return f.Description
+	}
+}
+
+// Normalize repairs the problems in an HPSF document that have only one
+// sensible fix, and returns the repaired copy along with a list of the
+// changes that were made. The original document is not modified. The fixes
+// are:
+//   - a connection port with no type gets the type of the component's port
+//   - a string property value is converted to the property's type, if it can be
+//   - duplicate connections are removed
+//   - properties that are set to their default value are removed
+//   - layout entries for components that don't exist are removed
+//
+// Components whose kind isn't installed in the translator (including
+// container instances) are left alone. Normalize doesn't validate the
+// document; problems it can't fix are left for ValidateConfig to report.
+func (t *Translator) Normalize(h *hpsf.HPSF) (*hpsf.HPSF, []Fix) {
+	h = h.Clone()
+	fixes := []Fix{}
+
+	templateComps := make(map[string]config.TemplateComponent)
+	for _, c := range h.Components {
+		if tc, ok := t.components[c.Kind]; ok && componentVersionSupported(tc.Version, c.Version) {
+			templateComps[c.GetSafeName()] = tc
+		}
+	}
+
+	for _, c := range h.Components {
+		tc, ok := templateComps[c.GetSafeName()]
+		if !ok {
+			continue
+		}
+		fixes = append(fixes, normalizeProperties(c, tc)...)
+	}
+
+	// fill in missing types first so that connections that only differed by
+	// a missing type are recognized as duplicates
+	for _, conn := range h.Connections {
+		fixes = append(fixes, normalizePortType(conn, &conn.Source, templateComps)...)
+		fixes = append(fixes, normalizePortType(conn, &conn.Destination, templateComps)...)
+	}
+
+	seen := make(map[string]bool)
+	connections := make([]*hpsf.Connection, 0, len(h.Connections))
+	for _, conn := range h.Connections {
+		key := fmt.Sprintf("%s.%s:%s -> %s.%s:%s",
+			conn.Source.GetSafeName(), conn.Source.PortName, conn.Source.Type,
+			conn.Destination.GetSafeName(), conn.Destination.PortName, conn.Destination.Type)
+		if seen[key] {
+			fixes = append(fixes, Fix{
+				Component:   conn.Source.Component,
+				Description: fmt.Sprintf("removed duplicate connection %s", connectionString(conn)),
+			})
+			continue
+		}
+		seen[key] = true
+		connections = append(connections, conn)
+	}
+	h.Connections = connections
+
+	if h.Layout != nil {
+		names := make(map[string]bool)
+		for _, c := range h.Components {
+			names[c.Name] = true
+		}
+		h.Layout.Components = slices.DeleteFunc(h.Layout.Components, func(lc hpsf.LayoutComponent) bool {
+			if names[lc.Name] {
+				return false
+			}
+			fixes = append(fixes, Fix{
+				Component:   lc.Name,
+				Description: "removed layout for a component that doesn't exist",
+			})
+			return true
+		})
+	}
+
+	return h, fixes
+}
+
+// normalizeProperties converts string values to the property's type and
+// removes properties that are set to their default.
+func normalizeProperties(c *hpsf.Component, tc config.TemplateComponent) []Fix {
+	var fixes []Fix
+	props := tc.Props()
+	properties := make([]hpsf.Property, 0, len(c.Properties))
+	for _, p := range c.Properties {
+		tp, ok := props[p.Name]
+		if !ok {
+			properties = append(properties, p)
+			continue
+		}
+
+		if s, ok := p.Value.(string); ok {
+			if v, err := tp.Coerce(s); err == nil {
+				if _, stillString := v.(string); !stillString {
+					p.Value = v
+					fixes = append(fixes, Fix{
+						Component:   c.Name,
+						Property:    p.Name,
+						Description: fmt.Sprintf("converted %q to %s %v", s, tp.Type, v),
+					})
+				}
+			}
+		}
+
+		// durations are written in their canonical form, like 1m30s
+		if tp.Type == hpsf.PTYPE_DUR {
+			if v, err := tp.Coerce(p.Value); err == nil && v != p.Value {
+				fixes = append(fixes, Fix{
+					Component:   c.Name,
+					Property:    p.Name,
+					Description: fmt.Sprintf("converted %v to %s %v", p.Value, tp.Type, v),
+				})
+				p.Value = v
+			}
+		}
+
+		if tp.Default != nil {
+			value, err := tp.Coerce(p.Value)
+			def, defErr := tp.Coerce(tp.Default)
+			if err == nil && defErr == nil && reflect.DeepEqual(value, def) {
+				fixes = append(fixes, Fix{
+					Component:   c.Name,
+					Property:    p.Name,
+					Description: "removed because it is set to the default value",
+				})
+				continue
+			}
+		}
+		properties = append(properties, p)
+	}
+	c.Properties = properties
+	return fixes
+}
+
+// normalizePortType fills in the type of one end of a connection from the
+// component's port, if the type is missing and the port is known.
+func normalizePortType(conn *hpsf.Connection, cp *hpsf.ConnectionPort, templateComps map[string]config.TemplateComponent) []Fix {
+	if cp.Type != "" {
+		return nil
+	}
+	tc, ok := templateComps[cp.GetSafeName()]
+	if !ok {
+		return nil
+	}
+	port := tc.GetPort(cp.PortName)
+	if port == nil {
+		return nil
+	}
+	cp.Type = port.Type
+	return []Fix{{
+		Component:   cp.Component,
+		Description: fmt.Sprintf("set the type of port %s to %s on connection %s", cp.PortName, port.Type, connectionString(conn)),
+	}}
+}
+
+func connectionString(conn *hpsf.Connection) string {
+	return fmt.Sprintf("%s.%s -> %s.%s",
+		conn.Source.Component, conn.Source.PortName, conn.Destination.Component, conn.Destination.PortName)
+}
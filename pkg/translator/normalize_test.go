@@ -0,0 +1,139 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const normalizeTestYAML = `components:
+  - name: otlp
+    kind: OTelReceiver
+    properties:
+      - name: GRPCPort
+        value: "5000"
+      - name: HTTPPort
+        value: 4318
+  - name: debug
+    kind: DebugExporter
+  - name: box
+    kind: MyContainer
+connections:
+  - source:
+      component: otlp
+      port: Traces
+    destination:
+      component: debug
+      port: Traces
+  - source:
+      component: otlp
+      port: Traces
+      type: OTelTraces
+    destination:
+      component: debug
+      port: Traces
+      type: OTelTraces
+  - source:
+      component: otlp
+      port: Logs
+    destination:
+      component: box
+      port: Logs
+layout:
+  components:
+    - name: otlp
+      position: {x: 0, y: 0}
+    - name: debug
+      position: {x: 100, y: 0}
+    - name: deleted
+      position: {x: 200, y: 0}
+`
+
+func TestTranslator_Normalize(t *testing.T) {
+	h, err := hpsf.FromYAML(normalizeTestYAML)
+	require.NoError(t, err)
+	tr := embeddedTranslator(t)
+
+	fixed, fixes := tr.Normalize(&h)
+	got := []string{}
+	for _, f := range fixes {
+		got = append(got, f.String())
+	}
+	assert.Equal(t, []string{
+		`otlp.GRPCPort: converted "5000" to int 5000`,
+		"otlp.HTTPPort: removed because it is set to the default value",
+		"otlp: set the type of port Traces to OTelTraces on connection otlp.Traces -> debug.Traces",
+		"debug: set the type of port Traces to OTelTraces on connection otlp.Traces -> debug.Traces",
+		"otlp: set the type of port Logs to OTelLogs on connection otlp.Logs -> box.Logs",
+		"otlp: removed duplicate connection otlp.Traces -> debug.Traces",
+		"deleted: removed layout for a component that doesn't exist",
+	}, got)
+
+	otlp := componentNamed(fixed, "otlp")
+	require.Len(t, otlp.Properties, 1)
+	assert.Equal(t, 5000, otlp.GetProperty("GRPCPort").Value)
+
+	require.Len(t, fixed.Connections, 2)
+	assert.Equal(t, hpsf.CTYPE_TRACES, fixed.Connections[0].Source.Type)
+	assert.Equal(t, hpsf.CTYPE_TRACES, fixed.Connections[0].Destination.Type)
+	// the container instance's port isn't known, so only the source is fixed
+	assert.Equal(t, hpsf.CTYPE_LOGS, fixed.Connections[1].Source.Type)
+	assert.Empty(t, fixed.Connections[1].Destination.Type)
+
+	require.Len(t, fixed.Layout.Components, 2)
+
+	// the original is unchanged
+	assert.Equal(t, "5000", componentNamed(&h, "otlp").GetProperty("GRPCPort").Value)
+	assert.Len(t, h.Connections, 3)
+	assert.Len(t, h.Layout.Components, 3)
+
+	// normalizing again finds nothing to do
+	_, fixes = tr.Normalize(fixed)
+	assert.Empty(t, fixes)
+}
+
+func TestTranslator_NormalizeLeavesInvalidValues(t *testing.T) {
+	h, err := hpsf.FromYAML(`components:
+  - name: otlp
+    kind: OTelReceiver
+    properties:
+      - name: GRPCPort
+        value: "not a number"
+      - name: Unknown
+        value: "1"
+`)
+	require.NoError(t, err)
+	fixed, fixes := embeddedTranslator(t).Normalize(&h)
+	assert.Empty(t, fixes)
+	assert.Equal(t, h.Components[0].Properties, fixed.Components[0].Properties)
+}
+
+func TestTranslator_NormalizeDurations(t *testing.T) {
+	h, err := hpsf.FromYAML(`components:
+  - name: s3
+    kind: S3ArchiveExporter
+    properties:
+      - name: Bucket
+        value: my-bucket
+      - name: BatchTimeout
+        value: 90
+      - name: Timeout
+        value: 5000ms
+`)
+	require.NoError(t, err)
+	fixed, fixes := embeddedTranslator(t).Normalize(&h)
+	got := []string{}
+	for _, f := range fixes {
+		got = append(got, f.String())
+	}
+	assert.Equal(t, []string{
+		"s3.BatchTimeout: converted 90 to duration 1m30s",
+		"s3.Timeout: converted 5000ms to duration 5s",
+		"s3.Timeout: removed because it is set to the default value",
+	}, got)
+	s3 := componentNamed(fixed, "s3")
+	require.Len(t, s3.Properties, 2)
+	assert.Equal(t, "1m30s", s3.GetProperty("BatchTimeout").Value)
+}
@@ -0,0 +1,153 @@
+package translator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/config/tmpl"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/hpsftypes"
+)
+
+// A Provenance says where the parts of a generated configuration came from, so
+// that a value in a long configuration can be traced back to the component,
+// template, and properties that produced it.
+//
+//   - Keys maps dotted keys, like "exporters.otlp/Honeycomb.sending_queue.queue_size"
+//     in a collector configuration, to the templates that set them. Keys whose
+//     values are lists, like the components of a collector pipeline, can have
+//     several origins; for other values, the last origin is the one that stuck.
+//   - Components maps collector component names, like "otlp/Honeycomb", to the
+//     templates that defined them.
+//   - Rules maps Refinery sampling rules, named by their dotted key, like
+//     "Samplers.__default__.RulesBasedSampler.Rules.0", to the components that
+//     make up the rule: the sampling sequencer, any conditions, and the sampler
+//     or dropper. A single rule without conditions is written out as the
+//     environment's sampler instead, and is indexed under that sampler's key,
+//     like "Samplers.__default__.DeterministicSampler".
+//
+// Keys that don't come from a component, like the defaults every configuration
+// starts with, aren't in the index.
+type Provenance struct {
+	Keys       map[string][]config.Origin `yaml:"keys,omitempty" json:"keys,omitempty"`
+	Components map[string][]config.Origin `yaml:"components,omitempty" json:"components,omitempty"`
+	Rules      map[string][]config.Origin `yaml:"rules,omitempty" json:"rules,omitempty"`
+	ruleCount  map[string]int             // the number of rules indexed in each environment
+}
+
+// NewProvenance returns an empty Provenance.
+func NewProvenance() *Provenance {
+	return &Provenance{
+		Keys:       make(map[string][]config.Origin),
+		Components: make(map[string][]config.Origin),
+		Rules:      make(map[string][]config.Origin),
+		ruleCount:  make(map[string]int),
+	}
+}
+
+// GenerateConfigWithProvenance is like GenerateConfig, but also returns an
+// index of where each part of the configuration came from.
+func (t *Translator) GenerateConfigWithProvenance(h *hpsf.HPSF, ct hpsftypes.Type, artifactVersion string, userdata map[string]any) (tmpl.TemplateConfig, *Provenance, error) {
+	prov := NewProvenance()
+	cfg, err := t.generateConfig(h, ct, artifactVersion, userdata, prov)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rc, ok := cfg.(*tmpl.RulesConfig); ok {
+		prov.promoteRules(rc.PromotedSamplers())
+	}
+	return cfg, prov, nil
+}
+
+// Lookup returns the origins of a dotted key and of all the keys beneath it,
+// so that a whole block of a configuration, like an exporter's
+// "sending_queue", can be traced at once. Each template of each component is
+// returned once, with all the properties it used for those keys.
+func (p *Provenance) Lookup(key string) []config.Origin {
+	var origins []config.Origin
+	for _, k := range slices.Sorted(maps.Keys(p.Keys)) {
+		if k != key && !strings.HasPrefix(k, key+".") {
+			continue
+		}
+		for _, o := range p.Keys[k] {
+			origins = mergeOrigin(origins, o)
+		}
+	}
+	return origins
+}
+
+// mergeOrigin adds the properties of an origin to the one for the same
+// component and template in a list, or appends it if there isn't one.
+func mergeOrigin(origins []config.Origin, o config.Origin) []config.Origin {
+	for i := range origins {
+		if origins[i].Component == o.Component && origins[i].Template == o.Template {
+			for _, prop := range o.Properties {
+				if !slices.Contains(origins[i].Properties, prop) {
+					origins[i].Properties = append(origins[i].Properties, prop)
+				}
+			}
+			return origins
+		}
+	}
+	o.Properties = slices.Clone(o.Properties)
+	return append(origins, o)
+}
+
+// addPath indexes the origins recorded while generating the configuration for
+// one path. Each path that produces sampling rules produces one rule, and the
+// paths' rules are appended to their environment's rules in order. It does
+// nothing if p is nil.
+func (p *Provenance) addPath(records []config.OriginRecord) {
+	if p == nil {
+		return
+	}
+	// the rule goes in the environment of the components that name one, like
+	// the sampling sequencer; conditions don't
+	var rule string
+	for _, rec := range records {
+		if rec.Target == config.OriginRule && rec.Name != "" {
+			rule = fmt.Sprintf("Samplers.%s.RulesBasedSampler.Rules.%d", rec.Name, p.ruleCount[rec.Name])
+			p.ruleCount[rec.Name]++
+			break
+		}
+	}
+	for _, rec := range records {
+		switch rec.Target {
+		case config.OriginKey:
+			p.Keys[rec.Name] = addOrigin(p.Keys[rec.Name], rec.Origin)
+		case config.OriginComponent:
+			p.Components[rec.Name] = addOrigin(p.Components[rec.Name], rec.Origin)
+		case config.OriginRule:
+			if rule != "" {
+				p.Rules[rule] = addOrigin(p.Rules[rule], rec.Origin)
+			}
+		}
+	}
+}
+
+// promoteRules moves the rules of environments whose single rule is written out
+// as the environment's sampler, given by tmpl.RulesConfig.PromotedSamplers, to
+// the key of that sampler.
+func (p *Provenance) promoteRules(promoted map[string]string) {
+	for env, samplerType := range promoted {
+		rule := fmt.Sprintf("Samplers.%s.RulesBasedSampler.Rules.0", env)
+		if origins, ok := p.Rules[rule]; ok {
+			delete(p.Rules, rule)
+			p.Rules[fmt.Sprintf("Samplers.%s.%s", env, samplerType)] = origins
+		}
+	}
+}
+
+// addOrigin appends an origin to a list unless it's already there.
+func addOrigin(origins []config.Origin, o config.Origin) []config.Origin {
+	for _, existing := range origins {
+		if existing.Component == o.Component && existing.Template == o.Template &&
+			slices.Equal(existing.Properties, o.Properties) {
+			return origins
+		}
+	}
+	return append(origins, o)
+}
@@ -0,0 +1,157 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/config/tmpl"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/hpsftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// provenanceWorkflow sends logs to a gRPC exporter and samples traces with two
+// rules, the first of which has a condition.
+func provenanceWorkflow(t *testing.T, trans *Translator) *hpsf.HPSF {
+	b := trans.NewBuilder("provenance")
+	receiver := b.Add("OTelReceiver")
+	start := b.Add("SamplingSequencer")
+	honeycomb := b.Add("HoneycombExporter")
+	grpc := b.Add("OTelGRPCExporter").Named("Backup").Set("QueueSize", 5000)
+	receiver.Connect("Traces", start).Connect("Logs", grpc)
+
+	errors := b.Add("FieldExistsCondition").Named("Errors").Set("Field", "error").Set("Operator", "exists")
+	keep := b.Add("DeterministicSampler").Named("Keep Errors").Set("SampleRate", 1)
+	rest := b.Add("DeterministicSampler").Named("Everything Else").Set("SampleRate", 100)
+	start.Connect("Rule 1", errors).Connect("Rule 2", rest)
+	errors.Connect("And", keep)
+	keep.Connect("Events", honeycomb)
+	rest.Connect("Events", honeycomb)
+
+	h, err := b.Build()
+	require.NoError(t, err)
+	return h
+}
+
+func TestGenerateConfigWithProvenance_Collector(t *testing.T) {
+	trans := embeddedTranslator(t)
+	h := provenanceWorkflow(t, trans)
+
+	cfg, prov, err := trans.GenerateConfigWithProvenance(h, hpsftypes.CollectorConfig, LatestVersion, nil)
+	require.NoError(t, err)
+
+	backup := func(props ...string) config.Origin {
+		return config.Origin{Component: "Backup", Kind: "OTelGRPCExporter", Template: "otel_grpc_exporter_collector", Properties: props}
+	}
+	assert.Equal(t, []config.Origin{backup("QueueSize")}, prov.Keys["exporters.otlp/Backup.sending_queue.queue_size"])
+	assert.Equal(t, []config.Origin{backup("Host", "Port")}, prov.Keys["exporters.otlp/Backup.endpoint"])
+	assert.Equal(t, []config.Origin{backup()}, prov.Keys["exporters.otlp/Backup.sending_queue.sizer"])
+	assert.Equal(t, []config.Origin{backup("BatchTimeout", "BatchSize", "QueueSize")},
+		prov.Lookup("exporters.otlp/Backup.sending_queue"))
+	assert.Equal(t, []config.Origin{backup("Host", "Port", "QueueSize", "BatchTimeout", "BatchSize")},
+		prov.Components["otlp/Backup"])
+	assert.Empty(t, prov.Lookup("exporters.otlp/Back"))
+
+	// every key of the generated configuration's components is indexed
+	for section, items := range cfg.(*tmpl.CollectorConfig).Sections {
+		if section == "service" {
+			continue
+		}
+		for key := range items {
+			assert.NotEmpty(t, prov.Keys[section+"."+key], "%s.%s", section, key)
+		}
+	}
+
+	// a pipeline's receivers, processors, and exporters each come from their own component
+	for key, origins := range prov.Keys {
+		if strings.HasPrefix(key, "service.pipelines.logs/") {
+			require.Len(t, origins, 1, key)
+			assert.Contains(t, []string{"Receive OTel", "Backup"}, origins[0].Component, key)
+		}
+	}
+}
+
+func TestGenerateConfigWithProvenance_RefineryRules(t *testing.T) {
+	trans := embeddedTranslator(t)
+	h := provenanceWorkflow(t, trans)
+
+	_, prov, err := trans.GenerateConfigWithProvenance(h, hpsftypes.RefineryRules, LatestVersion, nil)
+	require.NoError(t, err)
+
+	start := config.Origin{Component: "Start Sampling", Kind: "SamplingSequencer", Template: "StartSampling_RefineryRules"}
+	assert.Equal(t, map[string][]config.Origin{
+		"Samplers.__default__.RulesBasedSampler.Rules.0": {
+			start,
+			{Component: "Errors", Kind: "FieldExistsCondition", Template: "FieldExistsCondition", Properties: []string{"Operator", "Field"}},
+			{Component: "Keep Errors", Kind: "DeterministicSampler", Template: "DeterministicSampler_RefineryRules", Properties: []string{"SampleRate"}},
+		},
+		"Samplers.__default__.RulesBasedSampler.Rules.1": {
+			start,
+			{Component: "Everything Else", Kind: "DeterministicSampler", Template: "DeterministicSampler_RefineryRules", Properties: []string{"SampleRate"}},
+		},
+	}, prov.Rules)
+	assert.Empty(t, prov.Keys)
+	assert.Empty(t, prov.Components)
+}
+
+func TestGenerateConfigWithProvenance_PromotedRule(t *testing.T) {
+	trans := embeddedTranslator(t)
+	b := trans.NewBuilder("promoted")
+	start := b.Add("SamplingSequencer")
+	b.Add("OTelReceiver").Connect("Traces", start)
+	sampler := b.Add("DeterministicSampler").Named("Everything").Set("SampleRate", 10)
+	start.Connect("Rule 1", sampler)
+	sampler.Connect("Events", b.Add("HoneycombExporter"))
+	h, err := b.Build()
+	require.NoError(t, err)
+
+	cfg, prov, err := trans.GenerateConfigWithProvenance(h, hpsftypes.RefineryRules, LatestVersion, nil)
+	require.NoError(t, err)
+
+	// the only rule has no conditions, so it's written out as the sampler itself
+	out, err := cfg.RenderYAML()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "DeterministicSampler:")
+	assert.NotContains(t, string(out), "RulesBasedSampler")
+
+	assert.Equal(t, map[string][]config.Origin{
+		"Samplers.__default__.DeterministicSampler": {
+			{Component: "Start Sampling", Kind: "SamplingSequencer", Template: "StartSampling_RefineryRules"},
+			{Component: "Everything", Kind: "DeterministicSampler", Template: "DeterministicSampler_RefineryRules", Properties: []string{"SampleRate"}},
+		},
+	}, prov.Rules)
+}
+
+func TestGenerateConfigWithProvenance_RefineryConfig(t *testing.T) {
+	trans := embeddedTranslator(t)
+	h := provenanceWorkflow(t, trans)
+
+	_, prov, err := trans.GenerateConfigWithProvenance(h, hpsftypes.RefineryConfig, LatestVersion, nil)
+	require.NoError(t, err)
+
+	require.Len(t, prov.Keys["AccessKeys.SendKey"], 1)
+	assert.Equal(t, "Send to Honeycomb", prov.Keys["AccessKeys.SendKey"][0].Component)
+	assert.Equal(t, []string{"APIKey"}, prov.Keys["AccessKeys.SendKey"][0].Properties)
+	// the defaults that every configuration starts with don't come from a component
+	assert.Empty(t, prov.Keys["General.ConfigurationVersion"])
+}
+
+func TestGenerateConfigWithProvenance_SameConfig(t *testing.T) {
+	trans := embeddedTranslator(t)
+	h := provenanceWorkflow(t, trans)
+
+	for _, ct := range []hpsftypes.Type{hpsftypes.CollectorConfig, hpsftypes.RefineryConfig, hpsftypes.RefineryRules} {
+		plain, err := trans.GenerateConfig(h, ct, LatestVersion, nil)
+		require.NoError(t, err)
+		withProv, _, err := trans.GenerateConfigWithProvenance(h, ct, LatestVersion, nil)
+		require.NoError(t, err)
+
+		want, err := plain.RenderYAML()
+		require.NoError(t, err)
+		got, err := withProv.RenderYAML()
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), ct)
+	}
+}
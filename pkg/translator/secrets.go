@@ -0,0 +1,88 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/validator"
+)
+
+// validateSecrets warns about secrets that are written into the document
+// instead of referring to an environment variable.
+func (t *Translator) validateSecrets(h *hpsf.HPSF, templateComps map[string]config.TemplateComponent) validator.Result {
+	result := validator.NewResult("HPSF secret warnings")
+	for _, comp := range h.Components {
+		tmpl, ok := templateComps[comp.GetSafeName()]
+		if !ok {
+			continue
+		}
+		for _, prop := range tmpl.Properties {
+			p := comp.GetProperty(prop.Name)
+			if p == nil || !prop.Type.IsLiteralSecret(p.Value) {
+				continue
+			}
+			result.Add(hpsf.NewWarningf("secret is written into the document instead of referring to an environment variable like ${%s}",
+				hpsf.SecretEnvVar(comp.Name, prop.Name)).
+				WithCode(hpsf.CODE_LITERAL_SECRET).
+				WithComponent(comp.Name).
+				WithProperty(prop.Name).
+				WithLocation(p.Location()))
+		}
+	}
+	return result
+}
+
+// SecretEnv returns the environment that a collector needs to run the config
+// generated from this document: the values of the secrets that are written
+// into the document, keyed by the environment variables that refer to them in
+// the collector config (see hpsf.SecretEnvVar). Secrets that already refer to
+// an environment variable are not included. With SetLiteralSecrets on, the
+// config doesn't need any of them.
+func (t *Translator) SecretEnv(h *hpsf.HPSF) (map[string]string, error) {
+	// the collector config is generated from the flattened, upgraded document
+	h, err := h.Flatten()
+	if err != nil {
+		return nil, err
+	}
+	h, _ = t.Upgrade(h)
+
+	env := make(map[string]string)
+	for _, comp := range h.Components {
+		tmpl, ok := t.components[comp.Kind]
+		if !ok {
+			continue
+		}
+		for _, prop := range tmpl.Properties {
+			p := comp.GetProperty(prop.Name)
+			if p == nil || !prop.Type.IsLiteralSecret(p.Value) {
+				continue
+			}
+			env[hpsf.SecretEnvVar(comp.Name, prop.Name)] = fmt.Sprint(p.Value)
+		}
+	}
+	return env, nil
+}
+
+// PropertyType returns the type of a property of the named component in h:
+// the type its template gives it if the component's kind is installed, and
+// otherwise the type the document declares. The changes reported by
+// hpsf.Diff and the conflicts reported by hpsf.Merge3 only know the declared
+// types, so use this to fill in their Type before showing them; that's what
+// keeps secrets out of the output.
+func (t *Translator) PropertyType(h *hpsf.HPSF, component, property string) hpsf.PropType {
+	for _, comp := range h.Components {
+		if comp.Name != component {
+			continue
+		}
+		if tmpl, ok := t.components[comp.Kind]; ok {
+			if prop, ok := tmpl.Props()[property]; ok {
+				return prop.Type
+			}
+		}
+		if p := comp.GetProperty(property); p != nil {
+			return p.Type
+		}
+	}
+	return ""
+}
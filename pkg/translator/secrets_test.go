@@ -0,0 +1,109 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/hpsftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secretsTestYAML = `components:
+  - name: otlp
+    kind: OTelReceiver
+  - name: My Honeycomb
+    kind: HoneycombExporter
+    properties:
+      - name: APIKey
+        value: abc123
+  - name: referenced
+    kind: HoneycombExporter
+    properties:
+      - name: APIKey
+        value: ${MY_API_KEY}
+connections:
+  - source:
+      component: otlp
+      port: Logs
+      type: OTelLogs
+    destination:
+      component: My Honeycomb
+      port: Logs
+      type: OTelLogs
+  - source:
+      component: otlp
+      port: Metrics
+      type: OTelMetrics
+    destination:
+      component: referenced
+      port: Metrics
+      type: OTelMetrics
+`
+
+func TestTranslator_Secrets(t *testing.T) {
+	h, err := hpsf.FromYAML(secretsTestYAML)
+	require.NoError(t, err)
+	tr := embeddedTranslator(t)
+
+	// Inspect hides the literal key, but not the references
+	exporters := tr.Inspect(h).Filter(Exporters).Components
+	require.Len(t, exporters, 2)
+	assert.Equal(t, hpsf.RedactedValue, exporters[0].Properties["APIKey"])
+	assert.Equal(t, "${MY_API_KEY}", exporters[1].Properties["APIKey"])
+
+	// validation warns about the literal key
+	warnings, err := tr.ValidateConfigWithWarnings(&h)
+	require.NoError(t, err)
+	report := hpsf.NewReport(warnings)
+	require.Len(t, report.Problems, 1)
+	assert.Equal(t, hpsf.CODE_LITERAL_SECRET, report.Problems[0].Code)
+	assert.Equal(t, "My Honeycomb", report.Problems[0].Component)
+	assert.Equal(t, "APIKey", report.Problems[0].Property)
+	assert.Equal(t, 7, report.Problems[0].Location.Line)
+	assert.NotContains(t, report.Problems[0].Message, "abc123")
+
+	// the collector config refers to environment variables instead
+	cfg, err := tr.GenerateConfig(&h, hpsftypes.CollectorConfig, LatestVersion, nil)
+	require.NoError(t, err)
+	out, err := cfg.RenderYAML()
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "abc123")
+	assert.Contains(t, string(out), "x-honeycomb-team: ${HTP_MY_HONEYCOMB_APIKEY}")
+	assert.Contains(t, string(out), "x-honeycomb-team: ${MY_API_KEY}")
+	assert.Equal(t, "abc123", h.Components[1].GetProperty("APIKey").Value)
+
+	env, err := tr.SecretEnv(&h)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"HTP_MY_HONEYCOMB_APIKEY": "abc123"}, env)
+
+	// but it can keep the secrets as written
+	tr.SetLiteralSecrets(true)
+	cfg, err = tr.GenerateConfig(&h, hpsftypes.CollectorConfig, LatestVersion, nil)
+	require.NoError(t, err)
+	out, err = cfg.RenderYAML()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "x-honeycomb-team: abc123")
+	assert.Contains(t, string(out), "x-honeycomb-team: ${MY_API_KEY}")
+}
+
+func TestTranslator_PropertyType(t *testing.T) {
+	h, err := hpsf.FromYAML(secretsTestYAML)
+	require.NoError(t, err)
+	tr := embeddedTranslator(t)
+
+	// the document doesn't say the key is a secret, but the template does
+	assert.Equal(t, hpsf.PropType(""), h.Components[1].GetProperty("APIKey").Type)
+	assert.Equal(t, hpsf.PTYPE_SECRET, tr.PropertyType(&h, "My Honeycomb", "APIKey"))
+	assert.Equal(t, hpsf.PTYPE_INT, tr.PropertyType(&h, "otlp", "GRPCPort"))
+	assert.Equal(t, hpsf.PropType(""), tr.PropertyType(&h, "nobody", "APIKey"))
+
+	// so the diff of two documents can keep the key out of its report
+	b, err := hpsf.FromYAML(secretsTestYAML)
+	require.NoError(t, err)
+	b.Components[1].SetProperty(hpsf.Property{Name: "APIKey", Value: "def456"})
+	d := hpsf.Diff(&h, &b)
+	require.Len(t, d.PropertiesChanged, 1)
+	d.PropertiesChanged[0].Type = tr.PropertyType(&b, d.PropertiesChanged[0].Component, d.PropertiesChanged[0].Property)
+	assert.Equal(t, "properties changed:\n  ~ My Honeycomb.APIKey: <redacted> -> <redacted>\n", d.String())
+}
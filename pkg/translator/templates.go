@@ -0,0 +1,156 @@
+package translator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/validator"
+)
+
+// InstantiateTemplate creates a new HPSF document from the installed template
+// with the given kind (the key it was installed under, such as "TemplateProxy").
+// The result is a deep copy, so it can be edited freely without affecting the
+// template. Component names are made unique, and connections and layout are
+// kept in step with any renaming.
+//
+// params overrides component properties. Each key has the form
+// "component.property", where component is either the name of a component in
+// the template or the kind of a component that appears only once in it, for
+// example "HoneycombExporter.APIKey". Values are coerced to the property's
+// type and checked against its validations, so a bad parameter fails here
+// rather than at translation time.
+func (t *Translator) InstantiateTemplate(name string, params map[string]any) (hpsf.HPSF, error) {
+	template, ok := t.templates[name]
+	if !ok {
+		return hpsf.HPSF{}, fmt.Errorf("unknown template %s", name)
+	}
+
+	// the kind and version describe the template itself, not the new document
+	h := template.Clone()
+	h.Kind = ""
+	h.Version = ""
+
+	// rebuild the component list one at a time so that each name is unique
+	// among those that came before it. A name in a connection or a parameter
+	// refers to the first component with that name, and the layout entries
+	// with a name refer to the components with that name in the same order.
+	first := make(map[string]*hpsf.Component)
+	byName := make(map[string][]*hpsf.Component)
+	components := h.Components
+	h.Components = nil
+	for _, c := range components {
+		if _, seen := first[c.Name]; !seen {
+			first[c.Name] = c
+		}
+		byName[c.Name] = append(byName[c.Name], c)
+		c.Name = h.UniqueComponentName(c.Name)
+		h.Components = append(h.Components, c)
+	}
+	for _, conn := range h.Connections {
+		if c, ok := first[conn.Source.Component]; ok {
+			conn.Source.Component = c.Name
+		}
+		if c, ok := first[conn.Destination.Component]; ok {
+			conn.Destination.Component = c.Name
+		}
+	}
+	if h.Layout != nil {
+		placed := make(map[string]int)
+		for i, lc := range h.Layout.Components {
+			if comps := byName[lc.Name]; placed[lc.Name] < len(comps) {
+				h.Layout.Components[i].Name = comps[placed[lc.Name]].Name
+				placed[lc.Name]++
+			}
+		}
+	}
+
+	result := validator.NewResult("template instantiation errors")
+	// sort the keys so that errors are reported in a stable order
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		compName, propName, ok := cutLast(key, ".")
+		if !ok {
+			result.Add(hpsf.NewErrorf("template parameter %s must have the form component.property", key).
+				WithCode(hpsf.CODE_INVALID_TEMPLATE_PARAMETER))
+			continue
+		}
+		if c, ok := first[compName]; ok {
+			compName = c.Name
+		}
+		comp, err := findTemplateComponent(h, compName)
+		if err != nil {
+			result.Add(hpsf.NewError("template parameter does not match a component").
+				WithCode(hpsf.CODE_INVALID_TEMPLATE_PARAMETER).WithComponent(compName).WithProperty(propName).WithCause(err))
+			continue
+		}
+		tc, ok := t.components[comp.Kind]
+		if !ok {
+			result.Add(hpsf.NewErrorf("unknown component kind: %s", comp.Kind).
+				WithCode(hpsf.CODE_UNKNOWN_KIND).WithComponent(comp.Name))
+			continue
+		}
+		tp, ok := tc.Props()[propName]
+		if !ok {
+			result.Add(hpsf.NewError("property not found in template component").
+				WithCode(hpsf.CODE_PROPERTY_NOT_FOUND).WithComponent(comp.Name).WithProperty(propName))
+			continue
+		}
+
+		prop := hpsf.Property{Name: propName, Value: params[key], Type: tp.Type}
+		if err := tp.Validate(prop); err != nil {
+			result.Add(hpsf.NewError("failed to validate property").
+				WithCode(hpsf.CODE_INVALID_PROPERTY).WithCause(err).WithComponent(comp.Name).WithProperty(propName))
+			continue
+		}
+		var value any
+		if err := tp.Type.ValueCoerce(prop.Value, &value); err != nil {
+			// Validate already coerced the value successfully, so this can't happen
+			result.Add(err)
+			continue
+		}
+		// we don't store the type; template documents don't, and the template
+		// component already knows it
+		comp.SetProperty(hpsf.Property{Name: propName, Value: value})
+	}
+	if !result.IsEmpty() {
+		return hpsf.HPSF{}, result
+	}
+
+	return *h, nil
+}
+
+// findTemplateComponent finds a component by name, or failing that, by kind
+// if there's exactly one component of that kind.
+func findTemplateComponent(h *hpsf.HPSF, name string) (*hpsf.Component, error) {
+	var byKind []*hpsf.Component
+	for _, c := range h.Components {
+		if c.Name == name {
+			return c, nil
+		}
+		if c.Kind == name {
+			byKind = append(byKind, c)
+		}
+	}
+	switch len(byKind) {
+	case 0:
+		return nil, fmt.Errorf("no component is named %s or has that kind", name)
+	case 1:
+		return byKind[0], nil
+	default:
+		return nil, fmt.Errorf("there are %d components of kind %s; use a component name instead", len(byKind), name)
+	}
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
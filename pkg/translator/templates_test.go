@@ -0,0 +1,136 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstantiateTemplate(t *testing.T) {
+	tlater := embeddedTranslator(t)
+
+	h, err := tlater.InstantiateTemplate("TemplateEMAThroughput", map[string]any{
+		"EMA Throughput 1.GoalThroughputPerSec": "250",
+		"EMAThroughputSampler.FieldList":        []any{"service.name"},
+		"HoneycombExporter.APIKey":              "abc123",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "EMA Throughput Sampling", h.Name)
+	assert.Empty(t, h.Kind)
+	assert.Empty(t, h.Version)
+
+	sampler := componentNamed(&h, "EMA Throughput 1")
+	require.NotNil(t, sampler)
+	// the string is coerced to the property's type
+	assert.Equal(t, 250, sampler.GetProperty("GoalThroughputPerSec").Value)
+	assert.Equal(t, []string{"service.name"}, sampler.GetProperty("FieldList").Value)
+	assert.Equal(t, "abc123", componentNamed(&h, "Honeycomb Exporter 1").GetProperty("APIKey").Value)
+
+	require.NotNil(t, h.Layout)
+	assert.Len(t, h.Layout.Components, len(h.Components))
+
+	// the only complaint is about the literal API key
+	warnings, err := tlater.ValidateConfigWithWarnings(&h)
+	require.NoError(t, err)
+	assert.Equal(t, hpsf.CODE_LITERAL_SECRET, hpsf.NewReport(warnings).Problems[0].Code)
+
+	// the installed template is untouched, and a second instance is independent of the first
+	tmpl := tlater.GetTemplates()["TemplateEMAThroughput"]
+	assert.Equal(t, "TemplateEMAThroughput", tmpl.Kind)
+	assert.Nil(t, componentNamed(&tmpl, "Honeycomb Exporter 1").GetProperty("APIKey"))
+	assert.Equal(t, []any{"http.method", "http.status_code"},
+		componentNamed(&tmpl, "EMA Throughput 1").GetProperty("FieldList").Value)
+
+	h2, err := tlater.InstantiateTemplate("TemplateEMAThroughput", nil)
+	require.NoError(t, err)
+	h2.Components[0].Name = "changed"
+	h2.Layout.Components[0].Name = "changed"
+	assert.Equal(t, "OTel Receiver 1", h.Components[0].Name)
+	assert.Equal(t, "OTel Receiver 1", h.Layout.Components[0].Name)
+}
+
+func TestInstantiateTemplateRenamesDuplicates(t *testing.T) {
+	tlater := embeddedTranslator(t)
+	tlater.InstallTemplates(map[string]hpsf.HPSF{
+		"TemplateDup": {
+			Kind: "TemplateDup",
+			Components: []*hpsf.Component{
+				{Name: "Receiver 1", Kind: "OTelReceiver"},
+				{Name: "Receiver 1", Kind: "OTelReceiver"},
+				{Name: "Exporter", Kind: "DebugExporter"},
+			},
+			Connections: []*hpsf.Connection{
+				{
+					Source:      hpsf.ConnectionPort{Component: "Receiver 1", PortName: "Traces", Type: hpsf.CTYPE_TRACES},
+					Destination: hpsf.ConnectionPort{Component: "Exporter", PortName: "Traces", Type: hpsf.CTYPE_TRACES},
+				},
+			},
+			Layout: &hpsf.Layout{Components: []hpsf.LayoutComponent{
+				{Name: "Receiver 1", Position: &hpsf.Pos{X: 0, Y: 0}},
+				{Name: "Receiver 1", Position: &hpsf.Pos{X: 0, Y: 100}},
+				{Name: "Exporter", Position: &hpsf.Pos{X: 200, Y: 0}},
+			}},
+		},
+	})
+
+	h, err := tlater.InstantiateTemplate("TemplateDup", map[string]any{"Receiver 1.GRPCPort": 5317})
+	require.NoError(t, err)
+	names := []string{}
+	for _, c := range h.Components {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"Receiver 1", "Receiver 2", "Exporter"}, names)
+	require.NoError(t, h.Validate())
+
+	// the connection and the parameter still belong to the first receiver, and
+	// each layout entry to its own component
+	assert.Equal(t, "Receiver 1", h.Connections[0].Source.Component)
+	assert.Equal(t, 5317, componentNamed(&h, "Receiver 1").GetProperty("GRPCPort").Value)
+	assert.Nil(t, componentNamed(&h, "Receiver 2").GetProperty("GRPCPort"))
+	require.NotNil(t, h.Layout)
+	assert.Equal(t, "Receiver 1", h.Layout.Components[0].Name)
+	assert.Equal(t, "Receiver 2", h.Layout.Components[1].Name)
+	assert.Equal(t, 100, h.Layout.Components[1].Position.Y)
+}
+
+func TestInstantiateTemplateErrors(t *testing.T) {
+	tlater := embeddedTranslator(t)
+
+	_, err := tlater.InstantiateTemplate("TemplateNope", nil)
+	require.EqualError(t, err, "unknown template TemplateNope")
+
+	tests := []struct {
+		name    string
+		params  map[string]any
+		wantErr string
+	}{
+		{"no property", map[string]any{"OTelReceiver": 1}, "must have the form component.property"},
+		{"unknown component", map[string]any{"Nope.Host": "x"}, "no component is named Nope"},
+		{"unknown property", map[string]any{"OTelReceiver.Nope": "x"}, "property not found in template component"},
+		{"bad value", map[string]any{"EMA Throughput 1.GoalThroughputPerSec": -5}, "failed to validate property"},
+		{"wrong type", map[string]any{"EMA Throughput 1.GoalThroughputPerSec": "lots"}, "failed to validate property"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tlater.InstantiateTemplate("TemplateEMAThroughput", tt.params)
+			require.Error(t, err)
+			result, ok := err.(validator.Result)
+			require.True(t, ok)
+			assert.Equal(t, 1, result.Len())
+			assert.Contains(t, result.Unwrap().Error(), tt.wantErr)
+		})
+	}
+}
+
+func componentNamed(h *hpsf.HPSF, name string) *hpsf.Component {
+	for _, c := range h.Components {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
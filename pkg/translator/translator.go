@@ -22,8 +22,9 @@ const LatestVersion = "latest"
 // collection of components, and then further rendering those into configuration
 // files.
 type Translator struct {
-	components map[string]config.TemplateComponent
-	templates  map[string]hpsf.HPSF
+	components     map[string]config.TemplateComponent
+	templates      map[string]hpsf.HPSF
+	literalSecrets bool
 }
 
 // Deprecated: use NewEmptyTranslator and InstallComponents instead
@@ -53,6 +54,16 @@ func (t *Translator) InstallTemplates(components map[string]hpsf.HPSF) {
 	maps.Copy(t.templates, components)
 }
 
+// SetLiteralSecrets controls whether secrets that are written into a document
+// are rendered as written in generated collector configs. It's off by default,
+// so they're replaced by references to environment variables, like
+// ${HTP_MY_EXPORTER_APIKEY}, and SecretEnv returns the environment the
+// collector needs. Turn it on to keep the secrets in the config, as older
+// versions did.
+func (t *Translator) SetLiteralSecrets(on bool) {
+	t.literalSecrets = on
+}
+
 // GetComponents returns the components installed in the translator.
 func (t *Translator) GetComponents() map[string]config.TemplateComponent {
 	return t.components
@@ -189,7 +200,10 @@ func (t *Translator) getMatchingTemplateComponents(h *hpsf.HPSF) (map[string]con
 		if ok && componentVersionSupported(tc.Version, c.Version) {
 			templateComps[c.GetSafeName()] = tc
 		} else {
-			result.Add(fmt.Errorf("failed to locate corresponding template component for %s@%s: %w", c.Kind, c.Version, err))
+			result.Add(hpsf.NewErrorf("failed to locate corresponding template component for %s@%s", c.Kind, c.Version).
+				WithCode(hpsf.CODE_UNKNOWN_KIND).
+				WithComponent(c.Name).
+				WithLocation(c.Location()))
 		}
 	}
 	return templateComps, result
@@ -219,8 +233,10 @@ func (t *Translator) validateProperties(h *hpsf.HPSF, templateComps map[string]c
 				// properties, something's messed up. This means the property is
 				// not defined in the template component.
 				err := hpsf.NewError("property not found in template component").
+					WithCode(hpsf.CODE_PROPERTY_NOT_FOUND).
 					WithComponent(comp.Name).
-					WithProperty(prop.Name)
+					WithProperty(prop.Name).
+					WithLocation(prop.Location())
 				result.Add(err)
 			}
 		}
@@ -239,9 +255,12 @@ func (t *Translator) validateProperties(h *hpsf.HPSF, templateComps map[string]c
 				// this means the property itself has some issues
 				// we want to include the component name and property name in the error message for clarity
 				hspfError := hpsf.NewError("failed to validate property").
+					WithCode(hpsf.CODE_INVALID_PROPERTY).
 					WithCause(validateError).
 					WithComponent(comp.Name).
-					WithProperty(prop.Name)
+					WithProperty(prop.Name).
+					WithLocation(comp.Location()).            // defaulted properties point at their component
+					WithLocation(suppliedProperty.Location()) // explicit ones point at themselves
 				result.Add(hspfError)
 			}
 		}
@@ -254,12 +273,11 @@ func (t *Translator) validateProperties(h *hpsf.HPSF, templateComps map[string]c
 	return result
 }
 
-// this checks that there is exactly one connection on the input and output of each sampler
-// and condition component.
-func (t *Translator) validateSamplerConnections(h *hpsf.HPSF, templateComps map[string]config.TemplateComponent) validator.Result {
-	result := validator.NewResult("HPSF sampler connection validation errors")
-	// iterate over the components and check for samplers
-	for _, c := range h.Components {
+// validatePortConnections checks that each port with connection limits, like the single
+// input and output of samplers and conditions, has an allowed number of connections.
+func (t *Translator) validatePortConnections(g *hpsf.Graph, templateComps map[string]config.TemplateComponent) validator.Result {
+	result := validator.NewResult("HPSF port connection validation errors")
+	for _, c := range g.Components() {
 		tmpl, ok := templateComps[c.GetSafeName()]
 		if !ok {
 			// If we don't have a template component for this component, it
@@ -268,26 +286,24 @@ func (t *Translator) validateSamplerConnections(h *hpsf.HPSF, templateComps map[
 			continue
 		}
 
-		if tmpl.Style == "sampler" || tmpl.Style == "dropper" || tmpl.Style == "condition" {
-			// check the connections for the component
-			inputs := 0
-			outputs := 0
-			for _, conn := range h.Connections {
-				if conn.Destination.GetSafeName() == c.GetSafeName() {
-					inputs++
-				}
-				if conn.Source.GetSafeName() == c.GetSafeName() {
-					outputs++
-				}
+		for _, port := range tmpl.Ports {
+			limit := port.ConnectionLimit()
+			if limit == "" {
+				continue
 			}
-			if inputs != 1 {
-				err := hpsf.NewError("sampler, dropper, and condition components must have exactly one input connection").
-					WithComponent(c.Name)
-				result.Add(err)
+			connections := len(g.PortInputs(c.Name, port.Name))
+			if port.Direction == "output" {
+				connections = len(g.PortOutputs(c.Name, port.Name))
 			}
-			if outputs != 1 && tmpl.Style != "dropper" {
-				err := hpsf.NewError("sampler and condition components must have exactly one output connection").
-					WithComponent(c.Name)
+			if !port.AllowsConnections(connections) {
+				noun := port.Direction + " connection"
+				if port.MaxConnections != 1 && (port.MaxConnections != 0 || port.MinConnections != 1) {
+					noun += "s"
+				}
+				err := hpsf.NewErrorf("must have %s %s on port %s (it has %d)", limit, noun, port.Name, connections).
+					WithCode(hpsf.CODE_PORT_CONNECTIONS).
+					WithComponent(c.Name).
+					WithLocation(c.Location())
 				result.Add(err)
 			}
 		}
@@ -296,31 +312,85 @@ func (t *Translator) validateSamplerConnections(h *hpsf.HPSF, templateComps map[
 }
 
 // validateConnectionPorts checks that all connections have valid ports. The name on the connection
-// in hpsf must match the port name on the template component.
+// in hpsf must match the port name on the template component, and the types have to agree: the
+// two template ports must carry the same type of data, and the types declared on the connection
+// must match them.
 func (t *Translator) validateConnectionPorts(h *hpsf.HPSF, templateComps map[string]config.TemplateComponent) validator.Result {
 	result := validator.NewResult("HPSF connection port validation errors")
 	// iterate over the connections and check that the source and destination components have the
 	// specified ports. This is a sanity check to ensure that the connections are valid.
 	for _, conn := range h.Connections {
-		srcComp, ok := templateComps[conn.Source.GetSafeName()]
-		if !ok {
-			continue
+		var srcPort, dstPort *config.TemplatePort
+		if srcComp, ok := templateComps[conn.Source.GetSafeName()]; ok {
+			srcPort = srcComp.GetPort(conn.Source.PortName)
+			if srcPort == nil {
+				err := hpsf.NewErrorf("source component does not have a port called %s", conn.Source.PortName).
+					WithCode(hpsf.CODE_PORT_NOT_FOUND).
+					WithComponent(conn.Source.Component).
+					WithLocation(conn.Source.Location())
+				result.Add(err)
+			}
 		}
 
-		if srcComp.GetPort(conn.Source.PortName) == nil {
-			err := hpsf.NewErrorf("source component does not have a port called %s", conn.Source.PortName).
-				WithComponent(conn.Source.Component)
-			result.Add(err)
+		if dstComp, ok := templateComps[conn.Destination.GetSafeName()]; ok {
+			dstPort = dstComp.GetPort(conn.Destination.PortName)
+			if dstPort == nil {
+				err := hpsf.NewErrorf("destination component does not have a port called %s", conn.Destination.PortName).
+					WithCode(hpsf.CODE_PORT_NOT_FOUND).
+					WithComponent(conn.Destination.Component).
+					WithLocation(conn.Destination.Location())
+				result.Add(err)
+			}
 		}
 
-		dstComp, ok := templateComps[conn.Destination.GetSafeName()]
-		if !ok {
+		// If the ports themselves are incompatible, that's the only thing worth reporting;
+		// whatever types the connection declares, one end will be wrong.
+		if srcPort != nil && dstPort != nil && srcPort.Type != dstPort.Type {
+			err := hpsf.NewErrorf("cannot connect %s port %s.%s to %s port %s.%s",
+				srcPort.Type, conn.Source.Component, conn.Source.PortName,
+				dstPort.Type, conn.Destination.Component, conn.Destination.PortName).
+				WithCode(hpsf.CODE_PORT_TYPE_MISMATCH).
+				WithComponent(conn.Source.Component).
+				WithLocation(conn.Location())
+			result.Add(err)
 			continue
 		}
 
-		if dstComp.GetPort(conn.Destination.PortName) == nil {
-			err := hpsf.NewErrorf("destination component does not have a port called %s", conn.Destination.PortName).
-				WithComponent(conn.Destination.Component)
+		// a missing type is reported on its own; there's nothing to compare it with
+		if conn.Source.Type == "" {
+			result.Add(hpsf.NewError("connection source has no type").
+				WithCode(hpsf.CODE_CONNECTION_INCOMPLETE).
+				WithComponent(conn.Source.Component).
+				WithLocation(conn.Source.Location()))
+		} else if srcPort != nil && srcPort.Type != conn.Source.Type {
+			err := hpsf.NewErrorf("connection source declares type %s but port %s has type %s",
+				conn.Source.Type, conn.Source.PortName, srcPort.Type).
+				WithCode(hpsf.CODE_PORT_TYPE_MISMATCH).
+				WithComponent(conn.Source.Component).
+				WithLocation(conn.Source.Location())
+			result.Add(err)
+		}
+		if conn.Destination.Type == "" {
+			result.Add(hpsf.NewError("connection destination has no type").
+				WithCode(hpsf.CODE_CONNECTION_INCOMPLETE).
+				WithComponent(conn.Destination.Component).
+				WithLocation(conn.Destination.Location()))
+		} else if dstPort != nil && dstPort.Type != conn.Destination.Type {
+			err := hpsf.NewErrorf("connection destination declares type %s but port %s has type %s",
+				conn.Destination.Type, conn.Destination.PortName, dstPort.Type).
+				WithCode(hpsf.CODE_PORT_TYPE_MISMATCH).
+				WithComponent(conn.Destination.Component).
+				WithLocation(conn.Destination.Location())
+			result.Add(err)
+		}
+		// without both template ports, we can still check that the two ends agree with each other
+		if (srcPort == nil || dstPort == nil) && conn.Source.Type != "" && conn.Destination.Type != "" &&
+			conn.Source.Type != conn.Destination.Type {
+			err := hpsf.NewErrorf("connection source type %s does not match destination type %s",
+				conn.Source.Type, conn.Destination.Type).
+				WithCode(hpsf.CODE_PORT_TYPE_MISMATCH).
+				WithComponent(conn.Source.Component).
+				WithLocation(conn.Location())
 			result.Add(err)
 		}
 	}
@@ -328,29 +398,15 @@ func (t *Translator) validateConnectionPorts(h *hpsf.HPSF, templateComps map[str
 }
 
 // findPathComponents finds all the components in paths starting from the given component.
-// It returns a slice of component names that represent all components in paths
-// that start from the given component.
-func (t *Translator) findPathComponents(h *hpsf.HPSF, startComp string) []string {
-	visited := make(map[string]bool)
-	components := make([]string, 0)
-
-	var dfs func(comp string)
-	dfs = func(comp string) {
-		if visited[comp] {
-			return
-		}
-		visited[comp] = true
-		components = append(components, comp)
-
-		// Continue traversing to find all connected components
-		for _, conn := range h.Connections {
-			if conn.Source.GetSafeName() == comp {
-				dfs(conn.Destination.GetSafeName())
-			}
-		}
+// It returns the safe names of the component and of every component downstream of it.
+func (t *Translator) findPathComponents(g *hpsf.Graph, startComp string) []string {
+	var components []string
+	if c := g.Component(startComp); c != nil {
+		components = append(components, c.GetSafeName())
+	}
+	for _, c := range g.Downstream(startComp) {
+		components = append(components, c.GetSafeName())
 	}
-
-	dfs(startComp)
 	return components
 }
 
@@ -361,11 +417,12 @@ func (t *Translator) findPathComponents(h *hpsf.HPSF, startComp string) []string
 // - Every path on a startsampler except the one with the highest index must connect to a condition.
 // - Droppers can terminate a path (since they do not have an output port).
 // - The output of samplers must be connected to an "exporter" component.
-func (t *Translator) validateStartSampling(h *hpsf.HPSF, templateComps map[string]config.TemplateComponent) validator.Result {
+func (t *Translator) validateStartSampling(g *hpsf.Graph, templateComps map[string]config.TemplateComponent) validator.Result {
 	result := validator.NewResult("HPSF start sampling validation errors")
 	startSamplingCount := 0
-	var startSamplingComp string
-	for _, c := range h.Components {
+	var startSamplingComp, startSamplingName string
+	var startSamplingLoc hpsf.Location
+	for _, c := range g.Components() {
 		tmpl, ok := templateComps[c.GetSafeName()]
 		if !ok {
 			continue
@@ -374,16 +431,20 @@ func (t *Translator) validateStartSampling(h *hpsf.HPSF, templateComps map[strin
 		if tmpl.Style == "startsampling" {
 			startSamplingCount++
 			startSamplingComp = c.GetSafeName()
+			startSamplingName = c.Name
+			startSamplingLoc = c.Location()
 			if startSamplingCount > 1 {
 				err := hpsf.NewError("only one StartSampling component is allowed").
-					WithComponent(c.Name)
+					WithCode(hpsf.CODE_SAMPLING_PATHS).
+					WithComponent(c.Name).
+					WithLocation(c.Location())
 				result.Add(err)
 			}
 		}
 	}
 	if startSamplingCount == 0 {
 		// if there is no StartSampling component, we cannot have any samplers in the configuration
-		for _, c := range h.Components {
+		for _, c := range g.Components() {
 			tmpl, ok := templateComps[c.GetSafeName()]
 			if !ok {
 				continue
@@ -391,14 +452,16 @@ func (t *Translator) validateStartSampling(h *hpsf.HPSF, templateComps map[strin
 
 			if tmpl.Style == "sampler" {
 				err := hpsf.NewError("if there is no StartSampling component, no samplers are allowed").
-					WithComponent(c.Name)
+					WithCode(hpsf.CODE_SAMPLING_PATHS).
+					WithComponent(c.Name).
+					WithLocation(c.Location())
 				result.Add(err)
 			}
 		}
 	} else {
 		// if there is a StartSampling component, we must have at least one sampler or dropper in the configuration
 		hasSamplerOrDropper := false
-		for _, c := range h.Components {
+		for _, c := range g.Components() {
 			tmpl, ok := templateComps[c.GetSafeName()]
 			if !ok {
 				continue
@@ -411,23 +474,20 @@ func (t *Translator) validateStartSampling(h *hpsf.HPSF, templateComps map[strin
 		}
 		if !hasSamplerOrDropper {
 			err := hpsf.NewError("if there is a StartSampling component, at least one sampler or dropper is required").
-				WithComponent(startSamplingComp)
+				WithCode(hpsf.CODE_SAMPLING_PATHS).
+				WithComponent(startSamplingComp).
+				WithLocation(startSamplingLoc)
 			result.Add(err)
 		}
 	}
 	// now we need to check that each path from the StartSampling component leads to exactly one sampler or dropper
 	if startSamplingCount == 1 {
 		// Find all connections from StartSampling
-		startSamplingConnections := make([]*hpsf.Connection, 0)
-		for _, conn := range h.Connections {
-			if conn.Source.GetSafeName() == startSamplingComp {
-				startSamplingConnections = append(startSamplingConnections, conn)
-			}
-		}
+		startSamplingConnections := g.Outputs(startSamplingName)
 
 		// For each connection from StartSampling, trace the path to find if it leads to exactly one sampler or dropper
 		for _, startConn := range startSamplingConnections {
-			pathComponents := t.findPathComponents(h, startConn.Destination.GetSafeName())
+			pathComponents := t.findPathComponents(g, startConn.Destination.Component)
 			samplerOrDropperCount := 0
 			for _, comp := range pathComponents {
 				tmpl, ok := templateComps[comp]
@@ -437,7 +497,9 @@ func (t *Translator) validateStartSampling(h *hpsf.HPSF, templateComps map[strin
 			}
 			if samplerOrDropperCount != 1 {
 				err := hpsf.NewError("Each path from StartSampling must lead to exactly one sampler or dropper").
-					WithComponent(startSamplingComp)
+					WithCode(hpsf.CODE_SAMPLING_PATHS).
+					WithComponent(startSamplingComp).
+					WithLocation(startConn.Location())
 				result.Add(err)
 			}
 		}
@@ -459,7 +521,7 @@ func (t *Translator) validateStartSampling(h *hpsf.HPSF, templateComps map[strin
 			portIndex := startSamplingTemplate.GetPortIndex(startConn.Source.PortName)
 			if portIndex != highestIndex {
 				// This path must connect to a condition
-				pathComponents := t.findPathComponents(h, startConn.Destination.GetSafeName())
+				pathComponents := t.findPathComponents(g, startConn.Destination.Component)
 				hasCondition := false
 				for _, comp := range pathComponents {
 					tmpl, ok := templateComps[comp]
@@ -470,7 +532,9 @@ func (t *Translator) validateStartSampling(h *hpsf.HPSF, templateComps map[strin
 				}
 				if !hasCondition {
 					err := hpsf.NewError("Every path on a startsampler except the one with the highest index must connect to a condition").
-						WithComponent(startSamplingComp)
+						WithCode(hpsf.CODE_SAMPLING_PATHS).
+						WithComponent(startSamplingComp).
+						WithLocation(startConn.Location())
 					result.Add(err)
 				}
 			}
@@ -480,13 +544,102 @@ func (t *Translator) validateStartSampling(h *hpsf.HPSF, templateComps map[strin
 	return result
 }
 
+// validateDataFlow looks for components that are valid on their own but can't do anything useful
+// because of how they are connected. These components are silently left out of the generated
+// pipelines, so they are reported as warnings:
+// - receivers with no outgoing connections
+// - processors that never reach an exporter (or a dropper, which ends a path on purpose)
+// - exporters that nothing sends data to
+// - StartSampling components with none of their outputs connected
+func (t *Translator) validateDataFlow(g *hpsf.Graph, templateComps map[string]config.TemplateComponent) validator.Result {
+	result := validator.NewResult("HPSF data flow warnings")
+	for _, c := range g.Components() {
+		tmpl, ok := templateComps[c.GetSafeName()]
+		if !ok {
+			continue
+		}
+
+		switch tmpl.Style {
+		case "receiver":
+			if len(g.Outputs(c.Name)) == 0 {
+				result.Add(hpsf.NewWarning("receiver has no outgoing connections so its data goes nowhere").
+					WithCode(hpsf.CODE_DISCONNECTED_RECEIVER).
+					WithComponent(c.Name).
+					WithLocation(c.Location()))
+			}
+		case "processor":
+			reachesEnd := false
+			for _, comp := range t.findPathComponents(g, c.Name) {
+				if tc, ok := templateComps[comp]; ok && (tc.Style == "exporter" || tc.Style == "dropper") {
+					reachesEnd = true
+					break
+				}
+			}
+			if !reachesEnd {
+				result.Add(hpsf.NewWarning("processor is not connected to an exporter so its data goes nowhere").
+					WithCode(hpsf.CODE_DEAD_END_PROCESSOR).
+					WithComponent(c.Name).
+					WithLocation(c.Location()))
+			}
+		case "exporter":
+			if len(g.Inputs(c.Name)) == 0 {
+				result.Add(hpsf.NewWarning("exporter has no incoming connections so it has nothing to send").
+					WithCode(hpsf.CODE_UNFED_EXPORTER).
+					WithComponent(c.Name).
+					WithLocation(c.Location()))
+			}
+		case "startsampling":
+			if len(g.Outputs(c.Name)) == 0 {
+				result.Add(hpsf.NewWarning("none of the outputs of the StartSampling component are connected").
+					WithCode(hpsf.CODE_UNWIRED_STARTSAMPLING).
+					WithComponent(c.Name).
+					WithLocation(c.Location()))
+			}
+		}
+	}
+	return result
+}
+
 // ValidateConfig validates the configuration of the HPSF document as it stands with respect to the
 // components and templates installed in the translator.
 // Note that it returns a validation.Result so that the errors can be collected and reported in a
 // structured way. This allows for multiple validation errors to be returned at once, rather than
 // stopping at the first error. This is useful for providing feedback to users on multiple issues
 // in their configuration.
+// Warnings, like those about components that are connected in ways that make them
+// useless, don't make a configuration invalid, so they're not included; use
+// ValidateConfigWithWarnings to get them too.
 func (t *Translator) ValidateConfig(h *hpsf.HPSF) error {
+	_, err := t.ValidateConfigWithWarnings(h)
+	return err
+}
+
+// ValidateConfigWithWarnings is like ValidateConfig, but also returns the warnings
+// (problems with severity SEV_WARN) about the document, such as components that can't
+// send their data anywhere, literal secrets, and upgraded components. The warnings
+// are returned even if the document is invalid; err is nil if there are only
+// warnings.
+func (t *Translator) ValidateConfigWithWarnings(h *hpsf.HPSF) (warnings validator.Result, err error) {
+	warnings = validator.NewResult("hpsf validation warnings")
+	err = t.validateConfig(h)
+	problems, ok := err.(validator.Result)
+	if !ok {
+		return warnings, err
+	}
+	errs := validator.NewResult(problems.Msg)
+	for _, d := range problems.Details {
+		if hpsf.OnlyWarnings(d) {
+			warnings.Add(d)
+		} else {
+			errs.Add(d)
+		}
+	}
+	return warnings, errs.ErrOrNil()
+}
+
+// validateConfig does the work of ValidateConfigWithWarnings, returning the
+// warnings and errors together.
+func (t *Translator) validateConfig(h *hpsf.HPSF) error {
 	if h == nil {
 		return errors.New("nil HPSF document provided for validation")
 	}
@@ -496,6 +649,15 @@ func (t *Translator) ValidateConfig(h *hpsf.HPSF) error {
 		return err
 	}
 
+	// containers are validated in terms of the components they expand to
+	h, err := h.Flatten()
+	if err != nil {
+		return err
+	}
+
+	// components from older major versions are validated as they will be used
+	h, upgrades := t.Upgrade(h)
+
 	// We assume that the HPSF document has already been validated for syntax and structure since
 	// it's already in hpsf format. Our goal here is to make sure that the components and templates
 	// can be used to generate a valid configuration. This means checking that all components referenced
@@ -509,10 +671,14 @@ func (t *Translator) ValidateConfig(h *hpsf.HPSF) error {
 		return result
 	}
 
+	g := hpsf.NewGraph(h)
 	result.Add(t.validateProperties(h, templateComps))
 	result.Add(t.validateConnectionPorts(h, templateComps))
-	result.Add(t.validateStartSampling(h, templateComps))
-	result.Add(t.validateSamplerConnections(h, templateComps))
+	result.Add(t.validateStartSampling(g, templateComps))
+	result.Add(t.validatePortConnections(g, templateComps))
+	result.Add(t.validateDataFlow(g, templateComps))
+	result.Add(validateUpgrades(h, upgrades))
+	result.Add(t.validateSecrets(h, templateComps))
 
 	return result.ErrOrNil()
 }
@@ -642,6 +808,19 @@ func orderPaths(paths []hpsf.PathWithConnections, comps *OrderedComponentMap) {
 }
 
 func (t *Translator) GenerateConfig(h *hpsf.HPSF, ct hpsftypes.Type, artifactVersion string, userdata map[string]any) (tmpl.TemplateConfig, error) {
+	return t.generateConfig(h, ct, artifactVersion, userdata, nil)
+}
+
+// generateConfig does the work of GenerateConfig, recording where the parts of
+// the configuration came from in prov if it isn't nil.
+func (t *Translator) generateConfig(h *hpsf.HPSF, ct hpsftypes.Type, artifactVersion string, userdata map[string]any, prov *Provenance) (tmpl.TemplateConfig, error) {
+	// replace any container instances with the components they contain before we go looking for paths
+	h, err := h.Flatten()
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand containers: %w", err)
+	}
+	h, _ = t.Upgrade(h)
+
 	comps := NewOrderedComponentMap()
 	receiverNames := make(map[string]bool)
 	// make all the components
@@ -655,6 +834,12 @@ func (t *Translator) GenerateConfig(h *hpsf.HPSF, ct hpsftypes.Type, artifactVer
 			if tc.Style == "receiver" {
 				receiverNames[c.GetSafeName()] = true
 			}
+			if t.literalSecrets {
+				tc.LiteralSecrets()
+			}
+			if prov != nil {
+				tc.RecordOrigins()
+			}
 		}
 		return nil
 	}
@@ -713,6 +898,7 @@ func (t *Translator) GenerateConfig(h *hpsf.HPSF, ct hpsftypes.Type, artifactVer
 		}
 
 		mergedSomething := false
+		var origins []config.OriginRecord
 		for _, comp := range path.Path {
 			// look up the component in the ordered map
 			c, ok := comps.Get(comp.GetSafeName())
@@ -724,6 +910,9 @@ func (t *Translator) GenerateConfig(h *hpsf.HPSF, ct hpsftypes.Type, artifactVer
 			if err != nil {
 				return nil, err
 			}
+			if tc, ok := c.(*config.TemplateComponent); ok && prov != nil {
+				origins = append(origins, tc.TakeOrigins()...)
+			}
 			if compConfig != nil {
 				if err := composite.Merge(compConfig); err != nil {
 					return nil, fmt.Errorf("failed to merge component config: %w", err)
@@ -733,6 +922,7 @@ func (t *Translator) GenerateConfig(h *hpsf.HPSF, ct hpsftypes.Type, artifactVer
 		}
 		if mergedSomething {
 			composites = append(composites, composite)
+			prov.addPath(origins)
 		}
 	}
 	// If we have multiple pipelines, we need to merge them into a single config.
@@ -840,6 +1030,11 @@ func (t *Translator) Inspect(h hpsf.HPSF) InspectionResult {
 		Components: []ComponentInfo{},
 	}
 
+	// report the components inside containers rather than the containers themselves
+	if flat, err := h.Flatten(); err == nil {
+		h = *flat
+	}
+
 	// Iterate through all components
 	for _, c := range h.Components {
 		// Look up the template for this component
@@ -869,7 +1064,8 @@ func (t *Translator) Inspect(h hpsf.HPSF) InspectionResult {
 	return result
 }
 
-// getProperties extracts all properties from a component, using template defaults as fallback
+// getProperties extracts all properties from a component, using template defaults as fallback.
+// Secrets are redacted unless they refer to an environment variable.
 func getProperties(c *hpsf.Component, tc config.TemplateComponent) map[string]any {
 	properties := make(map[string]any)
 
@@ -882,7 +1078,7 @@ func getProperties(c *hpsf.Component, tc config.TemplateComponent) map[string]an
 		} else {
 			value = templateProperty.Default
 		}
-		properties[templateProperty.Name] = value
+		properties[templateProperty.Name] = templateProperty.Type.Redact(value)
 	}
 
 	return properties
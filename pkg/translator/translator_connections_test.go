@@ -0,0 +1,58 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePortConnections(t *testing.T) {
+	source := config.TemplateComponent{
+		Kind:  "TraceSource",
+		Style: "receiver",
+		Ports: []config.TemplatePort{{Name: "Traces", Direction: "output", Type: hpsf.CTYPE_TRACES}},
+	}
+	sink := config.TemplateComponent{
+		Kind:  "SingleTraceSink",
+		Style: "exporter",
+		Ports: []config.TemplatePort{{Name: "Traces", Direction: "input", Type: hpsf.CTYPE_TRACES, MaxConnections: 1}},
+	}
+	translator := NewEmptyTranslator()
+	translator.InstallComponents(map[string]config.TemplateComponent{
+		source.Kind: source,
+		sink.Kind:   sink,
+	})
+
+	connect := func(from string) *hpsf.Connection {
+		return &hpsf.Connection{
+			Source:      hpsf.ConnectionPort{Component: from, PortName: "Traces", Type: hpsf.CTYPE_TRACES},
+			Destination: hpsf.ConnectionPort{Component: "sink", PortName: "Traces", Type: hpsf.CTYPE_TRACES},
+		}
+	}
+	h := &hpsf.HPSF{
+		Components: []*hpsf.Component{
+			{Name: "a", Kind: source.Kind},
+			{Name: "b", Kind: source.Kind},
+			{Name: "sink", Kind: sink.Kind},
+		},
+		Connections: []*hpsf.Connection{connect("a")},
+	}
+	// b isn't connected yet, which is only worth a warning
+	warnings, err := translator.ValidateConfigWithWarnings(h)
+	require.NoError(t, err)
+	assert.Equal(t, 1, warnings.Len())
+
+	h.Connections = append(h.Connections, connect("b"))
+	err = translator.ValidateConfig(h)
+	require.Error(t, err)
+	result := err.(validator.Result)
+	require.Equal(t, 1, result.Len())
+	herr := result.Details[0].(*hpsf.HPSFError)
+	assert.Equal(t, hpsf.CODE_PORT_CONNECTIONS, herr.Code)
+	assert.Equal(t, "sink", herr.Component)
+	assert.Equal(t, "must have at most one input connection on port Traces (it has 2)", herr.Reason)
+}
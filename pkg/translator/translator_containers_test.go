@@ -0,0 +1,182 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/data"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/hpsftypes"
+	"github.com/honeycombio/hpsf/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the same workflow as containerFlatYAML, but with the exporter side packaged as a container
+const containerYAML = `components:
+  - name: Receiver
+    kind: OTelReceiver
+  - name: Block
+    kind: RedactAndSend
+    properties:
+      - name: Dataset
+        value: my-metrics
+connections:
+  - source:
+      component: Receiver
+      port: Traces
+      type: OTelTraces
+    destination:
+      component: Block
+      port: Traces
+      type: OTelTraces
+  - source:
+      component: Receiver
+      port: Metrics
+      type: OTelMetrics
+    destination:
+      component: Block
+      port: Metrics
+      type: OTelMetrics
+containers:
+  - name: RedactAndSend
+    components:
+      - name: Redact
+        kind: RedactionProcessor
+      - name: Send
+        kind: HoneycombExporter
+    connections:
+      - source:
+          component: Redact
+          port: Traces
+          type: OTelTraces
+        destination:
+          component: Send
+          port: Traces
+          type: OTelTraces
+    ports:
+      - name: Traces
+        component: Redact
+        port: Traces
+      - name: Metrics
+        component: Send
+        port: Metrics
+    props:
+      - name: Dataset
+        component: Send
+        property: MetricsDataset
+`
+
+const containerFlatYAML = `components:
+  - name: Receiver
+    kind: OTelReceiver
+  - name: Block/Redact
+    kind: RedactionProcessor
+  - name: Block/Send
+    kind: HoneycombExporter
+    properties:
+      - name: MetricsDataset
+        value: my-metrics
+connections:
+  - source:
+      component: Receiver
+      port: Traces
+      type: OTelTraces
+    destination:
+      component: Block/Redact
+      port: Traces
+      type: OTelTraces
+  - source:
+      component: Receiver
+      port: Metrics
+      type: OTelMetrics
+    destination:
+      component: Block/Send
+      port: Metrics
+      type: OTelMetrics
+  - source:
+      component: Block/Redact
+      port: Traces
+      type: OTelTraces
+    destination:
+      component: Block/Send
+      port: Traces
+      type: OTelTraces
+`
+
+func TestGenerateConfigWithContainers(t *testing.T) {
+	tr := NewEmptyTranslator()
+	comps, err := data.LoadEmbeddedComponents()
+	require.NoError(t, err)
+	tr.InstallComponents(comps)
+
+	withContainer, err := hpsf.FromYAML(containerYAML)
+	require.NoError(t, err)
+	require.NoError(t, tr.ValidateConfig(&withContainer))
+
+	flat, err := hpsf.FromYAML(containerFlatYAML)
+	require.NoError(t, err)
+	require.NoError(t, tr.ValidateConfig(&flat))
+
+	for _, ct := range []hpsftypes.Type{hpsftypes.CollectorConfig, hpsftypes.RefineryConfig, hpsftypes.RefineryRules} {
+		t.Run(string(ct), func(t *testing.T) {
+			want, err := tr.GenerateConfig(&flat, ct, LatestVersion, nil)
+			require.NoError(t, err)
+			wantYAML, err := want.RenderYAML()
+			require.NoError(t, err)
+
+			got, err := tr.GenerateConfig(&withContainer, ct, LatestVersion, nil)
+			require.NoError(t, err)
+			gotYAML, err := got.RenderYAML()
+			require.NoError(t, err)
+
+			assert.Equal(t, string(wantYAML), string(gotYAML))
+		})
+	}
+
+	// the container's contents show up when inspecting
+	inspected := tr.Inspect(withContainer)
+	require.Len(t, inspected.Filter(Exporters).Components, 1)
+	assert.Equal(t, "Block/Send", inspected.Filter(Exporters).Components[0].Name)
+	assert.Equal(t, "my-metrics", inspected.Filter(Exporters).Components[0].Properties["MetricsDataset"])
+}
+
+func TestValidateConfigWithBadContainerPort(t *testing.T) {
+	tr := NewEmptyTranslator()
+	comps, err := data.LoadEmbeddedComponents()
+	require.NoError(t, err)
+	tr.InstallComponents(comps)
+
+	h, err := hpsf.FromYAML(containerYAML)
+	require.NoError(t, err)
+	// point the public port at a port that the internal component doesn't have
+	h.Containers[0].Ports[0].Port = "Nope"
+
+	err = tr.ValidateConfig(&h)
+	result, ok := err.(validator.Result)
+	require.True(t, ok)
+	require.Equal(t, 1, result.Len())
+	assert.Contains(t, result.Details[0].Error(), "destination component does not have a port called Nope")
+	assert.Contains(t, result.Details[0].Error(), "Block/Redact")
+}
+
+func TestValidateConfigWithContainerNameCollision(t *testing.T) {
+	tr := NewEmptyTranslator()
+	comps, err := data.LoadEmbeddedComponents()
+	require.NoError(t, err)
+	tr.InstallComponents(comps)
+
+	h, err := hpsf.FromYAML(containerYAML)
+	require.NoError(t, err)
+	// a component named by hand like the exporter inside the Block instance
+	h.Components = append(h.Components, &hpsf.Component{Name: "Block/Send", Kind: "HoneycombExporter"})
+
+	err = tr.ValidateConfig(&h)
+	result, ok := err.(validator.Result)
+	require.True(t, ok)
+	require.Equal(t, 1, result.Len())
+	assert.Contains(t, result.Details[0].Error(), "duplicate component name after expanding containers")
+	assert.Contains(t, result.Details[0].Error(), "Block/Send")
+
+	_, err = tr.GenerateConfig(&h, hpsftypes.CollectorConfig, LatestVersion, nil)
+	require.Error(t, err)
+}
@@ -1,10 +1,12 @@
 package translator
 
 import (
+	"os"
 	"testing"
 
 	"github.com/honeycombio/hpsf/pkg/config"
 	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/hpsftypes"
 	"github.com/honeycombio/hpsf/pkg/validator"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -105,4 +107,64 @@ func TestPropertyValidation(t *testing.T) {
 		validationError := err.(validator.Result)
 		assert.Equal(t, 1, validationError.Len())
 	})
+
+	t.Run("Validate should fail when a rule is not a valid sampling rule", func(t *testing.T) {
+		translator := NewEmptyTranslator()
+
+		testComponent := config.TemplateComponent{
+			Kind: "RuleComponent",
+			Properties: []config.TemplateProperty{
+				{Name: "Rule", Type: hpsf.PTYPE_RULE},
+			},
+		}
+
+		translator.InstallComponents(map[string]config.TemplateComponent{
+			testComponent.Kind: testComponent,
+		})
+
+		hpsfDocument := hpsf.HPSF{
+			Components: []*hpsf.Component{
+				{
+					Name: "TestComponent",
+					Kind: testComponent.Kind,
+					Properties: []hpsf.Property{
+						{Name: "Rule", Value: map[string]any{"Scope": "span", "SampleRate": 10}},
+					},
+				},
+			},
+		}
+		require.NoError(t, translator.ValidateConfig(&hpsfDocument))
+
+		hpsfDocument.Components[0].Properties[0].Value = map[string]any{"Conditions": []any{map[string]any{"Field": "error"}}}
+		err := translator.ValidateConfig(&hpsfDocument)
+		require.Error(t, err)
+		report := hpsf.NewReport(err)
+		require.Len(t, report.Problems, 1)
+		assert.Equal(t, hpsf.CODE_INVALID_PROPERTY, report.Problems[0].Code)
+		assert.Equal(t, "Rule", report.Problems[0].Property)
+		require.Len(t, report.Problems[0].Causes, 1)
+		cause := report.Problems[0].Causes[0]
+		assert.Equal(t, "value is not a valid sampling rule", cause.Message)
+		require.Len(t, cause.Causes, 1)
+		assert.Equal(t, "condition 1 must have an Operator", cause.Causes[0].Message)
+	})
+}
+
+// CustomFilterProcessor's Rules are the settings of the collector's filter
+// processor, written out as a map. Workflows that already use it have to
+// keep validating and generating the same config.
+func TestCustomFilterProcessorRules(t *testing.T) {
+	translator := embeddedTranslator(t)
+
+	input, err := os.ReadFile("testdata/hpsf/customfilterprocessor_all.yaml")
+	require.NoError(t, err)
+	h, err := hpsf.FromYAML(string(input))
+	require.NoError(t, err)
+	require.NoError(t, translator.ValidateConfig(&h))
+
+	cfg, err := translator.GenerateConfig(&h, hpsftypes.CollectorConfig, LatestVersion, nil)
+	require.NoError(t, err)
+	out, err := cfg.RenderYAML()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `- attributes["container.name"] == "container_1"`)
 }
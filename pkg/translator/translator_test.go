@@ -22,6 +22,19 @@ import (
 	yamlv3 "gopkg.in/yaml.v3"
 )
 
+// embeddedTranslator returns a translator with the embedded components and
+// templates installed.
+func embeddedTranslator(tb testing.TB) *Translator {
+	trans := NewEmptyTranslator()
+	comps, err := data.LoadEmbeddedComponents()
+	require.NoError(tb, err)
+	trans.InstallComponents(comps)
+	templates, err := data.LoadEmbeddedTemplates()
+	require.NoError(tb, err)
+	trans.InstallTemplates(templates)
+	return trans
+}
+
 func TestThatEachTestFileHasAMatchingComponent(t *testing.T) {
 	deleteExtras := false
 
@@ -398,9 +411,15 @@ func TestTranslator_ValidateBadConfigs(t *testing.T) {
 		{"missing component", "testdata/bad_hpsf/missing_comp.yaml", "destination component not found,source component not found"},
 		{"missing StartSampling", "testdata/bad_hpsf/missing_startsampling.yaml", "no samplers are allowed,exactly one input connection"},
 		{"missing property", "testdata/bad_hpsf/missing_property.yaml", "property not found"},
-		{"missing port", "testdata/bad_hpsf/missing_port.yaml", "source component does not have a port,destination component does not have a port"},
+		{"missing port", "testdata/bad_hpsf/missing_port.yaml", "source component does not have a port,destination component does not have a port," +
+			"must have exactly one input connection on port Sample (it has 0)"},
+		{"mismatched port types", "testdata/bad_hpsf/mismatched_port_types.yaml", "cannot connect OTelLogs port otlp.Logs to SampleData port sampler.Sample," +
+			"connection source declares type OTelLogs but port Metrics has type OTelMetrics," +
+			"connection destination declares type OTelLogs but port Metrics has type OTelMetrics," +
+			"must have exactly one input connection"},
 		{"missing condition on lower index", "testdata/bad_hpsf/missing_condition_on_lower_index.yaml", "Every path on a startsampler except the one with the highest index must connect to a condition"},
 		{"missing component for specified version", "testdata/bad_hpsf/invalid_component_version.yaml", "failed to locate corresponding template component for HoneycombExporter@v999999.1.0"},
+		{"unwired StartSampling", "testdata/bad_hpsf/unwired_startsampling.yaml", "at least one sampler or dropper is required"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -443,6 +462,118 @@ func TestTranslator_ValidateBadConfigs(t *testing.T) {
 	}
 }
 
+func TestTranslator_ValidateConfigWithWarningsSeparatesErrors(t *testing.T) {
+	b, err := os.ReadFile("testdata/bad_hpsf/unwired_startsampling.yaml")
+	require.NoError(t, err)
+	h, err := hpsf.FromYAML(string(b))
+	require.NoError(t, err)
+
+	trans := NewEmptyTranslator()
+	comps, err := data.LoadEmbeddedComponents()
+	require.NoError(t, err)
+	trans.InstallComponents(comps)
+
+	warnings, err := trans.ValidateConfigWithWarnings(&h)
+	result, ok := err.(validator.Result)
+	require.True(t, ok)
+	require.Equal(t, 1, result.Len())
+	assert.Contains(t, result.Details[0].Error(), "at least one sampler or dropper is required")
+	require.Equal(t, 1, warnings.Len())
+	assert.Contains(t, warnings.Details[0].Error(), "W: none of the outputs of the StartSampling component are connected")
+}
+
+func TestTranslator_ValidateConfigReportsLocations(t *testing.T) {
+	file := "testdata/bad_hpsf/missing_port.yaml"
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	h, err := hpsf.FromNamedYAML(file, string(b))
+	require.NoError(t, err)
+
+	trans := NewEmptyTranslator()
+	comps, err := data.LoadEmbeddedComponents()
+	require.NoError(t, err)
+	trans.InstallComponents(comps)
+
+	err = trans.ValidateConfig(&h)
+	result, ok := err.(validator.Result)
+	require.True(t, ok)
+	require.Equal(t, 3, result.Len())
+
+	// the port errors point at the source and destination of the offending connection
+	var herr *hpsf.HPSFError
+	require.True(t, errors.As(result.Details[0], &herr))
+	assert.Equal(t, hpsf.Location{File: file, Line: 17, Column: 7}, herr.Location)
+	assert.Equal(t, hpsf.CODE_PORT_NOT_FOUND, herr.Code)
+	require.True(t, errors.As(result.Details[1], &herr))
+	assert.Equal(t, hpsf.Location{File: file, Line: 21, Column: 7}, herr.Location)
+	assert.Contains(t, herr.Error(), file+":21:7")
+
+	// and the sampler, which is left without an input, points at itself
+	require.True(t, errors.As(result.Details[2], &herr))
+	assert.Equal(t, hpsf.Location{File: file, Line: 6, Column: 5}, herr.Location)
+	assert.Equal(t, hpsf.CODE_PORT_CONNECTIONS, herr.Code)
+}
+
+func TestTranslator_ValidateConnectionPortsMissingType(t *testing.T) {
+	// HPSF.Validate rejects these first, so check the translator's own
+	// report of them directly
+	h, err := hpsf.FromYAML(`components:
+  - name: otlp
+    kind: OTelReceiver
+  - name: honeycomb
+    kind: OTelGRPCExporter
+connections:
+  - source: {component: otlp, port: Traces}
+    destination: {component: honeycomb, port: Traces, type: OTelTraces}
+`)
+	require.NoError(t, err)
+
+	trans := embeddedTranslator(t)
+	templateComps, result := trans.getMatchingTemplateComponents(&h)
+	require.True(t, result.IsEmpty())
+
+	result = trans.validateConnectionPorts(&h, templateComps)
+	require.Equal(t, 1, result.Len())
+	var herr *hpsf.HPSFError
+	require.True(t, errors.As(result.Details[0], &herr))
+	assert.Equal(t, "connection source has no type", herr.Reason)
+	assert.Equal(t, hpsf.CODE_CONNECTION_INCOMPLETE, herr.Code)
+	assert.Equal(t, "otlp", herr.Component)
+}
+
+func TestTranslator_ValidateConfigWarnings(t *testing.T) {
+	b, err := os.ReadFile("testdata/bad_hpsf/dead_ends.yaml")
+	require.NoError(t, err)
+	h, err := hpsf.FromYAML(string(b))
+	require.NoError(t, err)
+
+	trans := NewEmptyTranslator()
+	comps, err := data.LoadEmbeddedComponents()
+	require.NoError(t, err)
+	trans.InstallComponents(comps)
+
+	// warnings don't make the configuration invalid
+	require.NoError(t, trans.ValidateConfig(&h))
+
+	warnings, err := trans.ValidateConfigWithWarnings(&h)
+	require.NoError(t, err)
+	assert.True(t, hpsf.OnlyWarnings(warnings))
+	got := []string{}
+	for _, detail := range warnings.Details {
+		var herr *hpsf.HPSFError
+		require.True(t, errors.As(detail, &herr))
+		assert.Equal(t, hpsf.SEV_WARN, herr.Severity)
+		got = append(got, herr.Component+": "+herr.Reason)
+	}
+	assert.Equal(t, []string{
+		"idle: receiver has no outgoing connections so its data goes nowhere",
+		"dedup: processor is not connected to an exporter so its data goes nowhere",
+		"dedup_again: processor is not connected to an exporter so its data goes nowhere",
+		"unused: exporter has no incoming connections so it has nothing to send",
+	}, got)
+}
+
 func TestTranslator_ValidateValidConfigs(t *testing.T) {
 	tests := []struct {
 		name string
@@ -2912,3 +3043,47 @@ func TestVersionError_IsAndAs_Integration(t *testing.T) {
 		t.Fatal("errors.Is should have identified VersionError")
 	}
 }
+
+// pipelineDocument makes a document with n OTel receivers, each sending traces
+// through its own redaction processor to its own debug exporter and to one
+// shared Honeycomb exporter.
+func pipelineDocument(n int) *hpsf.HPSF {
+	h := &hpsf.HPSF{}
+	connect := func(src, dst, port string, ctype hpsf.ConnectionType) {
+		h.Connections = append(h.Connections, &hpsf.Connection{
+			Source:      hpsf.ConnectionPort{Component: src, PortName: port, Type: ctype},
+			Destination: hpsf.ConnectionPort{Component: dst, PortName: port, Type: ctype},
+		})
+	}
+	h.Components = append(h.Components, &hpsf.Component{Name: "Honeycomb", Kind: "HoneycombExporter"})
+	for i := range n {
+		recv, proc, exp := fmt.Sprintf("Receiver %d", i), fmt.Sprintf("Redaction %d", i), fmt.Sprintf("Debug %d", i)
+		h.Components = append(h.Components,
+			&hpsf.Component{Name: recv, Kind: "OTelReceiver"},
+			&hpsf.Component{Name: proc, Kind: "RedactionProcessor"},
+			&hpsf.Component{Name: exp, Kind: "DebugExporter"},
+		)
+		connect(recv, proc, "Traces", hpsf.CTYPE_TRACES)
+		connect(proc, exp, "Traces", hpsf.CTYPE_TRACES)
+		connect(proc, "Honeycomb", "Traces", hpsf.CTYPE_TRACES)
+	}
+	return h
+}
+
+func BenchmarkTranslator_ValidateConfig(b *testing.B) {
+	trans := embeddedTranslator(b)
+	h := pipelineDocument(100)
+	require.NoError(b, trans.ValidateConfig(h))
+	for b.Loop() {
+		_ = trans.ValidateConfig(h)
+	}
+}
+
+func BenchmarkTranslator_GenerateConfig(b *testing.B) {
+	trans := embeddedTranslator(b)
+	h := pipelineDocument(100)
+	for b.Loop() {
+		_, err := trans.GenerateConfig(h, hpsftypes.CollectorConfig, LatestVersion, nil)
+		require.NoError(b, err)
+	}
+}
@@ -0,0 +1,153 @@
+package translator
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/validator"
+)
+
+// Upgrade brings components that were written against an older major version
+// of their template up to the template's version, by applying the upgrade
+// rules declared in the template (see config.ComponentUpgrade). It returns the
+// upgraded copy along with a list of the changes that were made; the original
+// document is not modified. Components inside containers are upgraded too,
+// along with the container's references to their properties and ports.
+//
+// Components that don't need upgrading, or can't be upgraded because their
+// template doesn't say how, are left alone; ValidateConfig reports the latter.
+// GenerateConfig and ValidateConfig upgrade documents automatically, so this
+// is only needed to see or save the upgraded document.
+func (t *Translator) Upgrade(h *hpsf.HPSF) (*hpsf.HPSF, []Fix) {
+	h = h.Clone()
+	fixes := []Fix{}
+	for _, c := range h.Components {
+		fixes = append(fixes, t.upgradeComponent(c, h.Connections, nil, nil)...)
+	}
+	for i := range h.Containers {
+		cont := &h.Containers[i]
+		for j := range cont.Components {
+			fixes = append(fixes, t.upgradeComponent(&cont.Components[j], cont.Connections, cont.Ports, cont.Props)...)
+		}
+	}
+	return h, fixes
+}
+
+// upgradeComponent applies the upgrades for one component, updating the
+// connections and container references that use its property and port names.
+func (t *Translator) upgradeComponent(c *hpsf.Component, conns []*hpsf.Connection, ports []hpsf.PublicPort, props []hpsf.PublicProp) []Fix {
+	tc, ok := t.components[c.Kind]
+	if !ok || componentVersionSupported(tc.Version, c.Version) {
+		return nil
+	}
+	plan, ok := tc.UpgradePlan(c.Version)
+	if !ok {
+		return nil
+	}
+
+	description := fmt.Sprintf("upgraded from version %s to %s", c.Version, tc.Version)
+	fixes := []Fix{{Component: c.Name, Description: description}}
+	for _, u := range plan {
+		if u.Description != "" {
+			fixes = append(fixes, Fix{Component: c.Name, Description: u.Description})
+		}
+		fixes = append(fixes, upgradeProperties(c, u, props)...)
+		fixes = append(fixes, upgradePorts(c, u, conns, ports)...)
+	}
+	c.Version = tc.Version
+	return fixes
+}
+
+func upgradeProperties(c *hpsf.Component, u config.ComponentUpgrade, props []hpsf.PublicProp) []Fix {
+	var fixes []Fix
+	properties := make([]hpsf.Property, 0, len(c.Properties))
+	for _, p := range c.Properties {
+		if slices.Contains(u.RemoveProperties, p.Name) {
+			fixes = append(fixes, Fix{
+				Component:   c.Name,
+				Property:    p.Name,
+				Description: "removed because it no longer exists",
+			})
+			continue
+		}
+		if v, ok := u.MapValue(p.Name, p.Value); ok {
+			fixes = append(fixes, Fix{
+				Component:   c.Name,
+				Property:    p.Name,
+				Description: fmt.Sprintf("changed value from %v to %v", p.Value, v),
+			})
+			p.Value = v
+		}
+		if name, ok := u.RenameProperties[p.Name]; ok {
+			fixes = append(fixes, Fix{
+				Component:   c.Name,
+				Property:    p.Name,
+				Description: fmt.Sprintf("renamed to %s", name),
+			})
+			p.Name = name
+		}
+		properties = append(properties, p)
+	}
+	c.Properties = properties
+
+	// a container can expose a property that the instance hasn't set
+	for i, pp := range props {
+		if pp.Component != c.Name {
+			continue
+		}
+		if name, ok := u.RenameProperties[pp.Property]; ok {
+			props[i].Property = name
+		}
+	}
+	return fixes
+}
+
+func upgradePorts(c *hpsf.Component, u config.ComponentUpgrade, conns []*hpsf.Connection, ports []hpsf.PublicPort) []Fix {
+	var fixes []Fix
+	for _, conn := range conns {
+		for _, cp := range []*hpsf.ConnectionPort{&conn.Source, &conn.Destination} {
+			if cp.Component != c.Name {
+				continue
+			}
+			name, ok := u.RenamePorts[cp.PortName]
+			if !ok {
+				continue
+			}
+			fixes = append(fixes, Fix{
+				Component:   c.Name,
+				Description: fmt.Sprintf("renamed port %s to %s on connection %s", cp.PortName, name, connectionString(conn)),
+			})
+			cp.PortName = name
+		}
+	}
+	for i, pp := range ports {
+		if pp.Component != c.Name {
+			continue
+		}
+		if name, ok := u.RenamePorts[pp.Port]; ok {
+			ports[i].Port = name
+		}
+	}
+	return fixes
+}
+
+// validateUpgrades reports the changes made by Upgrade as warnings, so that
+// ValidateConfig can say what it did to a document before validating it.
+func validateUpgrades(h *hpsf.HPSF, fixes []Fix) validator.Result {
+	result := validator.NewResult("HPSF component upgrade warnings")
+	for _, f := range fixes {
+		warning := hpsf.NewWarning(f.Description).
+			WithCode(hpsf.CODE_COMPONENT_UPGRADED).
+			WithComponent(f.Component).
+			WithProperty(f.Property)
+		for _, c := range h.Components {
+			if c.Name == f.Component {
+				warning = warning.WithLocation(c.Location())
+			}
+		}
+		result.Add(warning)
+	}
+	return result
+}
@@ -0,0 +1,187 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/honeycombio/hpsf/pkg/config"
+	"github.com/honeycombio/hpsf/pkg/data"
+	"github.com/honeycombio/hpsf/pkg/hpsf"
+	"github.com/honeycombio/hpsf/pkg/hpsftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUpgradeTranslator returns a translator in which OTelReceiver is at v2,
+// having renamed its GRPC and HTTP port properties and its Traces port along
+// the way, and DebugExporter is at v1, having changed its verbosity levels.
+func newUpgradeTranslator(t *testing.T) *Translator {
+	comps, err := data.LoadEmbeddedComponents()
+	require.NoError(t, err)
+
+	otlp := comps["OTelReceiver"]
+	otlp.Version = "v2.0.0"
+	otlp.Upgrades = []config.ComponentUpgrade{
+		{
+			From:             "v0",
+			RenameProperties: map[string]string{"GRPCListenPort": "GRPCPort"},
+			RenamePorts:      map[string]string{"Spans": "Traces"},
+		},
+		{
+			From:             "v1",
+			RenameProperties: map[string]string{"HTTPListenPort": "HTTPPort"},
+		},
+	}
+	comps["OTelReceiver"] = otlp
+
+	debug := comps["DebugExporter"]
+	debug.Version = "v1.0.0"
+	debug.Upgrades = []config.ComponentUpgrade{{
+		From:             "v0",
+		Description:      "verbosity levels now match the collector's",
+		RemoveProperties: []string{"Color"},
+		MapValues:        map[string]map[string]any{"Verbosity": {"loud": "detailed"}},
+	}}
+	comps["DebugExporter"] = debug
+
+	tr := NewEmptyTranslator()
+	tr.InstallComponents(comps)
+	return tr
+}
+
+const upgradeTestYAML = `components:
+  - name: otlp
+    kind: OTelReceiver
+    version: v0.1.0
+    properties:
+      - name: GRPCListenPort
+        value: 5000
+      - name: HTTPListenPort
+        value: 5001
+  - name: debug
+    kind: DebugExporter
+    version: v0.1.0
+    properties:
+      - name: Verbosity
+        value: loud
+      - name: Color
+        value: true
+connections:
+  - source:
+      component: otlp
+      port: Spans
+      type: OTelTraces
+    destination:
+      component: debug
+      port: Traces
+      type: OTelTraces
+`
+
+func TestTranslator_Upgrade(t *testing.T) {
+	h, err := hpsf.FromYAML(upgradeTestYAML)
+	require.NoError(t, err)
+	tr := newUpgradeTranslator(t)
+
+	upgraded, fixes := tr.Upgrade(&h)
+	got := []string{}
+	for _, f := range fixes {
+		got = append(got, f.String())
+	}
+	assert.Equal(t, []string{
+		"otlp: upgraded from version v0.1.0 to v2.0.0",
+		"otlp.GRPCListenPort: renamed to GRPCPort",
+		"otlp: renamed port Spans to Traces on connection otlp.Spans -> debug.Traces",
+		"otlp.HTTPListenPort: renamed to HTTPPort",
+		"debug: upgraded from version v0.1.0 to v1.0.0",
+		"debug: verbosity levels now match the collector's",
+		"debug.Verbosity: changed value from loud to detailed",
+		"debug.Color: removed because it no longer exists",
+	}, got)
+
+	otlp := componentNamed(upgraded, "otlp")
+	assert.Equal(t, "v2.0.0", otlp.Version)
+	assert.Equal(t, 5000, otlp.GetProperty("GRPCPort").Value)
+	assert.Equal(t, 5001, otlp.GetProperty("HTTPPort").Value)
+	debug := componentNamed(upgraded, "debug")
+	require.Len(t, debug.Properties, 1)
+	assert.Equal(t, "detailed", debug.GetProperty("Verbosity").Value)
+	assert.Equal(t, "Traces", upgraded.Connections[0].Source.PortName)
+
+	// the original is unchanged
+	assert.Equal(t, "v0.1.0", componentNamed(&h, "otlp").Version)
+	assert.Equal(t, "Spans", h.Connections[0].Source.PortName)
+
+	// upgrading again finds nothing to do
+	_, fixes = tr.Upgrade(upgraded)
+	assert.Empty(t, fixes)
+}
+
+func TestTranslator_UpgradeIsAutomatic(t *testing.T) {
+	h, err := hpsf.FromYAML(upgradeTestYAML)
+	require.NoError(t, err)
+	tr := newUpgradeTranslator(t)
+
+	// the upgraded document is valid, and the changes are reported as warnings
+	warnings, err := tr.ValidateConfigWithWarnings(&h)
+	require.NoError(t, err)
+	report := hpsf.NewReport(warnings)
+	assert.Equal(t, 8, report.Warnings)
+	require.Len(t, report.Problems, 8)
+	assert.Equal(t, hpsf.CODE_COMPONENT_UPGRADED, report.Problems[0].Code)
+	assert.Equal(t, "otlp", report.Problems[0].Component)
+
+	cfg, err := tr.GenerateConfig(&h, hpsftypes.CollectorConfig, LatestVersion, nil)
+	require.NoError(t, err)
+	out, err := cfg.RenderYAML()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), ":5000")
+	assert.Contains(t, string(out), "verbosity: detailed")
+}
+
+func TestTranslator_UpgradeWithoutRules(t *testing.T) {
+	h, err := hpsf.FromYAML(strings.ReplaceAll(upgradeTestYAML, "v0.1.0", "v3.0.0"))
+	require.NoError(t, err)
+	tr := newUpgradeTranslator(t)
+
+	// components that are newer than their templates can't be upgraded
+	upgraded, fixes := tr.Upgrade(&h)
+	assert.Empty(t, fixes)
+	assert.Equal(t, h.Components, upgraded.Components)
+
+	err = tr.ValidateConfig(&h)
+	require.Error(t, err)
+	assert.False(t, hpsf.OnlyWarnings(err))
+	report := hpsf.NewReport(err)
+	require.NotEmpty(t, report.Problems)
+	assert.Equal(t, hpsf.CODE_UNKNOWN_KIND, report.Problems[0].Code)
+	assert.Equal(t, "failed to locate corresponding template component for OTelReceiver@v3.0.0", report.Problems[0].Message)
+}
+
+func TestTranslator_UpgradeContainers(t *testing.T) {
+	h, err := hpsf.FromYAML(`containers:
+  - name: Intake
+    components:
+      - name: otlp
+        kind: OTelReceiver
+        version: v0.1.0
+    ports:
+      - name: Out
+        component: otlp
+        port: Spans
+    props:
+      - name: Port
+        component: otlp
+        property: GRPCListenPort
+components:
+  - name: in
+    kind: Intake
+`)
+	require.NoError(t, err)
+	upgraded, fixes := newUpgradeTranslator(t).Upgrade(&h)
+	require.Len(t, fixes, 1)
+	cont := upgraded.Containers[0]
+	assert.Equal(t, "v2.0.0", cont.Components[0].Version)
+	assert.Equal(t, "Traces", cont.Ports[0].Port)
+	assert.Equal(t, "GRPCPort", cont.Props[0].Property)
+	assert.Equal(t, "Spans", h.Containers[0].Ports[0].Port)
+}